@@ -8,6 +8,7 @@ CGO_ENABLED=0
 GOOS=linux
 GOARCH=amd64
 go build -v -ldflags "-extldflags \"-static\" -X main.version=${BUILD_VERSION} -X main.buildInfo=${BUILD_INFO}" -o /bin/k8s-bigip-ctlr $REPOPATH/cmd/k8s-bigip-ctlr
+go build -v -ldflags "-extldflags \"-static\" -X main.version=${BUILD_VERSION} -X main.buildInfo=${BUILD_INFO}" -o /bin/cisctl $REPOPATH/cmd/cisctl
 
 RUN_TESTS=${RUN_TESTS:-1}
 
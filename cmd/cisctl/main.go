@@ -0,0 +1,90 @@
+/*-
+ * Copyright (c) 2017-2021 F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// cisctl works with the CIS configuration without a cluster or a BIG-IP
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	log "github.com/F5Networks/k8s-bigip-ctlr/pkg/vlogger"
+	clog "github.com/F5Networks/k8s-bigip-ctlr/pkg/vlogger/console"
+)
+
+// Set by the build
+var (
+	version   string
+	buildInfo string
+)
+
+// registerLogger registers the console logger once, as run is called more than once in tests
+var registerLogger sync.Once
+
+const usage = `cisctl works with the CIS configuration without a cluster or a BIG-IP.
+
+Usage:
+  cisctl render [flags]   Print the AS3 declaration for Kubernetes manifests
+  cisctl version          Print the version
+
+Run 'cisctl render --help' for the render flags.
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("no command given")
+	}
+	switch args[0] {
+	case "render":
+		return runRender(args[1:], out)
+	case "version":
+		fmt.Fprintf(out, "Version: %s, BuildInfo: %s\n", version, buildInfo)
+		return nil
+	case "help", "-h", "--help":
+		fmt.Fprint(out, usage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+func initLogger(logLevel string) error {
+	registerLogger.Do(func() {
+		log.RegisterLogger(
+			log.LL_MIN_LEVEL, log.LL_MAX_LEVEL, clog.NewConsoleLogger())
+	})
+
+	if ll := log.NewLogLevel(logLevel); nil != ll {
+		if *ll != log.GetLogLevel() {
+			log.SetLogLevel(*ll)
+		}
+	} else {
+		return fmt.Errorf("Unknown log level requested: %s\n"+
+			"    Valid log levels are: DEBUG, INFO, WARNING, ERROR, CRITICAL", logLevel)
+	}
+	return nil
+}
@@ -0,0 +1,346 @@
+/*-
+ * Copyright (c) 2017-2021 F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	cisscheme "github.com/F5Networks/k8s-bigip-ctlr/config/client/clientset/versioned/scheme"
+	"github.com/F5Networks/k8s-bigip-ctlr/pkg/agent/as3"
+	"github.com/F5Networks/k8s-bigip-ctlr/pkg/appmanager"
+	"github.com/F5Networks/k8s-bigip-ctlr/pkg/crmanager"
+	"github.com/F5Networks/k8s-bigip-ctlr/pkg/resource"
+	flag "github.com/spf13/pflag"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// renderOptions are the CIS options which affect the declaration, they
+// are named after the flags of the controller
+type renderOptions struct {
+	filenames          []string
+	output             string
+	logLevel           string
+	customResourceMode bool
+	partition          string
+	namespaces         []string
+	poolMemberType     string
+	useNodeInternal    bool
+	shareNodes         bool
+	defaultRouteDomain int
+	manageIngress      bool
+	manageIngressClass bool
+	ingressClass       string
+	manageConfigMaps   bool
+	defaultIngIP       string
+	vsSnatPoolName     string
+	overrideAS3Cfgmap  string
+}
+
+func newRenderFlags(opts *renderOptions) *flag.FlagSet {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	flags.StringArrayVarP(&opts.filenames, "filename", "f", []string{},
+		"Required, manifest file or directory of manifests to render, may be repeated.")
+	flags.StringVarP(&opts.output, "output", "o", "",
+		"Optional, file to write the declaration to instead of stdout.")
+	flags.StringVar(&opts.logLevel, "log-level", "WARNING",
+		"Optional, logging level")
+	flags.BoolVar(&opts.customResourceMode, "custom-resource-mode", false,
+		"Optional, render the F5 Custom Resources instead of Ingresses and ConfigMaps.")
+	flags.StringVar(&opts.partition, "bigip-partition", "",
+		"Required, partition for the Big-IP kubernetes objects.")
+	flags.StringArrayVar(&opts.namespaces, "namespace", []string{},
+		"Optional, Kubernetes namespace(s) watched by the controller, all namespaces if left blank.")
+	flags.StringVar(&opts.poolMemberType, "pool-member-type", "nodeport",
+		"Optional, type of BIG-IP pool members to create, 'nodeport' or 'cluster'.")
+	flags.BoolVar(&opts.useNodeInternal, "use-node-internal", true,
+		"Optional, provide kubernetes InternalIP addresses to pool")
+	flags.BoolVar(&opts.shareNodes, "share-nodes", false,
+		"Optional, when set to true, node will be shared among partition.")
+	flags.IntVar(&opts.defaultRouteDomain, "default-route-domain", 0,
+		"Optional, CIS uses this value as default Route Domain in BIG-IP ")
+	flags.BoolVar(&opts.manageIngress, "manage-ingress", true,
+		"Optional, specify whether or not to manage Ingress resources")
+	flags.BoolVar(&opts.manageIngressClass, "manage-ingress-class-only", false,
+		"Optional, process only the Ingresses of the ingress class.")
+	flags.StringVar(&opts.ingressClass, "ingress-class", "f5",
+		"Optional, the class of the Ingresses processed by the controller.")
+	flags.BoolVar(&opts.manageConfigMaps, "manage-configmaps", true,
+		"Optional, specify whether or not to manage ConfigMap resources")
+	flags.StringVar(&opts.defaultIngIP, "default-ingress-ip", "",
+		"Optional, IP address for any Ingress with the annotation "+
+			"'virtual-server.f5.com/ip:controller-default'.")
+	flags.StringVar(&opts.vsSnatPoolName, "vs-snat-pool-name", "",
+		"Optional, SNAT pool referenced by each virtual server.")
+	flags.StringVar(&opts.overrideAS3Cfgmap, "override-as3-declaration", "",
+		"Optional, <namespace>/<name> of the ConfigMap overriding the AS3 declaration.")
+	return flags
+}
+
+func runRender(args []string, out io.Writer) error {
+	opts := &renderOptions{}
+	flags := newRenderFlags(opts)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of cisctl render:\n%s", flags.FlagUsages())
+	}
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if err := initLogger(opts.logLevel); err != nil {
+		return err
+	}
+	if len(opts.filenames) == 0 {
+		return fmt.Errorf("missing required parameter --filename")
+	}
+	if opts.partition == "" {
+		return fmt.Errorf("missing required parameter --bigip-partition")
+	}
+	if opts.poolMemberType != "nodeport" && opts.poolMemberType != "cluster" {
+		return fmt.Errorf("'%v' is not a valid Pool Member Type", opts.poolMemberType)
+	}
+
+	objs, err := readManifests(opts.filenames)
+	if err != nil {
+		return err
+	}
+	if opts.poolMemberType == "cluster" && !hasNodes(objs) {
+		// Pods are considered to be running on the nodes named by the Endpoints
+		objs = append(objs, getEndpointsNodes(objs)...)
+	}
+	decl, err := renderDeclaration(opts, objs)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(decl), "", "  "); err != nil {
+		return fmt.Errorf("invalid declaration: %v", err)
+	}
+	buf.WriteString("\n")
+	if opts.output != "" {
+		return ioutil.WriteFile(opts.output, buf.Bytes(), 0644)
+	}
+	_, err = out.Write(buf.Bytes())
+	return err
+}
+
+// renderDeclaration returns the AS3 declaration CIS posts for the objects
+func renderDeclaration(opts *renderOptions, objs []runtime.Object) (string, error) {
+	userAgent := fmt.Sprintf("CIS/v%v cisctl", version)
+	resource.DEFAULT_PARTITION = opts.partition
+
+	if opts.customResourceMode {
+		return crmanager.RenderDeclaration(
+			crmanager.Params{
+				Namespaces:         opts.namespaces,
+				Partition:          opts.partition,
+				ControllerMode:     opts.poolMemberType,
+				UseNodeInternal:    opts.useNodeInternal,
+				ShareNodes:         opts.shareNodes,
+				DefaultRouteDomain: opts.defaultRouteDomain,
+			},
+			userAgent,
+			objs,
+		)
+	}
+
+	appmanager.RegisterBigIPSchemaTypes()
+	rsReq, err := appmanager.RenderResourceRequest(
+		&appmanager.Params{
+			UseNodeInternal:        opts.useNodeInternal,
+			IsNodePort:             opts.poolMemberType == "nodeport",
+			DefaultIngIP:           opts.defaultIngIP,
+			VsSnatPoolName:         opts.vsSnatPoolName,
+			ManageConfigMaps:       opts.manageConfigMaps,
+			ManageIngress:          opts.manageIngress,
+			ManageIngressClassOnly: opts.manageIngressClass,
+			IngressClass:           opts.ingressClass,
+			DgPath:                 strings.Join([]string{opts.partition, "Shared"}, "/"),
+			ProcessAgentLabels:     getProcessAgentLabelFunc(opts.overrideAS3Cfgmap),
+			UserAgent:              userAgent,
+		},
+		opts.namespaces,
+		objs,
+	)
+	if err != nil {
+		return "", err
+	}
+	as3Manager := as3.NewAS3Manager(&as3.Params{
+		UserAgent:           userAgent,
+		OverriderCfgMapName: opts.overrideAS3Cfgmap,
+		ShareNodes:          opts.shareNodes,
+	})
+	return as3Manager.RenderDeclaration(rsReq), nil
+}
+
+// getProcessAgentLabelFunc selects the ConfigMaps processed by the AS3 agent
+func getProcessAgentLabelFunc(overrideAS3Cfgmap string) func(map[string]string, string, string) bool {
+	return func(m map[string]string, n, ns string) bool {
+		if m["overrideAS3"] == "true" || m["overrideAS3"] == "false" {
+			return overrideAS3Cfgmap == "" || overrideAS3Cfgmap == ns+"/"+n
+		}
+		return m["as3"] == "true" || m["as3"] == "false"
+	}
+}
+
+// readManifests decodes the Kubernetes objects from the files, directories
+// are read for the .yaml, .yml and .json files in them
+func readManifests(filenames []string) ([]runtime.Object, error) {
+	if err := cisscheme.AddToScheme(scheme.Scheme); err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, name := range filenames {
+		info, err := os.Stat(name)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, name)
+			continue
+		}
+		entries, err := ioutil.ReadDir(name)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			switch filepath.Ext(entry.Name()) {
+			case ".yaml", ".yml", ".json":
+				if !entry.IsDir() {
+					files = append(files, filepath.Join(name, entry.Name()))
+				}
+			}
+		}
+	}
+
+	var objs []runtime.Object
+	for _, file := range files {
+		fileObjs, err := readManifest(file)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %v", file, err)
+		}
+		objs = append(objs, fileObjs...)
+	}
+	return objs, nil
+}
+
+func readManifest(file string) ([]runtime.Object, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var objs []runtime.Object
+	reader := yaml.NewYAMLReader(bufio.NewReader(f))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			return objs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		docObjs, err := decodeObject(doc)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, docObjs...)
+	}
+}
+
+// decodeObject decodes the YAML or JSON document, items of a List are returned
+// as objects
+func decodeObject(doc []byte) ([]runtime.Object, error) {
+	jsonDoc, err := yaml.ToJSON(doc)
+	if err != nil {
+		return nil, err
+	}
+	if string(jsonDoc) == "null" {
+		return nil, nil
+	}
+	obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(jsonDoc, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := obj.(*v1.List)
+	if !ok {
+		return []runtime.Object{obj}, nil
+	}
+	var objs []runtime.Object
+	for _, item := range list.Items {
+		itemObjs, err := decodeObject(item.Raw)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, itemObjs...)
+	}
+	return objs, nil
+}
+
+func hasNodes(objs []runtime.Object) bool {
+	for _, obj := range objs {
+		if _, ok := obj.(*v1.Node); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// getEndpointsNodes returns the nodes hosting the addresses of the Endpoints
+func getEndpointsNodes(objs []runtime.Object) []runtime.Object {
+	var nodes []runtime.Object
+	found := make(map[string]bool)
+	for _, obj := range objs {
+		eps, ok := obj.(*v1.Endpoints)
+		if !ok {
+			continue
+		}
+		for _, subset := range eps.Subsets {
+			for _, addr := range subset.Addresses {
+				if addr.NodeName == nil || found[*addr.NodeName] {
+					continue
+				}
+				found[*addr.NodeName] = true
+				node := &v1.Node{}
+				node.Name = *addr.NodeName
+				node.Status.Addresses = []v1.NodeAddress{
+					{Type: v1.NodeInternalIP, Address: addr.IP},
+					{Type: v1.NodeExternalIP, Address: addr.IP},
+				}
+				nodes = append(nodes, node)
+			}
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,166 @@
+/*-
+ * Copyright (c) 2017-2021 F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/config/apis/cis/v1"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	netv1 "k8s.io/api/networking/v1"
+)
+
+const virtualServerManifest = `
+apiVersion: cis.f5.com/v1
+kind: VirtualServer
+metadata:
+  name: cafe
+  namespace: default
+  labels:
+    f5cr: "true"
+spec:
+  host: cafe.example.com
+  virtualServerAddress: 172.16.3.4
+  pools:
+  - path: /coffee
+    service: svc-1
+    servicePort: 80
+---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Service
+  metadata:
+    name: svc-1
+    namespace: default
+  spec:
+    ports:
+    - name: http
+      port: 80
+- apiVersion: v1
+  kind: Endpoints
+  metadata:
+    name: svc-1
+    namespace: default
+  subsets:
+  - addresses:
+    - ip: 10.244.1.5
+      nodeName: node-1
+    ports:
+    - name: http
+      port: 80
+`
+
+const ingressManifest = `
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: ing1
+  namespace: default
+  annotations:
+    virtual-server.f5.com/ip: 10.1.1.1
+spec:
+  ingressClassName: f5
+  defaultBackend:
+    service:
+      name: svc-1
+      port:
+        number: 80
+---
+apiVersion: networking.k8s.io/v1
+kind: IngressClass
+metadata:
+  name: f5
+spec:
+  controller: f5.com/cntr-ingress-svcs
+`
+
+var _ = Describe("Render Tests", func() {
+	var manifestDir string
+
+	BeforeEach(func() {
+		var err error
+		manifestDir, err = ioutil.TempDir("", "cisctl-render")
+		Expect(err).To(BeNil())
+		Expect(ioutil.WriteFile(filepath.Join(manifestDir, "vs.yaml"),
+			[]byte(virtualServerManifest), 0644)).To(BeNil())
+		Expect(ioutil.WriteFile(filepath.Join(manifestDir, "ingress.yml"),
+			[]byte(ingressManifest), 0644)).To(BeNil())
+		Expect(ioutil.WriteFile(filepath.Join(manifestDir, "README.md"),
+			[]byte("not a manifest"), 0644)).To(BeNil())
+	})
+	AfterEach(func() {
+		os.RemoveAll(manifestDir)
+	})
+
+	It("reads the objects from the manifests", func() {
+		objs, err := readManifests([]string{manifestDir})
+		Expect(err).To(BeNil())
+		Expect(len(objs)).To(Equal(5))
+		Expect(objs[0]).To(BeAssignableToTypeOf(&netv1.Ingress{}))
+		Expect(objs[1]).To(BeAssignableToTypeOf(&netv1.IngressClass{}))
+		Expect(objs[2]).To(BeAssignableToTypeOf(&cisapiv1.VirtualServer{}))
+		Expect(objs[3]).To(BeAssignableToTypeOf(&v1.Service{}), "List items should be read")
+		Expect(objs[4]).To(BeAssignableToTypeOf(&v1.Endpoints{}), "List items should be read")
+
+		nodes := getEndpointsNodes(objs)
+		Expect(len(nodes)).To(Equal(1))
+		Expect(nodes[0].(*v1.Node).Name).To(Equal("node-1"))
+
+		_, err = readManifests([]string{filepath.Join(manifestDir, "README.md")})
+		Expect(err).NotTo(BeNil(), "Invalid manifest should fail")
+	})
+
+	It("renders the declaration of Custom Resources", func() {
+		var out bytes.Buffer
+		err := run([]string{"render", "--custom-resource-mode", "--bigip-partition", "test",
+			"--pool-member-type", "cluster", "-f", manifestDir}, &out)
+		Expect(err).To(BeNil())
+		Expect(out.String()).To(ContainSubstring(`"crd_172_16_3_4_80": {`))
+		Expect(out.String()).To(ContainSubstring(`"10.244.1.5"`), "Pool member not rendered")
+		Expect(out.String()).NotTo(ContainSubstring(`"ingress_10_1_1_1_80"`))
+	})
+
+	It("renders the declaration of Ingresses", func() {
+		outputFile := filepath.Join(manifestDir, "decl.json")
+		err := run([]string{"render", "--bigip-partition", "test", "--pool-member-type", "cluster",
+			"-f", filepath.Join(manifestDir, "vs.yaml"), "-f", filepath.Join(manifestDir, "ingress.yml"),
+			"-o", outputFile}, &bytes.Buffer{})
+		Expect(err).To(BeNil())
+		decl, err := ioutil.ReadFile(outputFile)
+		Expect(err).To(BeNil())
+		Expect(string(decl)).To(ContainSubstring(`"ingress_10_1_1_1_80": {`))
+		Expect(string(decl)).To(ContainSubstring(`"10.244.1.5"`), "Pool member not rendered")
+		Expect(string(decl)).NotTo(ContainSubstring(`"crd_172_16_3_4_80"`))
+	})
+
+	It("validates the arguments", func() {
+		Expect(run([]string{"render", "-f", manifestDir}, &bytes.Buffer{})).NotTo(BeNil(),
+			"Partition is required")
+		Expect(run([]string{"render", "--bigip-partition", "test"}, &bytes.Buffer{})).NotTo(BeNil(),
+			"Manifests are required")
+		Expect(run([]string{"render", "--bigip-partition", "test", "-f", manifestDir,
+			"--pool-member-type", "invalid"}, &bytes.Buffer{})).NotTo(BeNil())
+		Expect(run([]string{"unknown"}, &bytes.Buffer{})).NotTo(BeNil())
+	})
+})
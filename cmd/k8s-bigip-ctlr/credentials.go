@@ -0,0 +1,129 @@
+/*-
+ * Copyright (c) 2017-2021 F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	log "github.com/F5Networks/k8s-bigip-ctlr/pkg/vlogger"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// credentialsPollInterval is the interval to check the credentials directories
+// and the trusted certificates ConfigMap for changes. Kubelet updates the mounted
+// Secrets periodically as well.
+var credentialsPollInterval = 30 * time.Second
+
+// bigIPCredentials holds the credentials and the trusted certificates of the BIG-IPs
+type bigIPCredentials struct {
+	username     string
+	password     string
+	gtmUsername  string
+	gtmPassword  string
+	gtmURL       string
+	trustedCerts string
+}
+
+func getCurrentCredentials(trustedCerts string) bigIPCredentials {
+	return bigIPCredentials{
+		username:     *bigIPUsername,
+		password:     *bigIPPassword,
+		gtmUsername:  *gtmBigIPUsername,
+		gtmPassword:  *gtmBigIPPassword,
+		gtmURL:       *gtmBigIPURL,
+		trustedCerts: trustedCerts,
+	}
+}
+
+// readCredentialsFile returns the content of a file in the credentials directory,
+// ok is false when the file cannot be read
+func readCredentialsFile(dir, name string) (content string, ok bool) {
+	fileBytes, err := ioutil.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return "", false
+	}
+	return string(fileBytes), true
+}
+
+// reloadCredentials reads the credentials directories and the trusted certificates
+// ConfigMap again, the current credentials are kept for the missing files.
+// The BIG-IP URLs are kept, changing them requires a restart.
+func reloadCredentials(current bigIPCredentials) (bigIPCredentials, error) {
+	creds := current
+	if len(*credsDir) > 0 {
+		if usr, ok := readCredentialsFile(*credsDir, "username"); ok {
+			creds.username = strings.TrimSpace(usr)
+		}
+		if pass, ok := readCredentialsFile(*credsDir, "password"); ok {
+			creds.password = strings.TrimSpace(pass)
+		}
+		if bigipURL, ok := readCredentialsFile(*credsDir, "url"); ok {
+			urls, err := parseBigIPURLs(strings.TrimSpace(bigipURL))
+			if err == nil && !reflect.DeepEqual(urls, bigIPURLs) {
+				log.Warningf("[INIT] Changing the BIG-IP URL requires a restart of CIS, using %v", bigIPURLs)
+			}
+		}
+	}
+	if len(*gtmCredsDir) > 0 {
+		if usr, ok := readCredentialsFile(*gtmCredsDir, "username"); ok {
+			creds.gtmUsername = usr
+		}
+		if pass, ok := readCredentialsFile(*gtmCredsDir, "password"); ok {
+			creds.gtmPassword = pass
+		}
+		if gtmURL, ok := readCredentialsFile(*gtmCredsDir, "url"); ok {
+			if !strings.HasPrefix(gtmURL, "https://") {
+				gtmURL = "https://" + gtmURL
+			}
+			creds.gtmURL = gtmURL
+		}
+	}
+	if len(*trustedCertsCfgmap) > 0 {
+		trustedCerts, err := readBIGIPTrustedCerts()
+		if err != nil {
+			return current, err
+		}
+		creds.trustedCerts = trustedCerts
+	}
+	return creds, nil
+}
+
+// watchCredentials calls update with the BIG-IP credentials and trusted
+// certificates when they change, without restarting CIS
+func watchCredentials(creds bigIPCredentials, update func(bigIPCredentials), stopCh <-chan struct{}) {
+	if len(*credsDir) == 0 && len(*gtmCredsDir) == 0 && len(*trustedCertsCfgmap) == 0 {
+		return
+	}
+	go wait.Until(func() {
+		newCreds, err := reloadCredentials(creds)
+		if err != nil {
+			log.Errorf("[INIT] Failed to reload the BIG-IP credentials, using the current ones: %v", err)
+			return
+		}
+		if newCreds == creds {
+			return
+		}
+		log.Info("[INIT] BIG-IP credentials or trusted certificates changed, updating them")
+		update(newCreds)
+		creds = newCreds
+	}, credentialsPollInterval, stopCh)
+}
@@ -0,0 +1,130 @@
+/*-
+ * Copyright (c) 2017-2021 F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/F5Networks/k8s-bigip-ctlr/pkg/vlogger"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+	retryPeriod   = 2 * time.Second
+)
+
+// getLeaseName returns the name of the Lease used for the leader election.
+// Controllers managing the same partition compete for the same Lease.
+func getLeaseName(partition string) string {
+	if len(*leaderElectionLeaseName) != 0 {
+		return *leaderElectionLeaseName
+	}
+	name := strings.ToLower(partition)
+	name = strings.Replace(name, "_", "-", -1)
+	name = strings.Replace(name, ".", "-", -1)
+	return "k8s-bigip-ctlr-" + strings.Trim(name, "-")
+}
+
+// newLeaseLock returns the Lease lock identified by the hostname of the controller
+func newLeaseLock(client kubernetes.Interface, partition string) (*resourcelock.LeaseLock, error) {
+	id, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the hostname for the leader election identity: %v", err)
+	}
+	return &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      getLeaseName(partition),
+			Namespace: *leaderElectionNamespace,
+		},
+		Client: client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: id,
+		},
+	}, nil
+}
+
+// startLeaderElection campaigns for the Lease and calls onStartedLeading once
+// the controller is elected as the leader. The controller exits when it loses
+// the Lease, so that it restarts as a follower.
+// The returned function releases the Lease and waits for the election to end.
+func startLeaderElection(
+	client kubernetes.Interface,
+	partition string,
+	onStartedLeading func(),
+) (func(), error) {
+	lock, err := newLeaseLock(client, partition)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   leaseDuration,
+		RenewDeadline:   renewDeadline,
+		RetryPeriod:     retryPeriod,
+		ReleaseOnCancel: true,
+		Name:            lock.LeaseMeta.Name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(context.Context) {
+				log.Infof("[INIT] Elected as the leader: %v", lock.Identity())
+				onStartedLeading()
+			},
+			OnStoppedLeading: func() {
+				if ctx.Err() != nil {
+					log.Infof("[INIT] Released the leader election lease %v/%v",
+						lock.LeaseMeta.Namespace, lock.LeaseMeta.Name)
+					return
+				}
+				log.Fatalf("[INIT] Lost the leader election lease %v/%v",
+					lock.LeaseMeta.Namespace, lock.LeaseMeta.Name)
+			},
+			OnNewLeader: func(identity string) {
+				if identity != lock.Identity() {
+					log.Infof("[INIT] Following the leader: %v", identity)
+				}
+			},
+		},
+	})
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create the leader elector: %v", err)
+	}
+
+	log.Infof("[INIT] Waiting to be elected as the leader using the lease %v/%v",
+		lock.LeaseMeta.Namespace, lock.LeaseMeta.Name)
+	done := make(chan struct{})
+	go func() {
+		elector.Run(ctx)
+		close(done)
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}, nil
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/F5Networks/k8s-bigip-ctlr/pkg/health"
 	"github.com/F5Networks/k8s-bigip-ctlr/pkg/pollers"
 	bigIPPrometheus "github.com/F5Networks/k8s-bigip-ctlr/pkg/prometheus"
+	"github.com/F5Networks/k8s-bigip-ctlr/pkg/tokenmanager"
 	"github.com/F5Networks/k8s-bigip-ctlr/pkg/vxlan"
 	"github.com/F5Networks/k8s-bigip-ctlr/pkg/writer"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -41,6 +42,7 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
@@ -130,6 +132,8 @@ var (
 	httpAddress      *string
 	dgPath           string
 	disableTeems     *bool
+	dryRun           *bool
+	outputDeclDir    *string
 
 	namespaces             *[]string
 	useNodeInternal        *bool
@@ -150,14 +154,22 @@ var (
 	manageIngressClassOnly *bool
 	ingressClass           *string
 
+	enableLeaderElection    *bool
+	leaderElectionNamespace *string
+	leaderElectionLeaseName *string
+
 	bigIPURL                  *string
+	multiBigIPMode            *string
 	bigIPUsername             *string
 	bigIPPassword             *string
 	bigIPPartitions           *[]string
 	credsDir                  *string
+	tokenAuth                 *bool
+	loginProvider             *string
 	as3Validation             *bool
 	sslInsecure               *bool
 	ipam                      *bool
+	ipamConfigMap             *string
 	enableTLS                 *string
 	tls13CipherGroupReference *string
 	ciphers                   *string
@@ -188,6 +200,7 @@ var (
 	gtmCredsDir      *string
 
 	// package variables
+	bigIPURLs          []string
 	isNodePort         bool
 	watchAllNamespaces bool
 	vxlanName          string
@@ -195,6 +208,7 @@ var (
 	agRspChan          chan interface{}
 	eventChan          chan interface{}
 	configWriter       writer.Writer
+	declWriter         *writer.DeclarationWriter
 	k8sVersion         string
 )
 
@@ -238,6 +252,11 @@ func _init() {
 	disableTeems = globalFlags.Bool("disable-teems", false,
 		"Optional, flag to disable sending telemetry data to TEEM")
 	// Custom Resource
+	dryRun = globalFlags.Bool("dry-run", false,
+		"Optional, run the controller without posting the AS3 declarations to BIG-IP. "+
+			"Declarations are written to stdout, or to output-declaration-dir, with the diff against the previous declaration.")
+	outputDeclDir = globalFlags.String("output-declaration-dir", "",
+		"Optional, directory to write the AS3 declarations to, enables dry-run mode.")
 	customResourceMode = globalFlags.Bool("custom-resource-mode", false,
 		"Optional, When set to true, controller processes only F5 Custom Resources.")
 	defaultRouteDomain = globalFlags.Int("default-route-domain", 0,
@@ -249,7 +268,12 @@ func _init() {
 
 	// BigIP flags
 	bigIPURL = bigIPFlags.String("bigip-url", "",
-		"Required, URL for the Big-IP")
+		"Required, URL for the Big-IP. Comma separated URLs configure more than one BIG-IP, "+
+			"supported only in custom-resource-mode.")
+	multiBigIPMode = bigIPFlags.String("multi-bigip-mode", crmanager.MultiBIGIPModeActive,
+		"Optional, how the declarations are posted when more than one BIG-IP is configured. "+
+			"'active' posts only to the active BIG-IP of the HA group, detected through its failover status. "+
+			"'all' posts to each of the BIG-IPs independently.")
 	bigIPUsername = bigIPFlags.String("bigip-username", "",
 		"Required, user name for the Big-IP user account.")
 	bigIPPassword = bigIPFlags.String("bigip-password", "",
@@ -259,12 +283,21 @@ func _init() {
 	credsDir = bigIPFlags.String("credentials-directory", "",
 		"Optional, directory that contains the BIG-IP username, password, and/or "+
 			"url files. To be used instead of username, password, and/or url arguments.")
+	tokenAuth = bigIPFlags.Bool("bigip-token-auth", false,
+		"Optional, when set to true, authenticate to BIG-IP with an auth token, which is reused "+
+			"until it expires, instead of Basic auth on every request.")
+	loginProvider = bigIPFlags.String("bigip-login-provider", tokenmanager.DefaultLoginProvider,
+		"Optional, login provider of the BIG-IP auth token, like tmos, radius, tacacs or ldap.")
 	as3Validation = bigIPFlags.Bool("as3-validation", true,
 		"Optional, when set to false, disables as3 template validation on the controller.")
 	sslInsecure = bigIPFlags.Bool("insecure", false,
 		"Optional, when set to true, enable insecure SSL communication to BIGIP.")
 	ipam = bigIPFlags.Bool("ipam", false,
 		"Optional, when set to true, enable ipam feature for CRD.")
+	ipamConfigMap = bigIPFlags.String("ipam-configmap", "",
+		"Optional, provide Namespace and Name of the ConfigMap as <namespace>/<configmap-name> mapping "+
+			"ipamLabels to comma separated CIDR ranges. With --ipam, CIS allocates the addresses from these ranges "+
+			"itself instead of using F5 IPAM Controller and stores them in the <configmap-name>-allocations ConfigMap.")
 	as3PostDelay = bigIPFlags.Int("as3-post-delay", 0,
 		"Optional, time (in seconds) that CIS waits to post the available AS3 declaration.")
 	logAS3Response = bigIPFlags.Bool("log-as3-response", false,
@@ -342,6 +375,15 @@ func _init() {
 			"Additionally, the Ingress controller processes Ingress resources that do not have that annotation,"+
 			"which can be disabled by setting the `-manage-ingress-class-only` flag")
 
+	enableLeaderElection = kubeFlags.Bool("enable-leader-election", false,
+		"Optional, when set to true, run CIS with more than one replica. Only the replica "+
+			"elected as the leader posts the configuration to BIG-IP, the others take over when it is lost.")
+	leaderElectionNamespace = kubeFlags.String("leader-election-namespace", "kube-system",
+		"Optional, namespace of the Lease used for the leader election.")
+	leaderElectionLeaseName = kubeFlags.String("leader-election-lease-name", "",
+		"Optional, name of the Lease used for the leader election. "+
+			"Defaults to k8s-bigip-ctlr-<bigip-partition>.")
+
 	// If the flag is specified with no argument, default to LOOKUP
 	kubeFlags.Lookup("resolve-ingress-names").NoOptDefVal = "LOOKUP"
 
@@ -466,8 +508,25 @@ func verifyArgs() error {
 		}
 	}
 
+	if len(*outputDeclDir) != 0 {
+		*dryRun = true
+	}
+
+	if *multiBigIPMode != crmanager.MultiBIGIPModeActive && *multiBigIPMode != crmanager.MultiBIGIPModeAll {
+		return fmt.Errorf("'%v' is not a valid multi-bigip-mode, supported modes are '%v' and '%v'",
+			*multiBigIPMode, crmanager.MultiBIGIPModeActive, crmanager.MultiBIGIPModeAll)
+	}
+
+	if len(*ipamConfigMap) != 0 && len(strings.Split(*ipamConfigMap, "/")) != 2 {
+		return fmt.Errorf("invalid ipam-configmap '%v', expected <namespace>/<configmap-name>", *ipamConfigMap)
+	}
+
+	if *dryRun && !(*customResourceMode) && strings.ToLower(*agent) != cisAgent.AS3Agent {
+		return fmt.Errorf("dry-run is supported only with the as3 agent")
+	}
+
 	if (len(*bigIPURL) == 0 || len(*bigIPUsername) == 0 ||
-		len(*bigIPPassword) == 0) && len(*credsDir) == 0 {
+		len(*bigIPPassword) == 0) && len(*credsDir) == 0 && !(*dryRun) {
 		return fmt.Errorf("Missing BIG-IP credentials info")
 	}
 
@@ -523,6 +582,9 @@ func verifyArgs() error {
 			return fmt.Errorf("Missing required parameter route-vserver-addr")
 		}
 	}
+	if *enableLeaderElection && len(*leaderElectionNamespace) == 0 {
+		return fmt.Errorf("Missing required parameter leader-election-namespace")
+	}
 	if *overriderAS3CfgmapName != "" {
 		if len(strings.Split(*overriderAS3CfgmapName, "/")) != 2 {
 			return fmt.Errorf("Invalid value provided for --override-as3-declaration" +
@@ -573,21 +635,40 @@ func getCredentials() error {
 			return err
 		}
 	}
-	// Verify URL is valid
-	if !strings.HasPrefix(*bigIPURL, "https://") {
-		*bigIPURL = "https://" + *bigIPURL
+	urls, err := parseBigIPURLs(*bigIPURL)
+	if err != nil {
+		return err
 	}
-	u, err := url.Parse(*bigIPURL)
-	if nil != err {
-		return fmt.Errorf("Error parsing url: %s", err)
-	}
-	if len(u.Path) > 0 && u.Path != "/" {
-		return fmt.Errorf("BIGIP-URL path must be empty or '/'; check URL formatting and/or remove %s from path",
-			u.Path)
+	if len(urls) > 1 && !(*customResourceMode) {
+		return fmt.Errorf("More than one BIG-IP URL is supported only in custom-resource-mode")
 	}
+	bigIPURLs = urls
+	// The first BIG-IP is used for the python driver and GTM defaults
+	*bigIPURL = bigIPURLs[0]
 	return nil
 }
 
+// parseBigIPURLs verifies the URLs are valid, more than one BIG-IP is given as comma separated URLs
+func parseBigIPURLs(bigipURLs string) ([]string, error) {
+	var urls []string
+	for _, bigipURL := range strings.Split(bigipURLs, ",") {
+		bigipURL = strings.TrimSpace(bigipURL)
+		if !strings.HasPrefix(bigipURL, "https://") {
+			bigipURL = "https://" + bigipURL
+		}
+		u, err := url.Parse(bigipURL)
+		if nil != err {
+			return nil, fmt.Errorf("Error parsing url: %s", err)
+		}
+		if len(u.Path) > 0 && u.Path != "/" {
+			return nil, fmt.Errorf("BIGIP-URL path must be empty or '/'; check URL formatting and/or remove %s from path",
+				u.Path)
+		}
+		urls = append(urls, bigipURL)
+	}
+	return urls, nil
+}
+
 func getGTMCredentials() {
 	if len(*gtmCredsDir) > 0 {
 		var usr, pass, gtmBigipURL string
@@ -752,35 +833,48 @@ func setupWatchers(appMgr *appmanager.Manager, resyncPeriod time.Duration) {
 	}
 }
 
-func initCustomResourceManager(
-	config *rest.Config,
-) *crmanager.CRManager {
-
-	postMgrParams := crmanager.PostParams{
-		BIGIPUsername: *bigIPUsername,
-		BIGIPPassword: *bigIPPassword,
-		BIGIPURL:      *bigIPURL,
-		TrustedCerts:  "",
-		SSLInsecure:   true,
-		AS3PostDelay:  *as3PostDelay,
-		LogResponse:   *logAS3Response,
+// getCRPostParams returns the parameters to post to BIG-IP in custom resource mode.
+// The BIG-IP certificate is verified only with the trusted certificates configured.
+func getCRPostParams(creds bigIPCredentials) crmanager.PostParams {
+	return crmanager.PostParams{
+		BIGIPUsername:  creds.username,
+		BIGIPPassword:  creds.password,
+		BIGIPURL:       *bigIPURL,
+		BIGIPURLs:      bigIPURLs,
+		MultiBIGIPMode: *multiBigIPMode,
+		TrustedCerts:   creds.trustedCerts,
+		SSLInsecure:    len(*trustedCertsCfgmap) == 0 || *sslInsecure,
+		AS3PostDelay:   *as3PostDelay,
+		LogResponse:    *logAS3Response,
+		TokenAuth:      *tokenAuth,
+		LoginProvider:  *loginProvider,
 	}
+}
 
-	GtmParams := crmanager.GTMParams{
-		GTMBigIpUsername: *gtmBigIPUsername,
-		GTMBigIpPassword: *gtmBigIPPassword,
-		GTMBigIpUrl:      *gtmBigIPURL,
+func getGTMParams(creds bigIPCredentials) crmanager.GTMParams {
+	return crmanager.GTMParams{
+		GTMBigIpUsername: creds.gtmUsername,
+		GTMBigIpPassword: creds.gtmPassword,
+		GTMBigIpUrl:      creds.gtmURL,
 	}
+}
+
+func initCustomResourceManager(
+	config *rest.Config,
+	creds bigIPCredentials,
+) *crmanager.CRManager {
 
 	agentParams := crmanager.AgentParams{
-		PostParams:     postMgrParams,
-		GTMParams:      GtmParams,
+		PostParams:     getCRPostParams(creds),
+		GTMParams:      getGTMParams(creds),
 		Partition:      (*bigIPPartitions)[0],
 		LogLevel:       *logLevel,
 		VerifyInterval: *verifyInterval,
 		VXLANName:      vxlanName,
 		PythonBaseDir:  *pythonBaseDir,
 		UserAgent:      getUserAgentInfo(),
+
+		DeclarationWriter: getDeclarationWriter(),
 	}
 	agent := crmanager.NewAgent(agentParams)
 
@@ -798,8 +892,11 @@ func initCustomResourceManager(
 			NodePollInterval:   *nodePollInterval,
 			NodeLabelSelector:  *nodeLabelSelector,
 			IPAM:               *ipam,
+			IPAMConfigMap:      *ipamConfigMap,
 			ShareNodes:         *shareNodes,
 			DefaultRouteDomain: *defaultRouteDomain,
+			DryRun:             *dryRun,
+			LeaderElection:     *enableLeaderElection,
 		},
 	)
 
@@ -936,20 +1033,48 @@ func main() {
 		log.Debug("Telemetry data reporting to TEEM server is disabled")
 	}
 
+	// Metrics are registered before creating the work queues to report their metrics
+	bigIPPrometheus.RegisterMetrics()
+
 	if *customResourceMode {
 		getGTMCredentials()
-		crMgr := initCustomResourceManager(config)
+		creds := getCurrentCredentials(getBIGIPTrustedCerts())
+		crMgr := initCustomResourceManager(config, creds)
+		// Expose Prometheus metrics
+		http.Handle("/metrics", promhttp.Handler())
+		go func() {
+			log.Fatal(http.ListenAndServe(*httpAddress, nil).Error())
+		}()
 		crMgr.TeemData = td
-		err = crMgr.Agent.GetBigipAS3Version()
-		if err != nil {
-			log.Errorf("%v", err)
-			crMgr.Stop()
-			os.Exit(1)
+		if !(*dryRun) {
+			err = crMgr.Agent.GetBigipAS3Version()
+			if err != nil {
+				log.Errorf("%v", err)
+				crMgr.Stop()
+				os.Exit(1)
+			}
 		}
+		var stopLeaderElection func()
+		if *enableLeaderElection {
+			stopLeaderElection, err = startLeaderElection(kubeClient, (*bigIPPartitions)[0], crMgr.StartLeading)
+			if err != nil {
+				log.Errorf("%v", err)
+				crMgr.Stop()
+				os.Exit(1)
+			}
+		}
+		stopCh := make(chan struct{})
+		watchCredentials(creds, func(newCreds bigIPCredentials) {
+			crMgr.Agent.UpdateCredentials(getCRPostParams(newCreds), getGTMParams(newCreds))
+		}, stopCh)
 		sigs := make(chan os.Signal, 1)
 		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 		sig := <-sigs
+		close(stopCh)
 		crMgr.Stop()
+		if stopLeaderElection != nil {
+			stopLeaderElection()
+		}
 		log.Infof("Exiting - signal %v\n", sig)
 		return
 	}
@@ -975,11 +1100,15 @@ func main() {
 		BigIPPartitions: *bigIPPartitions,
 	}
 
-	subPidCh, err := startPythonDriver(getConfigWriter(), gs, bs, *pythonBaseDir)
-	if nil != err {
-		log.Fatalf("Could not initialize subprocess configuration: %v", err)
+	// Python driver configures BIG-IP, which is not required in dry-run mode
+	subPid := 0
+	if !(*dryRun) {
+		subPidCh, err := startPythonDriver(getConfigWriter(), gs, bs, *pythonBaseDir)
+		if nil != err {
+			log.Fatalf("Could not initialize subprocess configuration: %v", err)
+		}
+		subPid = <-subPidCh
 	}
-	subPid := <-subPidCh
 	defer func(pid int) {
 		if 0 != pid {
 			var proc *os.Process
@@ -1036,7 +1165,10 @@ func main() {
 
 	// Cleanup DEFAULT_PARTITION_AS3 partitions
 	//TODO: Remove this post CIS2.2
-	appMgr.AgentCIS.Remove(resource.DEFAULT_PARTITION)
+	// With leader election, only the leader cleans up the partitions
+	if !(*enableLeaderElection) {
+		appMgr.AgentCIS.Remove(resource.DEFAULT_PARTITION)
+	}
 	appMgr.K8sVersion = getk8sVersion()
 	appMgr.TeemData = td
 	GetNamespaces(appMgr)
@@ -1057,9 +1189,9 @@ func main() {
 	// Add health check e.g. is Python process still there?
 	hc := &health.HealthChecker{
 		SubPID: subPid,
+		DryRun: *dryRun,
 	}
 	http.Handle("/health", hc.HealthCheckHandler())
-	bigIPPrometheus.RegisterMetrics()
 	go func() {
 		log.Fatal(http.ListenAndServe(*httpAddress, nil).Error())
 	}()
@@ -1068,10 +1200,37 @@ func main() {
 
 	appMgr.Run(stopCh)
 
+	watchCredentials(getCurrentCredentials(getBIGIPTrustedCerts()), func(newCreds bigIPCredentials) {
+		if updater, ok := appMgr.AgentCIS.(cisAgent.CredentialsUpdater); ok {
+			updater.UpdateCredentials(newCreds.username, newCreds.password, newCreds.trustedCerts, *sslInsecure)
+		}
+		if subPid != 0 {
+			bs.BigIPUsername = newCreds.username
+			bs.BigIPPassword = newCreds.password
+			if err := writeDriverSection(getConfigWriter(), "bigip", bs); err != nil {
+				log.Errorf("[INIT] Failed to update the BIG-IP credentials of the python driver: %v", err)
+			}
+		}
+	}, stopCh)
+
+	var stopLeaderElection func()
+	if *enableLeaderElection {
+		stopLeaderElection, err = startLeaderElection(kubeClient, (*bigIPPartitions)[0], func() {
+			appMgr.AgentCIS.Remove(resource.DEFAULT_PARTITION)
+			appMgr.StartLeading()
+		})
+		if err != nil {
+			log.Fatalf("[INIT] %v", err)
+		}
+	}
+
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 	sig := <-sigs
 	close(stopCh)
+	if stopLeaderElection != nil {
+		stopLeaderElection()
+	}
 	log.Infof("[INIT] Exiting - signal %v\n", sig)
 }
 
@@ -1087,6 +1246,22 @@ func getConfigWriter() writer.Writer {
 	return configWriter
 }
 
+// getDeclarationWriter returns the writer for the AS3 declarations in dry-run mode
+func getDeclarationWriter() *writer.DeclarationWriter {
+	if !(*dryRun) {
+		return nil
+	}
+	if declWriter == nil {
+		var err error
+		declWriter, err = writer.NewDeclarationWriter(*outputDeclDir)
+		if nil != err {
+			log.Fatalf("[INIT] Failed creating DeclarationWriter: %v", err)
+			os.Exit(1)
+		}
+	}
+	return declWriter
+}
+
 func getRouteConfig() appmanager.RouteConfig {
 	return appmanager.RouteConfig{
 		RouteVSAddr: *routeVserverAddr,
@@ -1118,6 +1293,7 @@ func getAppManagerParams() appmanager.Params {
 		AgRspChan:              agRspChan,
 		SchemaLocal:            *schemaLocal,
 		ProcessAgentLabels:     getProcessAgentLabelFunc(),
+		LeaderElection:         *enableLeaderElection,
 	}
 }
 
@@ -1146,6 +1322,8 @@ func getAS3Params() *as3.Params {
 		BIGIPURL:                  *bigIPURL,
 		TrustedCerts:              getBIGIPTrustedCerts(),
 		SSLInsecure:               *sslInsecure,
+		TokenAuth:                 *tokenAuth,
+		LoginProvider:             *loginProvider,
 		IPAM:                      *ipam,
 		AS3PostDelay:              *as3PostDelay,
 		LogResponse:               *logAS3Response,
@@ -1154,6 +1332,7 @@ func getAS3Params() *as3.Params {
 		UserAgent:                 getUserAgentInfo(),
 		ConfigWriter:              getConfigWriter(),
 		EventChan:                 eventChan,
+		DeclarationWriter:         getDeclarationWriter(),
 	}
 }
 
@@ -1189,25 +1368,39 @@ func getKubeConfig() (*rest.Config, error) {
 
 // Read certificate from configmap
 func getBIGIPTrustedCerts() string {
+	certs, err := readBIGIPTrustedCerts()
+	if err != nil {
+		log.Errorf("[INIT] %v", err)
+		os.Exit(1)
+	}
+	return certs
+}
+
+// readBIGIPTrustedCerts reads the certificates in the trusted-certs-cfgmap
+func readBIGIPTrustedCerts() (string, error) {
 	namespaceCfgmapSlice := strings.Split(*trustedCertsCfgmap, "/")
 	if len(namespaceCfgmapSlice) != 2 {
 		log.Debugf("[INIT] Invalid trusted-certs-cfgmap option provided.")
-		return ""
+		return "", nil
 	}
 
 	cm, err := getConfigMapUsingNamespaceAndName(namespaceCfgmapSlice[0], namespaceCfgmapSlice[1])
 	if err != nil {
-		log.Errorf("[INIT] ConfigMap with name %v not found in namespace: %v, error: %v",
+		return "", fmt.Errorf("ConfigMap with name %v not found in namespace: %v, error: %v",
 			namespaceCfgmapSlice[1], namespaceCfgmapSlice[0], err)
-		os.Exit(1)
 	}
 
+	// Fetch all certificates from configmap, in the order of the keys to compare them
+	var keys []string
+	for k := range cm.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 	var certs string
-	// Fetch all certificates from configmap
-	for _, v := range cm.Data {
-		certs += v + "\n"
+	for _, k := range keys {
+		certs += cm.Data[k] + "\n"
 	}
-	return certs
+	return certs, nil
 }
 
 func getConfigMapUsingNamespaceAndName(cfgMapNamespace, cfgMapName string) (*v1.ConfigMap, error) {
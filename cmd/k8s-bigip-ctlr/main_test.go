@@ -323,6 +323,57 @@ var _ = Describe("Main Tests", func() {
 			Expect(err).ToNot(BeNil())
 		})
 
+		It("verifies dry-run args", func() {
+			defer _init()
+			os.Args = []string{
+				"./bin/k8s-bigip-ctlr",
+				"--namespace=testing",
+				"--bigip-partition=velcro1",
+				"--output-declaration-dir=/tmp/declarations",
+			}
+
+			flags.Parse(os.Args)
+			err := verifyArgs()
+			Expect(err).To(BeNil(), "BIG-IP credentials are not required in dry-run mode")
+			Expect(*dryRun).To(BeTrue())
+
+			*agent = "cccl"
+			err = verifyArgs()
+			Expect(err).ToNot(BeNil(), "dry-run is not supported with cccl agent")
+
+			*dryRun = false
+			*outputDeclDir = ""
+			*agent = "as3"
+			err = verifyArgs()
+			Expect(err).ToNot(BeNil(), "BIG-IP credentials are required without dry-run")
+		})
+
+		It("verifies leader election args", func() {
+			defer _init()
+			os.Args = []string{
+				"./bin/k8s-bigip-ctlr",
+				"--namespace=testing",
+				"--bigip-partition=Velcro_1",
+				"--bigip-password=admin",
+				"--bigip-url=bigip.example.com",
+				"--bigip-username=admin",
+				"--enable-leader-election",
+			}
+
+			flags.Parse(os.Args)
+			err := verifyArgs()
+			Expect(err).To(BeNil())
+			Expect(*leaderElectionNamespace).To(Equal("kube-system"))
+			Expect(getLeaseName((*bigIPPartitions)[0])).To(Equal("k8s-bigip-ctlr-velcro-1"))
+
+			*leaderElectionLeaseName = "cis-lease"
+			Expect(getLeaseName((*bigIPPartitions)[0])).To(Equal("cis-lease"))
+
+			*leaderElectionNamespace = ""
+			err = verifyArgs()
+			Expect(err).ToNot(BeNil(), "Lease namespace is required with leader election")
+		})
+
 		It("verifies SDN args", func() {
 			defer _init()
 			os.Args = []string{
@@ -411,6 +462,78 @@ var _ = Describe("Main Tests", func() {
 			Expect(*bigIPPassword).To(Equal("pass"))
 		})
 
+		It("reloads credentials from a file", func() {
+			defer _init()
+			defer os.RemoveAll("/tmp/k8s-test-creds")
+
+			os.Args = []string{
+				"./bin/k8s-bigip-ctlr",
+				"--namespace=testing",
+				"--credentials-directory=/tmp/k8s-test-creds",
+				"--bigip-partition=velcro1",
+				"--bigip-url=bigip.example.com",
+				"--custom-resource-mode=true",
+			}
+			flags.Parse(os.Args)
+			os.Mkdir("/tmp/k8s-test-creds", 0755)
+			err := ioutil.WriteFile("/tmp/k8s-test-creds/username", []byte("user"), 0755)
+			Expect(err).ToNot(HaveOccurred())
+			err = ioutil.WriteFile("/tmp/k8s-test-creds/password", []byte("pass"), 0755)
+			Expect(err).ToNot(HaveOccurred())
+			err = getCredentials()
+			Expect(err).ToNot(HaveOccurred())
+			getGTMCredentials()
+			creds := getCurrentCredentials("")
+
+			newCreds, err := reloadCredentials(creds)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(newCreds).To(Equal(creds), "Credentials should not change")
+
+			err = ioutil.WriteFile("/tmp/k8s-test-creds/password", []byte("rotated"), 0755)
+			Expect(err).ToNot(HaveOccurred())
+			err = ioutil.WriteFile("/tmp/k8s-test-creds/url", []byte("bigip2.example.com"), 0755)
+			Expect(err).ToNot(HaveOccurred())
+			newCreds, err = reloadCredentials(creds)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(newCreds.password).To(Equal("rotated"))
+			Expect(newCreds.username).To(Equal("user"))
+			Expect(*bigIPPassword).To(Equal("pass"), "Password flag should not change")
+			Expect(*bigIPURL).To(Equal("https://bigip.example.com"), "BIG-IP URL should not change")
+			Expect(getCRPostParams(newCreds).BIGIPPassword).To(Equal("rotated"))
+			Expect(getCRPostParams(newCreds).SSLInsecure).To(BeTrue(),
+				"BIG-IP certificate should not be verified without trusted certificates")
+		})
+
+		It("gets more than one BIG-IP URL", func() {
+			defer _init()
+			os.Args = []string{
+				"./bin/k8s-bigip-ctlr",
+				"--namespace=testing",
+				"--bigip-partition=velcro1",
+				"--bigip-url=bigip1.example.com, https://bigip2.example.com",
+				"--bigip-username=user",
+				"--bigip-password=pass",
+				"--custom-resource-mode=true",
+			}
+			flags.Parse(os.Args)
+			err := getCredentials()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(bigIPURLs).To(Equal([]string{"https://bigip1.example.com", "https://bigip2.example.com"}))
+			Expect(*bigIPURL).To(Equal("https://bigip1.example.com"))
+
+			os.Args[3] = "--bigip-url=bigip1.example.com,bigip2.example.com"
+			os.Args[6] = "--custom-resource-mode=false"
+			flags.Parse(os.Args)
+			err = getCredentials()
+			Expect(err).ToNot(BeNil(), "More than one BIG-IP URL should fail outside custom-resource-mode.")
+
+			os.Args[6] = "--custom-resource-mode=true"
+			flags.Parse(os.Args)
+			*multiBigIPMode = "standby"
+			err = verifyArgs()
+			Expect(err).ToNot(BeNil(), "Invalid multi-bigip-mode should fail.")
+		})
+
 		It("sets up the node poller", func() {
 			defer _init()
 			os.Args = []string{
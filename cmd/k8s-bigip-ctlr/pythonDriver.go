@@ -40,23 +40,31 @@ func initializeDriverConfig(
 
 	sectionNames := []string{"global", "bigip"}
 	for i, v := range []interface{}{global, bigIP} {
-		doneCh, errCh, err := configWriter.SendSection(sectionNames[i], v)
-		if nil != err {
-			return fmt.Errorf("failed writing global config section: %v", err)
-		}
-		select {
-		case <-doneCh:
-		case e := <-errCh:
-			return fmt.Errorf("failed writing section %s - %v: %v",
-				sectionNames[i], e, v)
-		case <-time.After(1000 * time.Millisecond):
-			log.Warning("Did not receive config write response in 1 second")
+		if err := writeDriverSection(configWriter, sectionNames[i], v); err != nil {
+			return err
 		}
 	}
 
 	return nil
 }
 
+// writeDriverSection writes a section of the python driver configuration
+func writeDriverSection(configWriter writer.Writer, name string, section interface{}) error {
+	doneCh, errCh, err := configWriter.SendSection(name, section)
+	if nil != err {
+		return fmt.Errorf("failed writing global config section: %v", err)
+	}
+	select {
+	case <-doneCh:
+	case e := <-errCh:
+		return fmt.Errorf("failed writing section %s - %v: %v",
+			name, e, section)
+	case <-time.After(1000 * time.Millisecond):
+		log.Warning("Did not receive config write response in 1 second")
+	}
+	return nil
+}
+
 func createDriverCmd(
 	configFilename string,
 	pyCmd string,
@@ -20,26 +20,47 @@ type VirtualServer struct {
 
 // VirtualServerStatus is the status of the VirtualServer resource.
 type VirtualServerStatus struct {
-	VSAddress string `json:"vsAddress,omitempty"`
+	VSAddress  string             `json:"vsAddress,omitempty"`
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+// Condition types reported in the status of the custom resources.
+const (
+	// ConditionValid indicates whether the resource passed validation.
+	ConditionValid = "Valid"
+	// ConditionIPAllocated indicates whether a virtual address is available for the resource.
+	ConditionIPAllocated = "IPAllocated"
+	// ConditionProgrammed indicates whether the resource is translated to BIG-IP configuration.
+	ConditionProgrammed = "Programmed"
+	// ConditionBIGIPAccepted indicates whether BIG-IP accepted the configuration of the resource.
+	ConditionBIGIPAccepted = "BIGIPAccepted"
+)
+
 // VirtualServerSpec is the spec of the VirtualServer resource.
 type VirtualServerSpec struct {
-	Host                   string           `json:"host,omitempty"`
-	VirtualServerAddress   string           `json:"virtualServerAddress,omitempty"`
-	IPAMLabel              string           `json:"ipamLabel,omitempty"`
-	VirtualServerName      string           `json:"virtualServerName,omitempty"`
-	VirtualServerHTTPPort  int32            `json:"virtualServerHTTPPort,omitempty"`
-	VirtualServerHTTPSPort int32            `json:"virtualServerHTTPSPort,omitempty"`
-	Pools                  []Pool           `json:"pools,omitempty"`
-	TLSProfileName         string           `json:"tlsProfileName,omitempty"`
-	HTTPTraffic            string           `json:"httpTraffic,omitempty"`
-	SNAT                   string           `json:"snat,omitempty"`
-	WAF                    string           `json:"waf,omitempty"`
-	RewriteAppRoot         string           `json:"rewriteAppRoot,omitempty"`
-	AllowVLANs             []string         `json:"allowVlans,omitempty"`
-	IRules                 []string         `json:"iRules,omitempty"`
-	ServiceIPAddress       []ServiceAddress `json:"serviceAddress,omitempty"`
+	Host                     string           `json:"host,omitempty"`
+	VirtualServerAddress     string           `json:"virtualServerAddress,omitempty"`
+	IPAMLabel                string           `json:"ipamLabel,omitempty"`
+	IPv6VirtualServerAddress string           `json:"ipv6VirtualServerAddress,omitempty"`
+	IPv6IPAMLabel            string           `json:"ipv6IpamLabel,omitempty"`
+	RouteDomain              int32            `json:"routeDomain,omitempty"`
+	VirtualServerName        string           `json:"virtualServerName,omitempty"`
+	VirtualServerHTTPPort    int32            `json:"virtualServerHTTPPort,omitempty"`
+	VirtualServerHTTPSPort   int32            `json:"virtualServerHTTPSPort,omitempty"`
+	Pools                    []Pool           `json:"pools,omitempty"`
+	TLSProfileName           string           `json:"tlsProfileName,omitempty"`
+	HTTPTraffic              string           `json:"httpTraffic,omitempty"`
+	SNAT                     string           `json:"snat,omitempty"`
+	WAF                      string           `json:"waf,omitempty"`
+	RewriteAppRoot           string           `json:"rewriteAppRoot,omitempty"`
+	AllowVLANs               []string         `json:"allowVlans,omitempty"`
+	IRules                   []string         `json:"iRules,omitempty"`
+	ServiceIPAddress         []ServiceAddress `json:"serviceAddress,omitempty"`
+	Partition                string           `json:"partition,omitempty"`
+	Persistence              *Persistence     `json:"persistence,omitempty"`
+	Profiles                 *ProfileSpec     `json:"profiles,omitempty"`
+	AllowSourceRange         []string         `json:"allowSourceRange,omitempty"`
+	DenySourceRange          []string         `json:"denySourceRange,omitempty"`
 }
 
 // ServiceAddress Service IP address definition (BIG-IP virtual-address).
@@ -53,21 +74,70 @@ type ServiceAddress struct {
 
 // Pool defines a pool object in BIG-IP.
 type Pool struct {
-	Path            string  `json:"path,omitempty"`
-	Service         string  `json:"service"`
-	ServicePort     int32   `json:"servicePort"`
-	NodeMemberLabel string  `json:"nodeMemberLabel,omitempty"`
-	Monitor         Monitor `json:"monitor"`
-	Rewrite         string  `json:"rewrite,omitempty"`
+	Path                string             `json:"path,omitempty"`
+	Service             string             `json:"service"`
+	ServicePort         int32              `json:"servicePort"`
+	NodeMemberLabel     string             `json:"nodeMemberLabel,omitempty"`
+	Monitor             Monitor            `json:"monitor"`
+	Monitors            []Monitor          `json:"monitors,omitempty"`
+	Rewrite             string             `json:"rewrite,omitempty"`
+	Weight              *int32             `json:"weight,omitempty"`
+	AlternateBackends   []AlternateBackend `json:"alternateBackends,omitempty"`
+	LoadBalancingMethod string             `json:"loadBalancingMethod,omitempty"`
+	SlowRampTime        *int32             `json:"slowRampTime,omitempty"`
+	MinimumMonitors     int32              `json:"minimumMonitors,omitempty"`
+	ConnectionLimit     int32              `json:"connectionLimit,omitempty"`
+	RateLimit           int32              `json:"rateLimit,omitempty"`
+}
+
+// AlternateBackend defines an additional service that shares the traffic
+// of a pool path based on its weight.
+type AlternateBackend struct {
+	Service     string `json:"service"`
+	ServicePort int32  `json:"servicePort,omitempty"`
+	Weight      *int32 `json:"weight,omitempty"`
 }
 
 // Monitor defines a monitor object in BIG-IP.
 type Monitor struct {
-	Type     string `json:"type"`
-	Send     string `json:"send"`
-	Recv     string `json:"recv"`
-	Interval int    `json:"interval"`
-	Timeout  int    `json:"timeout"`
+	Type              string `json:"type"`
+	Send              string `json:"send"`
+	Recv              string `json:"recv"`
+	Interval          int    `json:"interval"`
+	Timeout           int    `json:"timeout"`
+	Reference         string `json:"reference,omitempty"`
+	ServerName        string `json:"serverName,omitempty"`
+	ClientCertificate string `json:"clientCertificate,omitempty"`
+	QueryName         string `json:"queryName,omitempty"`
+	QueryType         string `json:"queryType,omitempty"`
+}
+
+// Persistence defines the session persistence of a virtual server in BIG-IP.
+type Persistence struct {
+	Method       string `json:"method,omitempty"`
+	CookieMethod string `json:"cookieMethod,omitempty"`
+	CookieName   string `json:"cookieName,omitempty"`
+	Duration     int32  `json:"duration,omitempty"`
+	IRule        string `json:"iRule,omitempty"`
+	Reference    string `json:"reference,omitempty"`
+	Fallback     string `json:"fallback,omitempty"`
+}
+
+// ProfileSpec defines the BIG-IP profiles referenced by a virtual server.
+type ProfileSpec struct {
+	TCP             ProfileTCP `json:"tcp,omitempty"`
+	HTTP            string     `json:"http,omitempty"`
+	HTTP2           string     `json:"http2,omitempty"`
+	HTTPCompression string     `json:"httpCompression,omitempty"`
+	WebAcceleration string     `json:"webAcceleration,omitempty"`
+	OneConnect      string     `json:"oneConnect,omitempty"`
+	RequestLogging  string     `json:"requestLogging,omitempty"`
+}
+
+// ProfileTCP defines the BIG-IP TCP profiles of the client and server side connections.
+type ProfileTCP struct {
+	Client string `json:"client,omitempty"`
+	Server string `json:"server,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -88,7 +158,13 @@ type TLSProfile struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec TLSProfileSpec `json:"spec"`
+	Spec   TLSProfileSpec   `json:"spec"`
+	Status TLSProfileStatus `json:"status,omitempty"`
+}
+
+// TLSProfileStatus is the status of the TLSProfile resource.
+type TLSProfileStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // TLSProfileSpec is spec for TLSServer
@@ -103,6 +179,18 @@ type TLS struct {
 	ClientSSL   string `json:"clientSSL"`
 	ServerSSL   string `json:"serverSSL"`
 	Reference   string `json:"reference"`
+	// ClientCASecret is the Secret with the CA bundle to validate the client certificates
+	ClientCASecret string `json:"clientCASecret,omitempty"`
+	// PeerCertMode is the client certificate authentication mode: require, request or ignore
+	PeerCertMode string `json:"peerCertMode,omitempty"`
+	// SNICertificates are selected by the SNI of the clients, ClientSSL is the default certificate
+	SNICertificates []SNICertificate `json:"sniCertificates,omitempty"`
+}
+
+// SNICertificate is the certificate of a host, which may be a wildcard
+type SNICertificate struct {
+	Host      string `json:"host"`
+	ClientSSL string `json:"clientSSL"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -129,7 +217,8 @@ type IngressLink struct {
 
 // IngressLinkStatus is the status of the ingressLink resource.
 type IngressLinkStatus struct {
-	VSAddress string `json:"vsAddress,omitempty"`
+	VSAddress  string             `json:"vsAddress,omitempty"`
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // IngressLinkSpec is Spec for IngressLink
@@ -138,6 +227,7 @@ type IngressLinkSpec struct {
 	Selector             *metav1.LabelSelector `json:"selector"`
 	IRules               []string              `json:"iRules,omitempty"`
 	IPAMLabel            string                `json:"ipamLabel"`
+	RouteDomain          int32                 `json:"routeDomain,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -159,22 +249,37 @@ type TransportServer struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec TransportServerSpec `json:"spec"`
+	Spec   TransportServerSpec   `json:"spec"`
+	Status TransportServerStatus `json:"status,omitempty"`
+}
+
+// TransportServerStatus is the status of the TransportServer resource.
+type TransportServerStatus struct {
+	VSAddress  string             `json:"vsAddress,omitempty"`
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 // TransportServerSpec is the spec of the VirtualServer resource.
 type TransportServerSpec struct {
-	VirtualServerAddress string           `json:"virtualServerAddress"`
-	VirtualServerPort    int32            `json:"virtualServerPort"`
-	VirtualServerName    string           `json:"virtualServerName"`
-	Mode                 string           `json:"mode"`
-	SNAT                 string           `json:"snat"`
-	Pool                 Pool             `json:"pool"`
-	AllowVLANs           []string         `json:"allowVlans,omitempty"`
-	Type                 string           `json:"type,omitempty"`
-	ServiceIPAddress     []ServiceAddress `json:"serviceAddress"`
-	IPAMLabel            string           `json:"ipamLabel"`
-	IRules               []string         `json:"iRules,omitempty"`
+	VirtualServerAddress     string           `json:"virtualServerAddress"`
+	VirtualServerPort        int32            `json:"virtualServerPort"`
+	VirtualServerName        string           `json:"virtualServerName"`
+	Mode                     string           `json:"mode"`
+	SNAT                     string           `json:"snat"`
+	Pool                     Pool             `json:"pool"`
+	AllowVLANs               []string         `json:"allowVlans,omitempty"`
+	Type                     string           `json:"type,omitempty"`
+	ServiceIPAddress         []ServiceAddress `json:"serviceAddress"`
+	IPAMLabel                string           `json:"ipamLabel"`
+	IPv6VirtualServerAddress string           `json:"ipv6VirtualServerAddress,omitempty"`
+	IPv6IPAMLabel            string           `json:"ipv6IpamLabel,omitempty"`
+	RouteDomain              int32            `json:"routeDomain,omitempty"`
+	IRules                   []string         `json:"iRules,omitempty"`
+	Partition                string           `json:"partition,omitempty"`
+	Persistence              *Persistence     `json:"persistence,omitempty"`
+	Profiles                 *ProfileSpec     `json:"profiles,omitempty"`
+	AllowSourceRange         []string         `json:"allowSourceRange,omitempty"`
+	DenySourceRange          []string         `json:"denySourceRange,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -196,7 +301,13 @@ type ExternalDNS struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec ExternalDNSSpec `json:"spec"`
+	Spec   ExternalDNSSpec   `json:"spec"`
+	Status ExternalDNSStatus `json:"status,omitempty"`
+}
+
+// ExternalDNSStatus is the status of the ExternalDNS resource.
+type ExternalDNSStatus struct {
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 type ExternalDNSSpec struct {
@@ -25,6 +25,27 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AlternateBackend) DeepCopyInto(out *AlternateBackend) {
+	*out = *in
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AlternateBackend.
+func (in *AlternateBackend) DeepCopy() *AlternateBackend {
+	if in == nil {
+		return nil
+	}
+	out := new(AlternateBackend)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DNSPool) DeepCopyInto(out *DNSPool) {
 	*out = *in
@@ -48,6 +69,7 @@ func (in *ExternalDNS) DeepCopyInto(out *ExternalDNS) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -123,13 +145,36 @@ func (in *ExternalDNSSpec) DeepCopy() *ExternalDNSSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalDNSStatus) DeepCopyInto(out *ExternalDNSStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalDNSStatus.
+func (in *ExternalDNSStatus) DeepCopy() *ExternalDNSStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalDNSStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IngressLink) DeepCopyInto(out *IngressLink) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -213,6 +258,13 @@ func (in *IngressLinkSpec) DeepCopy() *IngressLinkSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *IngressLinkStatus) DeepCopyInto(out *IngressLinkStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -242,10 +294,48 @@ func (in *Monitor) DeepCopy() *Monitor {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Persistence) DeepCopyInto(out *Persistence) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Persistence.
+func (in *Persistence) DeepCopy() *Persistence {
+	if in == nil {
+		return nil
+	}
+	out := new(Persistence)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Pool) DeepCopyInto(out *Pool) {
 	*out = *in
 	out.Monitor = in.Monitor
+	if in.Monitors != nil {
+		in, out := &in.Monitors, &out.Monitors
+		*out = make([]Monitor, len(*in))
+		copy(*out, *in)
+	}
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(int32)
+		**out = **in
+	}
+	if in.AlternateBackends != nil {
+		in, out := &in.AlternateBackends, &out.AlternateBackends
+		*out = make([]AlternateBackend, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SlowRampTime != nil {
+		in, out := &in.SlowRampTime, &out.SlowRampTime
+		*out = new(int32)
+		**out = **in
+	}
 	return
 }
 
@@ -259,6 +349,55 @@ func (in *Pool) DeepCopy() *Pool {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProfileSpec) DeepCopyInto(out *ProfileSpec) {
+	*out = *in
+	out.TCP = in.TCP
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProfileSpec.
+func (in *ProfileSpec) DeepCopy() *ProfileSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ProfileSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProfileTCP) DeepCopyInto(out *ProfileTCP) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProfileTCP.
+func (in *ProfileTCP) DeepCopy() *ProfileTCP {
+	if in == nil {
+		return nil
+	}
+	out := new(ProfileTCP)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SNICertificate) DeepCopyInto(out *SNICertificate) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SNICertificate.
+func (in *SNICertificate) DeepCopy() *SNICertificate {
+	if in == nil {
+		return nil
+	}
+	out := new(SNICertificate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceAddress) DeepCopyInto(out *ServiceAddress) {
 	*out = *in
@@ -278,6 +417,11 @@ func (in *ServiceAddress) DeepCopy() *ServiceAddress {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TLS) DeepCopyInto(out *TLS) {
 	*out = *in
+	if in.SNICertificates != nil {
+		in, out := &in.SNICertificates, &out.SNICertificates
+		*out = make([]SNICertificate, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -297,6 +441,7 @@ func (in *TLSProfile) DeepCopyInto(out *TLSProfile) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -359,7 +504,7 @@ func (in *TLSProfileSpec) DeepCopyInto(out *TLSProfileSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	out.TLS = in.TLS
+	in.TLS.DeepCopyInto(&out.TLS)
 	return
 }
 
@@ -373,12 +518,36 @@ func (in *TLSProfileSpec) DeepCopy() *TLSProfileSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSProfileStatus) DeepCopyInto(out *TLSProfileStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSProfileStatus.
+func (in *TLSProfileStatus) DeepCopy() *TLSProfileStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSProfileStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TransportServer) DeepCopyInto(out *TransportServer) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -436,7 +605,7 @@ func (in *TransportServerList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TransportServerSpec) DeepCopyInto(out *TransportServerSpec) {
 	*out = *in
-	out.Pool = in.Pool
+	in.Pool.DeepCopyInto(&out.Pool)
 	if in.AllowVLANs != nil {
 		in, out := &in.AllowVLANs, &out.AllowVLANs
 		*out = make([]string, len(*in))
@@ -452,6 +621,26 @@ func (in *TransportServerSpec) DeepCopyInto(out *TransportServerSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Persistence != nil {
+		in, out := &in.Persistence, &out.Persistence
+		*out = new(Persistence)
+		**out = **in
+	}
+	if in.Profiles != nil {
+		in, out := &in.Profiles, &out.Profiles
+		*out = new(ProfileSpec)
+		**out = **in
+	}
+	if in.AllowSourceRange != nil {
+		in, out := &in.AllowSourceRange, &out.AllowSourceRange
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DenySourceRange != nil {
+		in, out := &in.DenySourceRange, &out.DenySourceRange
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -465,13 +654,36 @@ func (in *TransportServerSpec) DeepCopy() *TransportServerSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransportServerStatus) DeepCopyInto(out *TransportServerStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransportServerStatus.
+func (in *TransportServerStatus) DeepCopy() *TransportServerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TransportServerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VirtualServer) DeepCopyInto(out *VirtualServer) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -532,7 +744,9 @@ func (in *VirtualServerSpec) DeepCopyInto(out *VirtualServerSpec) {
 	if in.Pools != nil {
 		in, out := &in.Pools, &out.Pools
 		*out = make([]Pool, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.AllowVLANs != nil {
 		in, out := &in.AllowVLANs, &out.AllowVLANs
@@ -549,6 +763,26 @@ func (in *VirtualServerSpec) DeepCopyInto(out *VirtualServerSpec) {
 		*out = make([]ServiceAddress, len(*in))
 		copy(*out, *in)
 	}
+	if in.Persistence != nil {
+		in, out := &in.Persistence, &out.Persistence
+		*out = new(Persistence)
+		**out = **in
+	}
+	if in.Profiles != nil {
+		in, out := &in.Profiles, &out.Profiles
+		*out = new(ProfileSpec)
+		**out = **in
+	}
+	if in.AllowSourceRange != nil {
+		in, out := &in.AllowSourceRange, &out.AllowSourceRange
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DenySourceRange != nil {
+		in, out := &in.DenySourceRange, &out.DenySourceRange
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -565,6 +799,13 @@ func (in *VirtualServerSpec) DeepCopy() *VirtualServerSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VirtualServerStatus) DeepCopyInto(out *VirtualServerStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
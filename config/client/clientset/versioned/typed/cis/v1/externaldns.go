@@ -40,6 +40,7 @@ type ExternalDNSsGetter interface {
 type ExternalDNSInterface interface {
 	Create(ctx context.Context, externalDNS *v1.ExternalDNS, opts metav1.CreateOptions) (*v1.ExternalDNS, error)
 	Update(ctx context.Context, externalDNS *v1.ExternalDNS, opts metav1.UpdateOptions) (*v1.ExternalDNS, error)
+	UpdateStatus(ctx context.Context, externalDNS *v1.ExternalDNS, opts metav1.UpdateOptions) (*v1.ExternalDNS, error)
 	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
 	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
 	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.ExternalDNS, error)
@@ -135,6 +136,22 @@ func (c *externalDNSs) Update(ctx context.Context, externalDNS *v1.ExternalDNS,
 	return
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *externalDNSs) UpdateStatus(ctx context.Context, externalDNS *v1.ExternalDNS, opts metav1.UpdateOptions) (result *v1.ExternalDNS, err error) {
+	result = &v1.ExternalDNS{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("externaldnss").
+		Name(externalDNS.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(externalDNS).
+		Do(ctx).
+		Into(result)
+	return
+}
+
 // Delete takes name of the externalDNS and deletes it. Returns an error if one occurs.
 func (c *externalDNSs) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
 	return c.client.Delete().
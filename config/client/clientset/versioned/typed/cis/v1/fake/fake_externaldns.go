@@ -102,6 +102,18 @@ func (c *FakeExternalDNSs) Update(ctx context.Context, externalDNS *cisv1.Extern
 	return obj.(*cisv1.ExternalDNS), err
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeExternalDNSs) UpdateStatus(ctx context.Context, externalDNS *cisv1.ExternalDNS, opts v1.UpdateOptions) (*cisv1.ExternalDNS, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(externaldnssResource, "status", c.ns, externalDNS), &cisv1.ExternalDNS{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*cisv1.ExternalDNS), err
+}
+
 // Delete takes name of the externalDNS and deletes it. Returns an error if one occurs.
 func (c *FakeExternalDNSs) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
 	_, err := c.Fake.
@@ -40,6 +40,7 @@ type TransportServersGetter interface {
 type TransportServerInterface interface {
 	Create(ctx context.Context, transportServer *v1.TransportServer, opts metav1.CreateOptions) (*v1.TransportServer, error)
 	Update(ctx context.Context, transportServer *v1.TransportServer, opts metav1.UpdateOptions) (*v1.TransportServer, error)
+	UpdateStatus(ctx context.Context, transportServer *v1.TransportServer, opts metav1.UpdateOptions) (*v1.TransportServer, error)
 	Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error
 	DeleteCollection(ctx context.Context, opts metav1.DeleteOptions, listOpts metav1.ListOptions) error
 	Get(ctx context.Context, name string, opts metav1.GetOptions) (*v1.TransportServer, error)
@@ -135,6 +136,22 @@ func (c *transportServers) Update(ctx context.Context, transportServer *v1.Trans
 	return
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *transportServers) UpdateStatus(ctx context.Context, transportServer *v1.TransportServer, opts metav1.UpdateOptions) (result *v1.TransportServer, err error) {
+	result = &v1.TransportServer{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("transportservers").
+		Name(transportServer.Name).
+		SubResource("status").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(transportServer).
+		Do(ctx).
+		Into(result)
+	return
+}
+
 // Delete takes name of the transportServer and deletes it. Returns an error if one occurs.
 func (c *transportServers) Delete(ctx context.Context, name string, opts metav1.DeleteOptions) error {
 	return c.client.Delete().
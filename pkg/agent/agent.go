@@ -31,6 +31,12 @@ type DeInitializer interface {
 	DeInit() error
 }
 
+// CredentialsUpdater is the interface which wraps the UpdateCredentials method,
+// implemented by the agents posting to BIG-IP
+type CredentialsUpdater interface {
+	UpdateCredentials(username, password, trustedCerts string, sslInsecure bool)
+}
+
 // Remover is the interface which wraps basic Remove method
 type Remover interface {
 	Remove(partition string) error
@@ -37,6 +37,12 @@ func (ag *agentAS3) Deploy(req interface{}) error {
 	return nil
 }
 
+// UpdateCredentials updates the credentials and the trusted certificates the
+// declarations are posted to BIG-IP with
+func (ag *agentAS3) UpdateCredentials(username, password, trustedCerts string, sslInsecure bool) {
+	ag.PostManager.UpdateCredentials(username, password, trustedCerts, sslInsecure)
+}
+
 //TODO: Remove this post CIS2.2
 func (ag *agentAS3) Remove(partition string) error {
 	log.Debugf("[AS3] Removing Partition %v_AS3 \n", partition)
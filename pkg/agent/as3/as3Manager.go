@@ -19,6 +19,7 @@ package as3
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -111,6 +112,10 @@ type AS3Manager struct {
 	as3Release                string
 	unprocessableEntityStatus bool
 	shareNodes                bool
+	// AS3 failures last reported to the response handler
+	lastFailures []AS3Failure
+	// Writes the declarations instead of posting them to BIG-IP in dry-run mode
+	declWriter *writer.DeclarationWriter
 }
 
 // Struct to allow NewManager to receive all or only specific parameters.
@@ -131,6 +136,8 @@ type Params struct {
 	BIGIPPassword       string
 	BIGIPURL            string
 	TrustedCerts        string
+	TokenAuth           bool
+	LoginProvider       string
 	AS3PostDelay        int
 	ConfigWriter        writer.Writer
 	EventChan           chan interface{}
@@ -143,6 +150,8 @@ type Params struct {
 	As3Release                string
 	As3SchemaVersion          string
 	unprocessableEntityStatus bool
+	// Set in dry-run mode, the declarations are not posted to BIG-IP
+	DeclarationWriter *writer.DeclarationWriter
 }
 
 // Create and return a new app manager that meets the Manager interface
@@ -162,6 +171,7 @@ func NewAS3Manager(params *Params) *AS3Manager {
 		as3SchemaVersion:          params.As3SchemaVersion,
 		OverriderCfgMapName:       params.OverriderCfgMapName,
 		shareNodes:                params.ShareNodes,
+		declWriter:                params.DeclarationWriter,
 		l2l3Agent: L2L3Agent{eventChan: params.EventChan,
 			configWriter: params.ConfigWriter},
 		PostManager: NewPostManager(PostParams{
@@ -171,7 +181,9 @@ func NewAS3Manager(params *Params) *AS3Manager {
 			TrustedCerts:  params.TrustedCerts,
 			SSLInsecure:   params.SSLInsecure,
 			AS3PostDelay:  params.AS3PostDelay,
-			LogResponse:   params.LogResponse}),
+			LogResponse:   params.LogResponse,
+			TokenAuth:     params.TokenAuth,
+			LoginProvider: params.LoginProvider}),
 	}
 
 	if as3Manager.tls13CipherGroupReference == "" {
@@ -184,11 +196,15 @@ func NewAS3Manager(params *Params) *AS3Manager {
 }
 
 func (am *AS3Manager) postAS3Declaration(rsReq ResourceRequest) (bool, string) {
+	return am.postAS3Config(am.prepareAS3Config(rsReq))
+}
+
+func (am *AS3Manager) prepareAS3Config(rsReq ResourceRequest) AS3Config {
 
 	am.ResourceRequest = rsReq
 
 	//as3Config := am.as3ActiveConfig
-	as3Config := &AS3Config{}
+	as3Config := AS3Config{}
 
 	// Process Route or Ingress
 	as3Config.resourceConfig = am.prepareAS3ResourceConfig()
@@ -196,7 +212,18 @@ func (am *AS3Manager) postAS3Declaration(rsReq ResourceRequest) (bool, string) {
 	// Process all Configmaps (including overrideAS3)
 	as3Config.configmaps, as3Config.overrideConfigmapData = am.prepareResourceAS3ConfigMaps()
 
-	return am.postAS3Config(*as3Config)
+	return as3Config
+}
+
+// RenderDeclaration returns the AS3 declaration of the resources without
+// posting it to BIG-IP, the latest supported AS3 version is used when the
+// version of BIG-IP is not known
+func (am *AS3Manager) RenderDeclaration(rsReq ResourceRequest) string {
+	if am.as3Version == "" {
+		am.setDefaultAS3Version()
+	}
+	as3Config := am.prepareAS3Config(rsReq)
+	return string(am.getUnifiedDeclaration(&as3Config))
 }
 
 func (am *AS3Manager) postAS3Config(tempAS3Config AS3Config) (bool, string) {
@@ -224,6 +251,12 @@ func (am *AS3Manager) postAS3Config(tempAS3Config AS3Config) (bool, string) {
 		tenants = getTenants(unifiedDecl, true)
 	}
 
+	if am.declWriter != nil {
+		if err := am.declWriter.Write("cis-as3", string(unifiedDecl)); err != nil {
+			log.Errorf("[DRY-RUN] Failed to write declaration: %v", err)
+		}
+		return true, ""
+	}
 	return am.PostManager.postConfig(string(unifiedDecl), tenants)
 }
 
@@ -328,6 +361,9 @@ func (am *AS3Manager) getDeletedTenants(curTenantMap map[string]interface{}) []s
 
 // Method to delete any AS3 partition
 func (am *AS3Manager) DeleteAS3Partition(partition string) (bool, string) {
+	if am.declWriter != nil {
+		return true, ""
+	}
 	emptyAS3Declaration := am.getEmptyAs3Declaration(partition)
 	return am.PostManager.postConfig(string(emptyAS3Declaration), nil)
 }
@@ -359,12 +395,14 @@ func (am *AS3Manager) ConfigDeployer() {
 		}
 
 		posted, event := am.postAS3Declaration(msgReq.ResourceRequest)
+		am.SendAgentFailures()
 		// To handle general errors
 		for !posted {
 			am.unprocessableEntityStatus = true
 			timeout := getTimeDurationForErrorResponse(event)
 			log.Debugf("[AS3] Error handling for event %v", event)
 			posted, event = am.postOnEventOrTimeout(timeout)
+			am.SendAgentFailures()
 		}
 		firstPost = false
 		if event == responseStatusOk {
@@ -399,6 +437,85 @@ func (am *AS3Manager) SendAgentResponse() {
 	am.postAgentResponse(MessageResponse{ResourceResponse: agRsp})
 }
 
+// Post AS3 failures of the last declaration over response channel
+// Same failures are reported only once, as the declaration is retried until it succeeds
+func (am *AS3Manager) SendAgentFailures() {
+	failures := am.PostManager.failures
+	if reflect.DeepEqual(failures, am.lastFailures) {
+		return
+	}
+	am.lastFailures = failures
+	if len(failures) == 0 || am.RspChan == nil {
+		return
+	}
+	agRsp := am.ResourceResponse
+	agRsp.IsResponseSuccessful = false
+	agRsp.AS3Failures = am.resolveAS3Failures(failures)
+	am.postAgentResponse(MessageResponse{ResourceResponse: agRsp})
+}
+
+// resolveAS3Failures finds the ConfigMaps and the resource configs
+// which produced the tenants and the objects of the AS3 failures
+func (am *AS3Manager) resolveAS3Failures(failures []AS3Failure) []AS3Failure {
+	resolved := make([]AS3Failure, 0, len(failures))
+	for _, failure := range failures {
+		for _, cm := range am.as3ActiveConfig.configmaps {
+			if _, ok := cm.config[failure.Tenant]; ok {
+				failure.ConfigMaps = append(failure.ConfigMaps, cm.Namespace+"/"+cm.Name)
+			}
+		}
+		if failure.Tenant == DEFAULT_PARTITION && am.Resources != nil {
+			failure.Resources = am.getFailedResources(failure.Objects)
+		}
+		resolved = append(resolved, failure)
+	}
+	return resolved
+}
+
+// getFailedResources returns names of the virtuals and pools producing the AS3 objects
+func (am *AS3Manager) getFailedResources(objects []string) []string {
+	var rscNames []string
+	addResource := func(name string) {
+		for _, rscName := range rscNames {
+			if rscName == name {
+				return
+			}
+		}
+		rscNames = append(rscNames, name)
+	}
+	for _, obj := range objects {
+		for _, cfg := range am.Resources.RsCfgs {
+			rsType := cfg.MetaData.ResourceType
+			if obj == as3FormattedString(cfg.Virtual.Name, rsType) {
+				addResource(cfg.Virtual.Name)
+			}
+			for _, pl := range cfg.Policies {
+				if obj == as3FormattedString(pl.Name, rsType) {
+					addResource(cfg.Virtual.Name)
+				}
+			}
+			for _, pool := range cfg.Pools {
+				if obj == as3FormattedString(pool.Name, rsType) {
+					addResource(pool.Name)
+				}
+			}
+			for _, mon := range cfg.Monitors {
+				if obj != as3FormattedString(mon.Name, rsType) {
+					continue
+				}
+				for _, pool := range cfg.Pools {
+					for _, monName := range pool.MonitorNames {
+						if monName == mon.Name || strings.HasSuffix(monName, "/"+mon.Name) {
+							addResource(pool.Name)
+						}
+					}
+				}
+			}
+		}
+	}
+	return rscNames
+}
+
 // Method implements posting MessageResponse on Agent Response Channel
 func (am *AS3Manager) postAgentResponse(msgRsp MessageResponse) {
 	select {
@@ -408,10 +525,23 @@ func (am *AS3Manager) postAgentResponse(msgRsp MessageResponse) {
 	}
 }
 
+// setDefaultAS3Version uses the latest AS3 version supported by CIS
+func (am *AS3Manager) setDefaultAS3Version() {
+	am.as3Version = defaultAS3Version
+	am.as3SchemaVersion = fmt.Sprintf("%.2f.0", as3Version)
+	am.as3Release = am.as3Version + "-" + defaultAS3Build
+}
+
 // Method to verify if App Services are installed or CIS as3 version is
 // compatible with BIG-IP, it will return with error if any one of the
 // requirements are not met
 func (am *AS3Manager) IsBigIPAppServicesAvailable() error {
+	if am.declWriter != nil {
+		// Without BIG-IP declarations are generated for the latest supported AS3 version
+		am.setDefaultAS3Version()
+		log.Infof("[DRY-RUN] Generating declarations for AS3 version %v", am.as3Release)
+		return nil
+	}
 	version, build, schemaVersion, err := am.PostManager.GetBigipAS3Version()
 	am.as3Version = version
 	as3Build := build
@@ -433,10 +563,7 @@ func (am *AS3Manager) IsBigIPAppServicesAvailable() error {
 	}
 
 	if bigIPAS3Version > as3Version {
-		am.as3Version = defaultAS3Version
-		am.as3SchemaVersion = fmt.Sprintf("%.2f.0", as3Version)
-		as3Build := defaultAS3Build
-		am.as3Release = am.as3Version + "-" + as3Build
+		am.setDefaultAS3Version()
 		log.Debugf("[AS3] BIGIP is serving with AS3 version: %v", bigIPAS3Version)
 		return nil
 	}
@@ -17,8 +17,14 @@ package as3
 
 import (
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
 
 	. "github.com/F5Networks/k8s-bigip-ctlr/pkg/resource"
+	"github.com/F5Networks/k8s-bigip-ctlr/pkg/writer"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -295,6 +301,120 @@ var _ = Describe("AS3Manager Tests", func() {
 		})
 	})
 
+	Describe("Dry Run", func() {
+		It("Writes the declaration instead of posting to BIG-IP", func() {
+			outputDir, err := ioutil.TempDir("", "as3-dry-run")
+			Expect(err).To(BeNil())
+			defer os.RemoveAll(outputDir)
+			mockMgr.declWriter, err = writer.NewDeclarationWriter(outputDir)
+			Expect(err).To(BeNil())
+			Expect(mockMgr.IsBigIPAppServicesAvailable()).To(BeNil())
+			Expect(mockMgr.as3Release).To(Equal(defaultAS3Version + "-" + defaultAS3Build))
+
+			var routeAdc map[string]interface{}
+			err = json.Unmarshal([]byte(readConfigFile(configPath+"as3_route.json")), &routeAdc)
+			Expect(err).To(BeNil())
+			posted, event := mockMgr.postAS3Config(AS3Config{resourceConfig: routeAdc})
+			Expect(posted).To(BeTrue())
+			Expect(event).To(BeEmpty(), "Dry run should not report a BIG-IP response")
+			Expect(filepath.Join(outputDir, "cis-as3.json")).To(BeAnExistingFile())
+
+			posted, _ = mockMgr.DeleteAS3Partition("test_AS3")
+			Expect(posted).To(BeTrue())
+		})
+	})
+
+	Describe("Render Declaration", func() {
+		It("Renders the declaration without posting to BIG-IP", func() {
+			cmcfg1 := readConfigFile(configPath + "as3config_valid_1.json")
+			cmcfg2 := readConfigFile(configPath + "as3config_valid_2.json")
+			rsReq := ResourceRequest{Resources: &AgentResources{}}
+			for idx, data := range []string{cmcfg1, cmcfg2} {
+				rsReq.AgentCfgmaps = append(rsReq.AgentCfgmaps, &AgentCfgMap{
+					GetEndpoints: mockGetEndPoints,
+					Name:         fmt.Sprintf("cfgmap%d", idx+1),
+					Namespace:    "default",
+					Data:         data,
+					Label: map[string]string{
+						AS3Label:    TrueLabel,
+						F5TypeLabel: VSLabel,
+					},
+				})
+			}
+			var decl map[string]interface{}
+			err := json.Unmarshal([]byte(mockMgr.RenderDeclaration(rsReq)), &decl)
+			Expect(err).To(BeNil())
+			adc := decl["declaration"].(map[string]interface{})
+			Expect(adc).To(HaveKey("Tenant1"), "Tenant of the ConfigMap not rendered")
+			Expect(adc).To(HaveKey("Tenant2"), "Tenant of the ConfigMap not rendered")
+			Expect(adc).To(HaveKey(DEFAULT_PARTITION), "Tenant of CIS not rendered")
+			Expect(mockMgr.as3ActiveConfig.unifiedDeclaration).To(BeEmpty(),
+				"Rendered declaration should not be the active configuration")
+		})
+		It("Renders the declaration for the latest AS3 version", func() {
+			mockMgr.as3Version = ""
+			decl := mockMgr.RenderDeclaration(ResourceRequest{Resources: &AgentResources{}})
+			Expect(mockMgr.as3Release).To(Equal(defaultAS3Version + "-" + defaultAS3Build))
+			Expect(decl).To(ContainSubstring(mockMgr.as3Release))
+		})
+	})
+
+	Describe("AS3 Failures", func() {
+		It("Reports AS3 failures with the originating resources", func() {
+			mockMgr.RspChan = make(chan interface{}, 1)
+			mockMgr.Resources = &AgentResources{
+				RsCfgs: ResourceConfigs{
+					&ResourceConfig{
+						MetaData: MetaData{ResourceType: ResourceTypeRoute},
+						Virtual:  Virtual{Name: "openshift_route_https"},
+						Pools: Pools{
+							{Name: "openshift_default_svc1", MonitorNames: []string{"/k8s/openshift_default_svc1_0_http"}},
+							{Name: "openshift_default_svc2"},
+						},
+						Monitors: Monitors{{Name: "openshift_default_svc1_0_http"}},
+					},
+				},
+			}
+			mockMgr.as3ActiveConfig.configmaps = []*AS3ConfigMap{
+				{Name: "as3cfgmap", Namespace: "default", config: as3ADC{"tenant1": as3Tenant{}}},
+			}
+			mockMgr.PostManager.failures = []AS3Failure{
+				{Tenant: DEFAULT_PARTITION, Objects: []string{"openshift_default_svc1_0_http"}, Message: "invalid monitor"},
+				{Tenant: "tenant1", Message: "invalid tenant"},
+			}
+			mockMgr.SendAgentFailures()
+			rsp := (<-mockMgr.RspChan).(MessageResponse).ResourceResponse
+			Expect(rsp.IsResponseSuccessful).To(BeFalse())
+			Expect(rsp.AS3Failures).To(HaveLen(2))
+			Expect(rsp.AS3Failures[0].Resources).To(Equal([]string{"openshift_default_svc1"}))
+			Expect(rsp.AS3Failures[0].ConfigMaps).To(BeEmpty())
+			Expect(rsp.AS3Failures[1].ConfigMaps).To(Equal([]string{"default/as3cfgmap"}))
+
+			// Same failures are not reported again
+			mockMgr.SendAgentFailures()
+			Expect(mockMgr.RspChan).To(BeEmpty())
+		})
+	})
+
+	Describe("Credentials", func() {
+		It("Updates the BIG-IP credentials and trusted certificates", func() {
+			postMgr := NewPostManager(PostParams{BIGIPUsername: "user", BIGIPPassword: "pass"})
+			client := postMgr.getHTTPClient()
+
+			postMgr.UpdateCredentials("user", "rotated", "", false)
+			req, _ := http.NewRequest("GET", "https://bigip.example.com", nil)
+			postMgr.setBasicAuth(req)
+			username, password, _ := req.BasicAuth()
+			Expect(username).To(Equal("user"))
+			Expect(password).To(Equal("rotated"))
+			Expect(postMgr.getHTTPClient()).To(BeIdenticalTo(client), "HTTP client should not change")
+
+			postMgr.UpdateCredentials("user", "rotated", "certs", false)
+			Expect(postMgr.TrustedCerts).To(Equal("certs"))
+			Expect(postMgr.getHTTPClient()).NotTo(BeIdenticalTo(client), "HTTP client should be rebuilt")
+		})
+	})
+
 	Describe("TLS Profile", func() {
 		It("Default Cipher Group", func() {
 			mockMgr.enableTLS = "1.3"
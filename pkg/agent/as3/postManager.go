@@ -25,8 +25,11 @@ import (
 	"io/ioutil"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
+	. "github.com/F5Networks/k8s-bigip-ctlr/pkg/resource"
+	"github.com/F5Networks/k8s-bigip-ctlr/pkg/tokenmanager"
 	log "github.com/F5Networks/k8s-bigip-ctlr/pkg/vlogger"
 	routeclient "github.com/openshift/client-go/route/clientset/versioned/typed/route/v1"
 )
@@ -49,6 +52,12 @@ type PostManager struct {
 	postChan   chan config
 	httpClient *http.Client
 	activeCfg  config
+	// Failures reported by BIG-IP for the last posted declaration
+	failures []AS3Failure
+	// guards the credentials and the HTTP client updated at runtime
+	credsMutex sync.RWMutex
+	// authenticates with auth tokens instead of Basic auth when set
+	tokenManager *tokenmanager.TokenManager
 	PostParams
 }
 
@@ -62,6 +71,9 @@ type PostParams struct {
 	//Log the AS3 response body in Controller logs
 	LogResponse   bool
 	RouteClientV1 routeclient.RouteV1Interface
+	// Authenticate with an auth token of the login provider instead of Basic auth
+	TokenAuth     bool
+	LoginProvider string
 }
 
 type config struct {
@@ -75,6 +87,9 @@ func NewPostManager(params PostParams) *PostManager {
 		PostParams: params,
 	}
 	pm.setupBIGIPRESTClient()
+	if params.TokenAuth {
+		pm.tokenManager = tokenmanager.NewTokenManager(params.BIGIPUsername, params.BIGIPPassword, params.LoginProvider)
+	}
 
 	return pm
 }
@@ -106,6 +121,45 @@ func (postMgr *PostManager) setupBIGIPRESTClient() {
 	}
 }
 
+// UpdateCredentials updates the credentials and the trusted certificates of BIG-IP
+// and rebuilds the HTTP client when the certificates change
+func (postMgr *PostManager) UpdateCredentials(username, password, trustedCerts string, sslInsecure bool) {
+	postMgr.credsMutex.Lock()
+	defer postMgr.credsMutex.Unlock()
+	postMgr.BIGIPUsername = username
+	postMgr.BIGIPPassword = password
+	if postMgr.tokenManager != nil {
+		postMgr.tokenManager.SetCredentials(username, password)
+	}
+	if postMgr.TrustedCerts == trustedCerts && postMgr.SSLInsecure == sslInsecure && postMgr.httpClient != nil {
+		return
+	}
+	postMgr.TrustedCerts = trustedCerts
+	postMgr.SSLInsecure = sslInsecure
+	oldClient := postMgr.httpClient
+	postMgr.setupBIGIPRESTClient()
+	if oldClient != nil {
+		oldClient.CloseIdleConnections()
+	}
+	log.Info("[AS3] Updated the HTTP client with the trusted certificates of BIG-IP")
+}
+
+// setBasicAuth sets the credentials of BIG-IP on the request, unless auth tokens are used
+func (postMgr *PostManager) setBasicAuth(req *http.Request) {
+	if postMgr.tokenManager != nil {
+		return
+	}
+	postMgr.credsMutex.RLock()
+	defer postMgr.credsMutex.RUnlock()
+	req.SetBasicAuth(postMgr.BIGIPUsername, postMgr.BIGIPPassword)
+}
+
+func (postMgr *PostManager) getHTTPClient() *http.Client {
+	postMgr.credsMutex.RLock()
+	defer postMgr.credsMutex.RUnlock()
+	return postMgr.httpClient
+}
+
 func (postMgr *PostManager) getAS3APIURL(tenants []string) string {
 	apiURL := postMgr.BIGIPURL + "/mgmt/shared/appsvcs/declare/" + strings.Join(tenants, ",")
 	return apiURL
@@ -132,6 +186,7 @@ func (postMgr *PostManager) postConfig(data string, tenants []string) (bool, str
 		data:      data,
 		as3APIURL: postMgr.getAS3APIURL(tenants),
 	}
+	postMgr.failures = nil
 	httpReqBody := bytes.NewBuffer([]byte(cfg.data))
 
 	req, err := http.NewRequest("POST", cfg.as3APIURL, httpReqBody)
@@ -140,7 +195,7 @@ func (postMgr *PostManager) postConfig(data string, tenants []string) (bool, str
 		return false, responseStatusCommon
 	}
 	log.Debugf("[AS3] posting request to %v", cfg.as3APIURL)
-	req.SetBasicAuth(postMgr.BIGIPUsername, postMgr.BIGIPPassword)
+	postMgr.setBasicAuth(req)
 
 	httpResp, responseMap := postMgr.httpReq(req)
 	if httpResp == nil || responseMap == nil {
@@ -168,7 +223,7 @@ func (postMgr *PostManager) GetBigipAS3Version() (string, string, string, error)
 	}
 
 	log.Debugf("[AS3] posting GET BIGIP AS3 Version request on %v", url)
-	req.SetBasicAuth(postMgr.BIGIPUsername, postMgr.BIGIPPassword)
+	postMgr.setBasicAuth(req)
 
 	httpResp, responseMap := postMgr.httpReq(req)
 	if httpResp == nil || responseMap == nil {
@@ -195,8 +250,16 @@ func (postMgr *PostManager) GetBigipAS3Version() (string, string, string, error)
 	return "", "", "", fmt.Errorf("Error response from BIGIP with status code %v", httpResp.StatusCode)
 }
 
+// doRequest sends the request to BIG-IP, with the auth token when auth tokens are used
+func (postMgr *PostManager) doRequest(request *http.Request) (*http.Response, error) {
+	if postMgr.tokenManager != nil {
+		return postMgr.tokenManager.Do(postMgr.getHTTPClient(), request)
+	}
+	return postMgr.getHTTPClient().Do(request)
+}
+
 func (postMgr *PostManager) httpReq(request *http.Request) (*http.Response, map[string]interface{}) {
-	httpResp, err := postMgr.httpClient.Do(request)
+	httpResp, err := postMgr.doRequest(request)
 	if err != nil {
 		log.Errorf("[AS3] REST call error: %v ", err)
 		return nil, nil
@@ -248,6 +311,7 @@ func (postMgr *PostManager) handleResponseStatusNotFound(responseMap map[string]
 	if postMgr.LogResponse {
 		log.Errorf("[AS3] Raw response from Big-IP: %v ", responseMap)
 	}
+	postMgr.failures = ParseAS3Failures(responseMap)
 	return true, responseStatusNotFound
 }
 
@@ -267,6 +331,7 @@ func (postMgr *PostManager) handleResponseOthers(responseMap map[string]interfac
 	if postMgr.LogResponse {
 		log.Errorf("[AS3] Raw response from Big-IP: %v ", responseMap)
 	}
+	postMgr.failures = ParseAS3Failures(responseMap)
 	//return postMgr.postOnEventOrTimeout(timeoutMedium, cfg)
 	return false, responseStatusCommon
 }
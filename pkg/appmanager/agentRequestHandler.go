@@ -3,10 +3,17 @@ package appmanager
 import (
 	cisAgent "github.com/F5Networks/k8s-bigip-ctlr/pkg/agent"
 	. "github.com/F5Networks/k8s-bigip-ctlr/pkg/resource"
+	log "github.com/F5Networks/k8s-bigip-ctlr/pkg/vlogger"
 )
 
 // Method to deploy resources on configured agent
 func (appMgr *Manager) deployResource() error {
+	// Only the leader deploys the resources, which are kept up to date in standby
+	if appMgr.isStandby() {
+		log.Debugf("[CORE] Not the leader, skipping the deployment of resources")
+		return nil
+	}
+
 	// Generate Agent Request
 
 	// Prepare Custom Profiles Copy
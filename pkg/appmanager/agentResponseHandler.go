@@ -18,15 +18,20 @@ package appmanager
 
 import (
 	"context"
+	"strings"
+
 	"github.com/F5Networks/k8s-bigip-ctlr/pkg/resource"
 	log "github.com/F5Networks/k8s-bigip-ctlr/pkg/vlogger"
 	routeapi "github.com/openshift/api/route/v1"
 	v1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 const (
 	F5RouterName = "F5 BIG-IP"
+	// Reason of the events recorded for the AS3 failures
+	AS3RejectedReason = "AS3Rejected"
 )
 
 // Get the RFC3339Copy of the timestamp for updating the OpenShift Routes
@@ -138,6 +143,108 @@ func (appMgr *Manager) agentResponseWorker() {
 				log.Debugf("[CORE] Updating Route Admit Status")
 				appMgr.updateRouteAdmitStatus()
 			}
+		} else if len(rspMsg.AS3Failures) != 0 {
+			log.Debugf("[CORE] Recording AS3 failures as events")
+			appMgr.recordAS3Failures(rspMsg.AS3Failures)
+		}
+	}
+}
+
+// recordAS3Failures records the AS3 failures as events on the ConfigMaps,
+// Ingresses and Routes which produced the failed tenants and objects
+func (appMgr *Manager) recordAS3Failures(failures []resource.AS3Failure) {
+	for _, failure := range failures {
+		for _, key := range failure.ConfigMaps {
+			keyParts := strings.Split(key, "/")
+			if len(keyParts) != 2 {
+				continue
+			}
+			cm, err := appMgr.kubeClient.CoreV1().ConfigMaps(keyParts[0]).Get(
+				context.TODO(), keyParts[1], metaV1.GetOptions{})
+			if err != nil {
+				log.Debugf("[CORE] Unable to get ConfigMap %v to record AS3 failure: %v", key, err)
+				continue
+			}
+			appMgr.recordAS3FailureEvent(cm, cm.ObjectMeta.Namespace, failure.Message)
+		}
+		// Tenants of the user defined ConfigMaps are not produced by Ingresses or Routes
+		if failure.Tenant != resource.DEFAULT_PARTITION {
+			continue
+		}
+		for _, obj := range appMgr.getAS3FailureResources(failure.Resources) {
+			appMgr.recordAS3FailureEvent(obj, obj.(metaV1.Object).GetNamespace(), failure.Message)
+		}
+	}
+}
+
+// getAS3FailureResources returns the Ingresses and Routes using the services
+// of the failed virtuals and pools, failure without any known virtual or pool
+// is reported on all the Ingresses and Routes
+func (appMgr *Manager) getAS3FailureResources(rscNames []string) []runtime.Object {
+	svcDeps := make(map[resource.ObjectDependency]struct{})
+	addSvcDep := func(namespace, svcName string) {
+		svcDeps[resource.ObjectDependency{
+			Kind:      resource.ServiceDep,
+			Namespace: namespace,
+			Name:      svcName,
+		}] = struct{}{}
+	}
+	appMgr.resources.Lock()
+	appMgr.resources.ForEach(func(key resource.ServiceKey, cfg *resource.ResourceConfig) {
+		if len(rscNames) == 0 {
+			addSvcDep(key.Namespace, key.ServiceName)
+			return
+		}
+		for _, name := range rscNames {
+			if name == cfg.Virtual.Name {
+				addSvcDep(key.Namespace, key.ServiceName)
+			}
+			for _, pool := range cfg.Pools {
+				if name == pool.Name {
+					addSvcDep(key.Namespace, pool.ServiceName)
+				}
+			}
+		}
+	})
+	appMgr.resources.Unlock()
+
+	var objs []runtime.Object
+	namespaces := make(map[string]struct{})
+	for dep := range svcDeps {
+		namespaces[dep.Namespace] = struct{}{}
+	}
+	for namespace := range namespaces {
+		appInf, found := appMgr.getNamespaceInformer(namespace)
+		if !found {
+			continue
+		}
+		var nsObjs []interface{}
+		if nil != appInf.ingInformer {
+			ings, _ := appInf.ingInformer.GetIndexer().ByIndex("namespace", namespace)
+			nsObjs = append(nsObjs, ings...)
 		}
+		if nil != appInf.routeInformer {
+			routes, _ := appInf.routeInformer.GetIndexer().ByIndex("namespace", namespace)
+			nsObjs = append(nsObjs, routes...)
+		}
+		for _, obj := range nsObjs {
+			_, deps := resource.NewObjectDependencies(obj)
+			for dep := range deps {
+				if _, ok := svcDeps[dep]; ok {
+					objs = append(objs, obj.(runtime.Object))
+					break
+				}
+			}
+		}
+	}
+	return objs
+}
+
+func (appMgr *Manager) recordAS3FailureEvent(obj runtime.Object, namespace, message string) {
+	if appMgr.eventNotifier == nil {
+		return
 	}
+	evNotifier := appMgr.eventNotifier.CreateNotifierForNamespace(
+		namespace, appMgr.kubeClient.CoreV1())
+	evNotifier.RecordEvent(obj, v1.EventTypeWarning, AS3RejectedReason, message)
 }
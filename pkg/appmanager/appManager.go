@@ -139,6 +139,9 @@ type Manager struct {
 	processAgentLabels func(map[string]string, string, string) bool
 	K8sVersion         string
 	TeemData           *teem.TeemsData
+	// Followers of the leader election process the resources without deploying them
+	standbyMutex sync.Mutex
+	standby      bool
 }
 
 // Watched Namespaces for global availability.
@@ -178,6 +181,7 @@ type Params struct {
 	AgRspChan          chan interface{}
 	ProcessAgentLabels func(map[string]string, string, string) bool
 	UserAgent          string
+	LeaderElection     bool
 }
 
 // Configuration options for Routes in OpenShift
@@ -206,6 +210,8 @@ const (
 	Routes         = "routes"
 	Secrets        = "secrets"
 	IngressClasses = "ingressclasses"
+	// Queued when CIS is elected as the leader to deploy the resources
+	Leader = "leader"
 
 	hubModeInterval = 30 * time.Second //Hubmode ConfigMap resync interval
 )
@@ -259,6 +265,7 @@ func NewManager(params *Params) *Manager {
 		processAgentLabels:     params.ProcessAgentLabels,
 		agentCfgMap:            make(map[string]*AgentCfgMap),
 		agentCfgMapSvcCache:    make(map[string]*SvcEndPointsCache),
+		standby:                params.LeaderElection,
 	}
 	manager.processedResources = make(map[string]bool)
 
@@ -939,6 +946,21 @@ func (appMgr *Manager) IsNodePort() bool {
 	return appMgr.isNodePort
 }
 
+// StartLeading deploys the resources once CIS is elected as the leader.
+// Until then the resources are processed in standby without deploying them.
+func (appMgr *Manager) StartLeading() {
+	appMgr.standbyMutex.Lock()
+	appMgr.standby = false
+	appMgr.standbyMutex.Unlock()
+	appMgr.vsQueue.Add(serviceQueueKey{ResourceKind: Leader})
+}
+
+func (appMgr *Manager) isStandby() bool {
+	appMgr.standbyMutex.Lock()
+	defer appMgr.standbyMutex.Unlock()
+	return appMgr.standby
+}
+
 func (appMgr *Manager) UseNodeInternal() bool {
 	return appMgr.useNodeInternal
 }
@@ -1070,6 +1092,14 @@ func (appMgr *Manager) processNextVirtualServer() bool {
 
 	defer appMgr.vsQueue.Done(key)
 	skey := key.(serviceQueueKey)
+	if skey.ResourceKind == Leader {
+		// Resources processed in standby are deployed once the initial sync is complete
+		if appMgr.steadyState {
+			appMgr.deployResource()
+		}
+		appMgr.vsQueue.Forget(key)
+		return true
+	}
 	if !appMgr.steadyState {
 		//If not in steady state update,delete events are added back to queue
 		//processed once in steady state
@@ -1129,6 +1159,8 @@ func (appMgr *Manager) syncVirtualServer(sKey serviceQueueKey) error {
 		endTime := time.Now()
 		log.Debugf("[CORE] Finished syncing virtual servers %+v in namespace %+v (%v), %v/%v",
 			sKey.ServiceName, sKey.Namespace, endTime.Sub(startTime), appMgr.processedItems, appMgr.queueLen)
+		bigIPPrometheus.ProcessingDuration.WithLabelValues("syncVirtualServer").
+			Observe(endTime.Sub(startTime).Seconds())
 	}()
 	// Get the informers for the namespace. This will tell us if we care about
 	// this item.
@@ -2957,7 +2989,7 @@ func (appMgr *Manager) getEndpointsForCluster(
 			if portName == p.Name {
 				for _, addr := range subset.Addresses {
 					// Checking for headless service
-					if containsNode(nodes, *addr.NodeName) || clusterIP == "None" {
+					if (addr.NodeName != nil && containsNode(nodes, *addr.NodeName)) || clusterIP == "None" {
 						member := Member{
 							Address: addr.IP,
 							Port:    p.Port,
@@ -653,6 +653,24 @@ var _ = Describe("AppManager Tests", func() {
 			Expect(func() { appMgr.deployResource() }).ToNot(Panic())
 			Expect(mw.WrittenTimes).To(Equal(1))
 		})
+
+		It("Deploys the resources only after it is elected as the leader", func() {
+			mw := &test.MockWriter{
+				FailStyle: test.Success,
+				Sections:  make(map[string]interface{}),
+			}
+			appMgr.AgentCIS, _ = agent.CreateAgent(agent.CCCLAgent)
+			appMgr.AgentCIS.Init(&cccl.Params{ConfigWriter: mw})
+			appMgr.standby = true
+			appMgr.steadyState = true
+			Expect(appMgr.deployResource()).To(BeNil())
+			Expect(mw.WrittenTimes).To(Equal(0), "Resources deployed in standby")
+
+			appMgr.StartLeading()
+			Expect(appMgr.isStandby()).To(BeFalse())
+			Expect(appMgr.processNextVirtualServer()).To(BeTrue())
+			Expect(mw.WrittenTimes).To(Equal(1), "Resources not deployed by the leader")
+		})
 	})
 
 	Context("Using Real Manager", func() {
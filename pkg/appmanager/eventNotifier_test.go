@@ -30,6 +30,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	routeapi "github.com/openshift/api/route/v1"
 	fakeRouteClient "github.com/openshift/client-go/route/clientset/versioned/fake"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
@@ -66,6 +67,14 @@ func NewFakeEvent(
 		ing := obj.(*netv1.Ingress)
 		namespace = ing.ObjectMeta.Namespace
 		name = ing.ObjectMeta.Name
+	case *routeapi.Route:
+		route := obj.(*routeapi.Route)
+		namespace = route.ObjectMeta.Namespace
+		name = route.ObjectMeta.Name
+	case *v1.ConfigMap:
+		cm := obj.(*v1.ConfigMap)
+		namespace = cm.ObjectMeta.Namespace
+		name = cm.ObjectMeta.Name
 	default:
 		// Set namespace and name to the error message
 		namespace = fmt.Sprintf("NewFakeEvent: Unhandled object type: %T\n", obj)
@@ -279,5 +288,46 @@ var _ = Describe("Event Notifier Tests", func() {
 				}
 			}
 		})
+		It("AS3 failures are recorded on the originating resources", func() {
+			deployIngress(0, "v1beta1")
+			deployIngress(1, "v1beta1")
+			cfgMap := test.NewConfigMap("as3-cfgmap", "1", namespaces[2], map[string]string{})
+			_, err := mockMgr.appMgr.kubeClient.CoreV1().ConfigMaps(namespaces[2]).Create(
+				context.TODO(), cfgMap, metav1.CreateOptions{})
+			Expect(err).To(BeNil())
+
+			rsCfgs := mockMgr.appMgr.resources.GetAll(ServiceKey{
+				ServiceName: "service",
+				ServicePort: 80,
+				Namespace:   namespaces[0],
+			})
+			Expect(rsCfgs).To(HaveLen(1))
+			Expect(rsCfgs[0].Pools).To(HaveLen(1))
+
+			mockMgr.appMgr.recordAS3Failures([]AS3Failure{
+				{
+					Tenant:    DEFAULT_PARTITION,
+					Message:   "invalid pool",
+					Resources: []string{rsCfgs[0].Pools[0].Name},
+				},
+				{
+					Tenant:     "as3tenant",
+					Message:    "invalid tenant",
+					ConfigMaps: []string{namespaces[2] + "/" + cfgMap.Name},
+				},
+			})
+			events := mockMgr.getFakeEvents(namespaces[0])
+			Expect(events).To(HaveLen(3))
+			Expect(events[2].Name).To(Equal("ingress"))
+			Expect(events[2].EventType).To(Equal(v1.EventTypeWarning))
+			Expect(events[2].Reason).To(Equal(AS3RejectedReason))
+			Expect(events[2].Message).To(Equal("invalid pool"))
+			Expect(mockMgr.getFakeEvents(namespaces[1])).To(HaveLen(2),
+				"Ingress using other service should not get the failure")
+			events = mockMgr.getFakeEvents(namespaces[2])
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Name).To(Equal(cfgMap.Name))
+			Expect(events[0].Message).To(Equal("invalid tenant"))
+		})
 	})
 })
@@ -0,0 +1,199 @@
+/*-
+ * Copyright (c) 2016-2021, F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package appmanager
+
+import (
+	"fmt"
+
+	. "github.com/F5Networks/k8s-bigip-ctlr/pkg/resource"
+	"github.com/F5Networks/k8s-bigip-ctlr/pkg/teem"
+	log "github.com/F5Networks/k8s-bigip-ctlr/pkg/vlogger"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	netv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+// renderAgent keeps the resource request of the last deployment instead of
+// handing it over to BIG-IP
+type renderAgent struct {
+	rsReq ResourceRequest
+}
+
+func (ag *renderAgent) Init(interface{}) error { return nil }
+
+func (ag *renderAgent) Deploy(req interface{}) error {
+	ag.rsReq = req.(MessageRequest).ResourceRequest
+	return nil
+}
+
+func (ag *renderAgent) Remove(string) error { return nil }
+
+func (ag *renderAgent) DeInit() error { return nil }
+
+func (ag *renderAgent) IsImplInAgent(rsrc string) bool {
+	return ResourceTypeCfgMap == rsrc
+}
+
+// RenderResourceRequest processes the Kubernetes objects with the App Manager,
+// without a cluster or a BIG-IP, and returns the resource request which is
+// handed over to the AS3 agent to prepare the declaration.
+func RenderResourceRequest(
+	params *Params,
+	namespaces []string,
+	objs []runtime.Object,
+) (ResourceRequest, error) {
+	// Only the objects watched by the App Manager are seeded to the client
+	var k8sObjs []runtime.Object
+	var nodes []v1.Node
+	k8sVersion := "v1.19.0"
+	for _, obj := range objs {
+		switch rsc := obj.(type) {
+		case *v1beta1.Ingress:
+			// Ingress informers are created for the version of the Ingress
+			k8sVersion = "v1.18.0"
+			k8sObjs = append(k8sObjs, obj)
+		case *v1.Node:
+			nodes = append(nodes, *rsc)
+			k8sObjs = append(k8sObjs, obj)
+		case *v1.Service, *v1.Endpoints, *v1.ConfigMap, *v1.Secret, *netv1.Ingress,
+			*netv1.IngressClass:
+			k8sObjs = append(k8sObjs, obj)
+		}
+	}
+	params.KubeClient = fake.NewSimpleClientset(k8sObjs...)
+	// Closed once rendered to stop the agent response worker of the App Manager
+	params.AgRspChan = make(chan interface{})
+	defer close(params.AgRspChan)
+
+	agent := &renderAgent{}
+	appMgr := NewManager(params)
+	appMgr.AgentCIS = agent
+	appMgr.K8sVersion = k8sVersion
+	appMgr.TeemData = &teem.TeemsData{
+		ResourceType: teem.ResourceTypes{
+			Ingresses:  make(map[string]int),
+			Routes:     make(map[string]int),
+			Configmaps: make(map[string]int),
+		},
+	}
+
+	cfgMapSelector, err := labels.Parse(DefaultConfigMapLabel)
+	if err != nil {
+		return ResourceRequest{}, err
+	}
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+	for _, ns := range namespaces {
+		if err := appMgr.AddNamespace(ns, cfgMapSelector, 0); err != nil {
+			return ResourceRequest{}, err
+		}
+	}
+	appMgr.ProcessNodeUpdate(nodes, nil)
+
+	// Informers are not started, the objects are added to their stores the
+	// same way the informers filter them in a cluster
+	for _, obj := range k8sObjs {
+		if ingClass, ok := obj.(*netv1.IngressClass); ok {
+			// IngressClasses are cluster wide, each namespace informer watches them
+			for _, appInf := range appMgr.appInformers {
+				if appInf.ingClassInformer != nil {
+					_ = appInf.ingClassInformer.GetStore().Add(ingClass)
+				}
+			}
+			continue
+		}
+		store := appMgr.getRenderStore(obj)
+		if store == nil {
+			continue
+		}
+		if cm, ok := obj.(*v1.ConfigMap); ok && !cfgMapSelector.Matches(labels.Set(cm.Labels)) {
+			log.Warningf("[CORE] Skipping ConfigMap %v/%v without the label %v",
+				cm.Namespace, cm.Name, DefaultConfigMapLabel)
+			continue
+		}
+		if err := store.Add(obj); err != nil {
+			return ResourceRequest{}, fmt.Errorf("failed to add object: %v", err)
+		}
+	}
+
+	var keys []*serviceQueueKey
+	for _, obj := range k8sObjs {
+		var ok bool
+		var objKeys []*serviceQueueKey
+		switch rsc := obj.(type) {
+		case *v1.Service:
+			ok, objKeys = appMgr.checkValidService(rsc)
+		case *v1.Endpoints:
+			ok, objKeys = appMgr.checkValidEndpoints(rsc)
+		case *v1.ConfigMap:
+			if appMgr.manageConfigMaps && cfgMapSelector.Matches(labels.Set(rsc.Labels)) {
+				ok, objKeys = appMgr.checkValidConfigMap(rsc, OprTypeCreate)
+			}
+		case *v1beta1.Ingress, *netv1.Ingress:
+			if appMgr.manageIngress {
+				ok, objKeys = appMgr.checkValidIngress(rsc)
+			}
+		}
+		if ok {
+			keys = append(keys, objKeys...)
+		}
+	}
+	for _, key := range keys {
+		if err := appMgr.syncVirtualServer(*key); err != nil {
+			return ResourceRequest{}, err
+		}
+	}
+	appMgr.deployResource()
+
+	return agent.rsReq, nil
+}
+
+// getRenderStore returns the informer store for the object
+func (appMgr *Manager) getRenderStore(obj runtime.Object) cache.Store {
+	metaObj, err := meta.Accessor(obj)
+	if err != nil {
+		return nil
+	}
+	appInf, ok := appMgr.getNamespaceInformer(metaObj.GetNamespace())
+	if !ok {
+		return nil
+	}
+
+	var informer cache.SharedIndexInformer
+	switch obj.(type) {
+	case *v1.Service:
+		informer = appInf.svcInformer
+	case *v1.Endpoints:
+		informer = appInf.endptInformer
+	case *v1.ConfigMap:
+		informer = appInf.cfgMapInformer
+	case *v1.Secret:
+		informer = appInf.secretInformer
+	case *v1beta1.Ingress, *netv1.Ingress:
+		informer = appInf.ingInformer
+	}
+	if informer == nil {
+		return nil
+	}
+	return informer.GetStore()
+}
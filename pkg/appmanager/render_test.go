@@ -0,0 +1,78 @@
+/*-
+ * Copyright (c) 2016-2021, F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package appmanager
+
+import (
+	. "github.com/F5Networks/k8s-bigip-ctlr/pkg/resource"
+	"github.com/F5Networks/k8s-bigip-ctlr/pkg/test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+var _ = Describe("Render Tests", func() {
+	namespace := "default"
+	var objs []runtime.Object
+
+	BeforeEach(func() {
+		ing := test.NewIngress("ingress", "1", namespace,
+			v1beta1.IngressSpec{
+				Backend: &v1beta1.IngressBackend{
+					ServiceName: "foo",
+					ServicePort: intstr.IntOrString{IntVal: 80},
+				},
+			},
+			map[string]string{
+				F5VsBindAddrAnnotation:  "1.2.3.4",
+				F5VsPartitionAnnotation: "velcro",
+			})
+		svc := test.NewService("foo", "1", namespace, v1.ServiceTypeClusterIP,
+			[]v1.ServicePort{{Port: 80}})
+		eps := test.NewEndpoints("foo", "1", "node0", namespace, []string{"10.2.96.3"}, nil,
+			[]v1.EndpointPort{{Port: 80}})
+		node := test.NewNode("node0", "1", false,
+			[]v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "127.0.0.0"}}, nil)
+		objs = []runtime.Object{ing, svc, eps, node}
+	})
+
+	It("Renders the resource request of Ingresses", func() {
+		rsReq, err := RenderResourceRequest(&Params{
+			ManageIngress:   true,
+			UseNodeInternal: true,
+		}, nil, objs)
+		Expect(err).To(BeNil())
+		Expect(rsReq.Resources).NotTo(BeNil())
+		Expect(len(rsReq.Resources.RsCfgs)).To(Equal(1), "Ingress should be processed")
+		rsCfg := rsReq.Resources.RsCfgs[0]
+		Expect(rsCfg.Virtual.Destination).To(Equal("/velcro/1.2.3.4:80"))
+		Expect(len(rsCfg.Pools)).To(Equal(1))
+		Expect(rsCfg.Pools[0].Members).To(Equal([]Member{
+			{Address: "10.2.96.3", Port: 80, SvcPort: 80, Session: "user-enabled"},
+		}))
+	})
+
+	It("Skips the Ingresses when they are not managed", func() {
+		rsReq, err := RenderResourceRequest(&Params{
+			ManageIngress: false,
+		}, nil, objs)
+		Expect(err).To(BeNil())
+		Expect(rsReq.Resources.RsCfgs).To(BeEmpty())
+	})
+})
@@ -63,6 +63,11 @@ func NewAgent(params AgentParams) *Agent {
 		EventChan:    make(chan interface{}),
 		activeDecl:   "",
 		userAgent:    params.UserAgent,
+		declWriter:   params.DeclarationWriter,
+	}
+	if agent.declWriter != nil {
+		log.Info("[DRY-RUN] Declarations are written instead of being posted to BIG-IP")
+		return agent
 	}
 	// If running in VXLAN mode, extract the partition name from the tunnel
 	// to be used in configuring a net instance of CCCL for that partition
@@ -86,29 +91,8 @@ func NewAgent(params AgentParams) *Agent {
 		DisableLTM:     true,
 		GTM:            true,
 	}
-	bs := bigIPSection{
-		BigIPUsername:   params.PostParams.BIGIPUsername,
-		BigIPPassword:   params.PostParams.BIGIPPassword,
-		BigIPURL:        params.PostParams.BIGIPURL,
-		BigIPPartitions: []string{params.Partition},
-	}
-
-	var gtm gtmBigIPSection
-	if len(params.GTMParams.GTMBigIpUrl) == 0 || len(params.GTMParams.GTMBigIpUsername) == 0 || len(params.GTMParams.GTMBigIpPassword) == 0 {
-		// gs.GTM = false
-		gtm = gtmBigIPSection{
-			GtmBigIPUsername: params.PostParams.BIGIPUsername,
-			GtmBigIPPassword: params.PostParams.BIGIPPassword,
-			GtmBigIPURL:      params.PostParams.BIGIPURL,
-		}
-		log.Warning("Creating GTM with default bigip credentials as GTM BIGIP Url or GTM BIGIP Username or GTM BIGIP Password is missing on CIS args.")
-	} else {
-		gtm = gtmBigIPSection{
-			GtmBigIPUsername: params.GTMParams.GTMBigIpUsername,
-			GtmBigIPPassword: params.GTMParams.GTMBigIpPassword,
-			GtmBigIPURL:      params.GTMParams.GTMBigIpUrl,
-		}
-	}
+	bs := newBigIPSection(params.PostParams, params.Partition)
+	gtm := newGTMBigIPSection(params.PostParams, params.GTMParams)
 
 	agent.startPythonDriver(
 		gs,
@@ -120,6 +104,53 @@ func NewAgent(params AgentParams) *Agent {
 	return agent
 }
 
+func newBigIPSection(postParams PostParams, partition string) bigIPSection {
+	return bigIPSection{
+		BigIPUsername:   postParams.BIGIPUsername,
+		BigIPPassword:   postParams.BIGIPPassword,
+		BigIPURL:        postParams.BIGIPURL,
+		BigIPPartitions: []string{partition},
+	}
+}
+
+func newGTMBigIPSection(postParams PostParams, gtmParams GTMParams) gtmBigIPSection {
+	if len(gtmParams.GTMBigIpUrl) == 0 || len(gtmParams.GTMBigIpUsername) == 0 || len(gtmParams.GTMBigIpPassword) == 0 {
+		log.Warning("Creating GTM with default bigip credentials as GTM BIGIP Url or GTM BIGIP Username or GTM BIGIP Password is missing on CIS args.")
+		return gtmBigIPSection{
+			GtmBigIPUsername: postParams.BIGIPUsername,
+			GtmBigIPPassword: postParams.BIGIPPassword,
+			GtmBigIPURL:      postParams.BIGIPURL,
+		}
+	}
+	return gtmBigIPSection{
+		GtmBigIPUsername: gtmParams.GTMBigIpUsername,
+		GtmBigIPPassword: gtmParams.GTMBigIpPassword,
+		GtmBigIPURL:      gtmParams.GTMBigIpUrl,
+	}
+}
+
+// UpdateCredentials updates the credentials and the trusted certificates of the BIG-IP
+// and of the GTM BIG-IP without restarting the controller
+func (agent *Agent) UpdateCredentials(postParams PostParams, gtmParams GTMParams) {
+	agent.PostManager.updateCredentials(
+		postParams.BIGIPUsername,
+		postParams.BIGIPPassword,
+		postParams.TrustedCerts,
+		postParams.SSLInsecure,
+	)
+	if agent.declWriter != nil {
+		return
+	}
+	// The python driver reconnects to the BIG-IPs with the updated sections
+	sections := map[string]interface{}{
+		"bigip":     newBigIPSection(postParams, agent.Partition),
+		"gtm_bigip": newGTMBigIPSection(postParams, gtmParams),
+	}
+	if err := writeDriverSections(agent.ConfigWriter, sections); err != nil {
+		log.Errorf("Failed to update the BIG-IP credentials of the python driver: %v", err)
+	}
+}
+
 func (agent *Agent) Stop() {
 	agent.ConfigWriter.Stop()
 	agent.stopPythonDriver()
@@ -127,12 +158,26 @@ func (agent *Agent) Stop() {
 
 func (agent *Agent) PostConfig(config ResourceConfigWrapper) {
 	agent.PostGTMConfig(config)
-	decl := createAS3Declaration(config, agent.userAgent)
+	if agent.partitions == nil {
+		agent.partitions = make(map[string]struct{})
+	}
+	for _, rsCfg := range config.rsCfgs {
+		agent.partitions[getTenantName(rsCfg)] = struct{}{}
+	}
+	config.partitions = agent.partitions
+	adc := createAS3ADC(config)
+	decl := createTenantDeclaration(adc, agent.userAgent)
 	if DeepEqualJSON(agent.activeDecl, decl) {
 		log.Debug("[AS3] No Change in the Configuration")
 		return
 	}
-	agent.Write(string(decl), nil)
+	if agent.declWriter != nil {
+		if err := agent.declWriter.Write("cis-crd", string(decl)); err != nil {
+			log.Errorf("[DRY-RUN] Failed to write declaration: %v", err)
+		}
+	} else {
+		agent.postChangedTenants(config, adc)
+	}
 	agent.activeDecl = decl
 
 	allPoolMembers := config.rsCfgs.GetAllPoolMembers()
@@ -155,6 +200,95 @@ func (agent *Agent) PostConfig(config ResourceConfigWrapper) {
 	}
 }
 
+// postChangedTenants posts the declaration of only the Tenants which differ from
+// their declaration last accepted by BIG-IP
+func (agent *Agent) postChangedTenants(config ResourceConfigWrapper, adc as3ADC) {
+	tenants := agent.getChangedTenants(adc)
+	if len(tenants) == 0 {
+		log.Debug("[AS3] No Change in the Tenants accepted by BIG-IP")
+		return
+	}
+	log.Debugf("[AS3] Posting the declaration of the Tenants: %v", tenants)
+
+	tenantDecls := as3ADC{}
+	for _, tenant := range tenants {
+		tenantDecls[tenant] = adc[tenant]
+	}
+	tenantConfig := config
+	tenantConfig.rsCfgs = ResourceConfigs{}
+	for _, rsCfg := range config.rsCfgs {
+		if _, ok := tenantDecls[getTenantName(rsCfg)]; ok {
+			tenantConfig.rsCfgs = append(tenantConfig.rsCfgs, rsCfg)
+		}
+	}
+	agent.Write(
+		string(createTenantDeclaration(tenantDecls, agent.userAgent)),
+		tenants,
+		getRequestMeta(tenantConfig),
+	)
+}
+
+// getTenantName returns the AS3 Tenant of the resource config
+func getTenantName(rsCfg *ResourceConfig) string {
+	if rsCfg.Virtual.Partition == "" {
+		return DEFAULT_PARTITION
+	}
+	return rsCfg.Virtual.Partition
+}
+
+// getTenants returns the AS3 Tenants in the declaration of the resource configs,
+// the declaration is posted only for these Tenants leaving the others on BIG-IP intact
+func getTenants(config ResourceConfigWrapper) []string {
+	tenantSet := map[string]struct{}{DEFAULT_PARTITION: {}}
+	for partition := range config.partitions {
+		tenantSet[partition] = struct{}{}
+	}
+	for _, rsCfg := range config.rsCfgs {
+		tenantSet[getTenantName(rsCfg)] = struct{}{}
+	}
+	var tenants []string
+	for tenant := range tenantSet {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	return tenants
+}
+
+// getRequestMeta returns the custom resources contributing to the resource configs
+// along with the AS3 objects created for them
+func getRequestMeta(config ResourceConfigWrapper) requestMeta {
+	reqMeta := requestMeta{
+		resources: make(map[string]string),
+		objects:   make(map[string][]string),
+	}
+	for _, cfg := range config.rsCfgs {
+		var objNames []string
+		objNames = append(objNames, cfg.Virtual.Name)
+		for _, pl := range cfg.Pools {
+			objNames = append(objNames, pl.Name)
+		}
+		for _, mon := range cfg.Monitors {
+			objNames = append(objNames, mon.Name)
+		}
+		for _, pol := range cfg.Policies {
+			objNames = append(objNames, pol.Name)
+		}
+		for ref := range cfg.IRulesMap {
+			objNames = append(objNames, ref.Name)
+		}
+		for ref := range cfg.IntDgMap {
+			objNames = append(objNames, ref.Name)
+		}
+		for key, kind := range cfg.MetaData.baseResources {
+			reqMeta.resources[key] = kind
+			for _, name := range objNames {
+				reqMeta.objects[name] = append(reqMeta.objects[name], key)
+			}
+		}
+	}
+	return reqMeta
+}
+
 func (agent Agent) PostGTMConfig(config ResourceConfigWrapper) {
 
 	dnsConfig := make(map[string]interface{})
@@ -184,11 +318,16 @@ func (agent Agent) PostGTMConfig(config ResourceConfigWrapper) {
 
 //Create AS3 declaration
 func createAS3Declaration(config ResourceConfigWrapper, userAgentInfo string) as3Declaration {
+	return createTenantDeclaration(createAS3ADC(config), userAgentInfo)
+}
+
+// createTenantDeclaration creates the AS3 declaration of the given Tenants
+func createTenantDeclaration(tenantDecls as3ADC, userAgentInfo string) as3Declaration {
 	var as3Config map[string]interface{}
 	_ = json.Unmarshal([]byte(baseAS3Config), &as3Config)
 
 	adc := as3Config["declaration"].(map[string]interface{})
-	for k, v := range createAS3ADC(config) {
+	for k, v := range tenantDecls {
 		adc[k] = v
 	}
 
@@ -204,38 +343,88 @@ func createAS3Declaration(config ResourceConfigWrapper, userAgentInfo string) as
 	return as3Declaration(decl)
 }
 
+// createAS3ADC creates an AS3 Tenant for each of the partitions of the resource configs
 func createAS3ADC(config ResourceConfigWrapper) as3ADC {
+	tenantCfgs := make(map[string]ResourceConfigs)
+	for _, tenant := range getTenants(config) {
+		tenantCfgs[tenant] = ResourceConfigs{}
+	}
+	for _, rsCfg := range config.rsCfgs {
+		tenant := getTenantName(rsCfg)
+		tenantCfgs[tenant] = append(tenantCfgs[tenant], rsCfg)
+	}
+
+	as3JSONDecl := as3ADC{}
+	for tenant, rsCfgs := range tenantCfgs {
+		as3JSONDecl[tenant] = createAS3Tenant(config, tenant, rsCfgs)
+	}
+	return as3JSONDecl
+}
+
+// createAS3Tenant creates the AS3 Tenant for the resource configs of a partition
+func createAS3Tenant(config ResourceConfigWrapper, tenant string, rsCfgs ResourceConfigs) as3Tenant {
 	// Create Shared as3Application object
 	sharedApp := as3Application{}
 	sharedApp["class"] = "Application"
 	sharedApp["template"] = "shared"
 	// Process rscfg to create AS3 Resources
-	processResourcesForAS3(config.rsCfgs, sharedApp, config.shareNodes)
+	processResourcesForAS3(rsCfgs, sharedApp, config.shareNodes, tenant)
 
 	// Process CustomProfiles
-	processCustomProfilesForAS3(config.customProfiles, sharedApp)
+	processCustomProfilesForAS3(getTenantCustomProfiles(config.customProfiles, rsCfgs), sharedApp)
 
 	// Process Profiles
-	processProfilesForAS3(config.rsCfgs, sharedApp)
+	processProfilesForAS3(rsCfgs, sharedApp, tenant)
 
-	processIRulesForAS3(config, sharedApp)
+	processIRulesForAS3(rsCfgs, sharedApp)
 
-	processDataGroupForAS3(config, sharedApp)
+	processDataGroupForAS3(rsCfgs, sharedApp)
 
 	// Create AS3 Tenant
-	tenant := as3Tenant{
+	return as3Tenant{
 		"class":              "Tenant",
-		"defaultRouteDomain": config.defaultRouteDomain,
+		"defaultRouteDomain": getTenantRouteDomain(rsCfgs, config.defaultRouteDomain),
 		as3SharedApplication: sharedApp,
 	}
-	as3JSONDecl := as3ADC{
-		DEFAULT_PARTITION: tenant,
+}
+
+// getTenantRouteDomain returns the default route domain of an AS3 Tenant, which is the
+// route domain of all the virtuals of the partition if they share one, else the default
+// route domain of CIS
+func getTenantRouteDomain(rsCfgs ResourceConfigs, defaultRouteDomain int) int {
+	var routeDomain int32
+	for i, rsCfg := range rsCfgs {
+		if i > 0 && rsCfg.Virtual.RouteDomain != routeDomain {
+			return defaultRouteDomain
+		}
+		routeDomain = rsCfg.Virtual.RouteDomain
 	}
-	return as3JSONDecl
+	if routeDomain == 0 {
+		return defaultRouteDomain
+	}
+	return int(routeDomain)
 }
 
-func processIRulesForAS3(config ResourceConfigWrapper, sharedApp as3Application) {
-	for _, rsCfg := range config.rsCfgs {
+// getTenantCustomProfiles returns the custom profiles of the virtuals in the resource configs
+func getTenantCustomProfiles(customProfiles *CustomProfileStore, rsCfgs ResourceConfigs) *CustomProfileStore {
+	virtuals := make(map[string]struct{})
+	for _, rsCfg := range rsCfgs {
+		virtuals[rsCfg.Virtual.Name] = struct{}{}
+	}
+	tenantProfiles := NewCustomProfiles()
+	if customProfiles == nil {
+		return tenantProfiles
+	}
+	for key, prof := range customProfiles.Profs {
+		if _, ok := virtuals[key.ResourceName]; ok {
+			tenantProfiles.Profs[key] = prof
+		}
+	}
+	return tenantProfiles
+}
+
+func processIRulesForAS3(rsCfgs ResourceConfigs, sharedApp as3Application) {
+	for _, rsCfg := range rsCfgs {
 		// Create irule declaration
 		for _, v := range rsCfg.IRulesMap {
 			iRule := &as3IRules{}
@@ -246,8 +435,8 @@ func processIRulesForAS3(config ResourceConfigWrapper, sharedApp as3Application)
 	}
 }
 
-func processDataGroupForAS3(config ResourceConfigWrapper, sharedApp as3Application) {
-	for _, rsCfg := range config.rsCfgs {
+func processDataGroupForAS3(rsCfgs ResourceConfigs, sharedApp as3Application) {
+	for _, rsCfg := range rsCfgs {
 		for idk, idg := range rsCfg.IntDgMap {
 			for _, dg := range idg {
 				dataGroupRecord, found := sharedApp[dg.Name]
@@ -322,7 +511,7 @@ func getDGRecordValueForAS3(dgName string, sharedApp as3Application, virtualAddr
 }
 
 //Process for AS3 Resource
-func processResourcesForAS3(rsCfgs ResourceConfigs, sharedApp as3Application, shareNodes bool) {
+func processResourcesForAS3(rsCfgs ResourceConfigs, sharedApp as3Application, shareNodes bool, tenant string) {
 	for _, cfg := range rsCfgs {
 		//Create policies
 		createPoliciesDecl(cfg, sharedApp)
@@ -331,12 +520,12 @@ func processResourcesForAS3(rsCfgs ResourceConfigs, sharedApp as3Application, sh
 		createMonitorDecl(cfg, sharedApp)
 
 		//Create pools
-		createPoolDecl(cfg, sharedApp, shareNodes)
+		createPoolDecl(cfg, sharedApp, shareNodes, tenant)
 
 		switch cfg.MetaData.ResourceType {
 		case VirtualServer:
 			//Create AS3 Service for virtual server
-			createServiceDecl(cfg, sharedApp)
+			createServiceDecl(cfg, sharedApp, tenant)
 		case TransportServer:
 			//Create AS3 Service for transport virtual server
 			createTransportServiceDecl(cfg, sharedApp)
@@ -374,17 +563,31 @@ func createPoliciesDecl(cfg *ResourceConfig, sharedApp as3Application) {
 }
 
 // Create AS3 Pools for CRD
-func createPoolDecl(cfg *ResourceConfig, sharedApp as3Application, shareNodes bool) {
+func createPoolDecl(cfg *ResourceConfig, sharedApp as3Application, shareNodes bool, tenant string) {
+	monitorNames := make(map[string]struct{})
+	for _, mon := range cfg.Monitors {
+		monitorNames[mon.Name] = struct{}{}
+		monitorNames[JoinBigipPath(mon.Partition, mon.Name)] = struct{}{}
+	}
 	for _, v := range cfg.Pools {
 		pool := &as3Pool{}
-		// TODO
-		// pool.LoadBalancingMode = v.Balance
+		pool.LoadBalancingMode = v.Balance
+		pool.SlowRampTime = v.SlowRampTime
+		if v.MinimumMonitors != 0 {
+			pool.MinimumMonitors = v.MinimumMonitors
+		} else if len(v.MonitorNames) > 1 {
+			// Members are up only when all the monitors are up by default
+			pool.MinimumMonitors = "all"
+		}
 		pool.Class = "Pool"
 		for _, val := range v.Members {
 			var member as3PoolMember
 			member.AddressDiscovery = "static"
 			member.ServicePort = val.Port
-			member.ServerAddresses = append(member.ServerAddresses, val.Address)
+			member.ConnectionLimit = v.ConnectionLimit
+			member.RateLimit = v.RateLimit
+			// Pool members are in the route domain of the virtual
+			member.ServerAddresses = append(member.ServerAddresses, withRouteDomain(val.Address, cfg.Virtual.RouteDomain))
 			if shareNodes {
 				member.ShareNodes = shareNodes
 			}
@@ -392,9 +595,15 @@ func createPoolDecl(cfg *ResourceConfig, sharedApp as3Application, shareNodes bo
 		}
 		for _, val := range v.MonitorNames {
 			var monitor as3ResourcePointer
+			// Monitors not created by CIS are referenced from BIG-IP
+			if _, ok := monitorNames[val]; !ok {
+				monitor.BigIP = val
+				pool.Monitors = append(pool.Monitors, monitor)
+				continue
+			}
 			use := strings.Split(val, "/")
 			monitor.Use = fmt.Sprintf("/%s/%s/%s",
-				DEFAULT_PARTITION,
+				tenant,
 				as3SharedApplication,
 				use[len(use)-1],
 			)
@@ -426,7 +635,9 @@ func processIrulesForCRD(cfg *ResourceConfig, svc *as3Service) {
 		}
 		if strings.HasSuffix(iRuleNoPort, HttpRedirectIRuleName) ||
 			strings.HasSuffix(iRuleNoPort, HttpRedirectNoHostIRuleName) ||
-			strings.HasSuffix(iRuleName, TLSIRuleName) {
+			strings.HasSuffix(iRuleName, TLSIRuleName) ||
+			strings.HasSuffix(iRuleName, AbDeploymentPathIRuleName) ||
+			strings.HasSuffix(iRuleName, SourceRangeIRuleName) {
 
 			IRules = append(IRules, iRuleName)
 		} else {
@@ -440,14 +651,14 @@ func processIrulesForCRD(cfg *ResourceConfig, svc *as3Service) {
 }
 
 // Create AS3 Service for CRD
-func createServiceDecl(cfg *ResourceConfig, sharedApp as3Application) {
+func createServiceDecl(cfg *ResourceConfig, sharedApp as3Application, tenant string) {
 	svc := &as3Service{}
 	numPolicies := len(cfg.Virtual.Policies)
 	switch {
 	case numPolicies == 1:
 		policyName := cfg.Virtual.Policies[0].Name
 		svc.PolicyEndpoint = fmt.Sprintf("/%s/%s/%s",
-			DEFAULT_PARTITION,
+			tenant,
 			as3SharedApplication,
 			policyName)
 	case numPolicies > 1:
@@ -457,7 +668,7 @@ func createServiceDecl(cfg *ResourceConfig, sharedApp as3Application) {
 				peps,
 				as3ResourcePointer{
 					Use: fmt.Sprintf("/%s/%s/%s",
-						DEFAULT_PARTITION,
+						tenant,
 						as3SharedApplication,
 						pep.Name,
 					),
@@ -470,7 +681,7 @@ func createServiceDecl(cfg *ResourceConfig, sharedApp as3Application) {
 		ps := strings.Split(cfg.Virtual.PoolName, "/")
 		if cfg.Virtual.PoolName != "" {
 			svc.Pool = fmt.Sprintf("/%s/%s/%s",
-				DEFAULT_PARTITION,
+				tenant,
 				as3SharedApplication,
 				ps[len(ps)-1])
 		}
@@ -483,9 +694,11 @@ func createServiceDecl(cfg *ResourceConfig, sharedApp as3Application) {
 		svc.TranslateServerPort = true
 		svc.Class = "Service_HTTP"
 	} else {
-		svc.PersistenceMethods = cfg.Virtual.PersistenceMethods
+		svc.PersistenceMethods = persistenceMethodsDecl(cfg.Virtual.PersistenceMethods)
 		svc.Class = "Service_TCP"
 	}
+	processPersistenceForCRD(cfg, svc, sharedApp)
+	processProfileSpecForCRD(cfg, svc)
 
 	if cfg.Virtual.SNAT == "auto" || cfg.Virtual.SNAT == "none" {
 		svc.SNAT = cfg.Virtual.SNAT
@@ -535,6 +748,99 @@ func createServiceDecl(cfg *ResourceConfig, sharedApp as3Application) {
 	sharedApp[cfg.Virtual.Name] = svc
 }
 
+// persistenceMethodsDecl converts the built-in persistence methods of a virtual
+func persistenceMethodsDecl(methods []string) []as3MultiTypeParam {
+	var persistenceMethods []as3MultiTypeParam
+	for _, method := range methods {
+		persistenceMethods = append(persistenceMethods, method)
+	}
+	return persistenceMethods
+}
+
+// Create AS3 Persist for the session persistence of a CRD virtual, the persistence
+// replaces the default persistence methods of the service without changing its class
+func processPersistenceForCRD(cfg *ResourceConfig, svc *as3Service, sharedApp as3Application) {
+	persistence := cfg.Virtual.Persistence
+	if persistence == nil {
+		return
+	}
+	if persistence.Reference != "" {
+		svc.PersistenceMethods = []as3MultiTypeParam{
+			&as3ResourcePointer{BigIP: persistence.Reference},
+		}
+	} else {
+		persist := &as3Persist{
+			Class:             "Persist",
+			PersistenceMethod: persistence.Method,
+			Duration:          persistence.Duration,
+		}
+		if persistence.Method == "cookie" {
+			persist.CookieMethod = persistence.CookieMethod
+			if persist.CookieMethod == "" {
+				persist.CookieMethod = "insert"
+			}
+			persist.CookieName = persistence.CookieName
+		}
+		if persistence.IRule != "" {
+			persist.IRule = &as3ResourcePointer{BigIP: persistence.IRule}
+		}
+		persistName := cfg.Virtual.Name + "_persistence"
+		sharedApp[persistName] = persist
+		svc.PersistenceMethods = []as3MultiTypeParam{
+			&as3ResourcePointer{Use: persistName},
+		}
+	}
+	if strings.HasPrefix(persistence.Fallback, "/") {
+		svc.FallbackPersistence = &as3ResourcePointer{BigIP: persistence.Fallback}
+	} else if persistence.Fallback != "" {
+		svc.FallbackPersistence = persistence.Fallback
+	}
+}
+
+// Attach the BIG-IP profiles referenced by a CRD virtual to its AS3 Service, profiles
+// which are not referenced keep the AS3 defaults of the service class. The HTTP/2
+// profile is attached with the TLS profiles as only Service_HTTPS supports it
+func processProfileSpecForCRD(cfg *ResourceConfig, svc *as3Service) {
+	profiles := cfg.Virtual.ProfileSpec
+	if profiles == nil {
+		return
+	}
+	switch {
+	case profiles.TCPClient != "" && profiles.TCPServer != "" && profiles.TCPClient != profiles.TCPServer:
+		svc.ProfileTCP = map[string]as3MultiTypeParam{
+			"ingress": &as3ResourcePointer{BigIP: profiles.TCPClient},
+			"egress":  &as3ResourcePointer{BigIP: profiles.TCPServer},
+		}
+	case profiles.TCPClient != "" && profiles.TCPServer == "":
+		svc.ProfileTCP = map[string]as3MultiTypeParam{
+			"ingress": &as3ResourcePointer{BigIP: profiles.TCPClient},
+			"egress":  "normal",
+		}
+	case profiles.TCPClient == "" && profiles.TCPServer != "":
+		svc.ProfileTCP = map[string]as3MultiTypeParam{
+			"ingress": "normal",
+			"egress":  &as3ResourcePointer{BigIP: profiles.TCPServer},
+		}
+	case profiles.TCPClient != "":
+		svc.ProfileTCP = &as3ResourcePointer{BigIP: profiles.TCPClient}
+	}
+	if profiles.HTTP != "" {
+		svc.ProfileHTTP = &as3ResourcePointer{BigIP: profiles.HTTP}
+	}
+	if profiles.HTTPCompression != "" {
+		svc.ProfileHTTPCompression = &as3ResourcePointer{BigIP: profiles.HTTPCompression}
+	}
+	if profiles.WebAcceleration != "" {
+		svc.ProfileAcceleration = &as3ResourcePointer{BigIP: profiles.WebAcceleration}
+	}
+	if profiles.OneConnect != "" {
+		svc.ProfileMultiplex = &as3ResourcePointer{BigIP: profiles.OneConnect}
+	}
+	if profiles.RequestLogging != "" {
+		svc.ProfileTrafficLog = &as3ResourcePointer{BigIP: profiles.RequestLogging}
+	}
+}
+
 // Create AS3 Service Address for Virtual Server Address
 func createServiceAddressDecl(cfg *ResourceConfig, virtualAddress string, sharedApp as3Application) string {
 	var name string
@@ -547,7 +853,7 @@ func createServiceAddressDecl(cfg *ResourceConfig, virtualAddress string, shared
 		serviceAddress.SpanningEnabled = sa.SpanningEnabled
 		serviceAddress.TrafficGroup = sa.TrafficGroup
 		serviceAddress.VirtualAddress = virtualAddress
-		name = "crd_service_address_" + strings.Replace(virtualAddress, ".", "_", -1)
+		name = AS3NameFormatter("crd_service_address_" + virtualAddress)
 		sharedApp[name] = serviceAddress
 	}
 	return name
@@ -585,6 +891,22 @@ func createRuleCondition(rl *Rule, rulesData *as3Rule, port int) {
 			continue
 		}
 
+		if c.Tcp {
+			condition.Type = "tcp"
+			condition.Address = &as3PolicyCompareString{
+				Values:  c.Values,
+				Operand: "match",
+			}
+			if c.Not {
+				condition.Address.Operand = "does-not-match"
+			}
+			if c.ClientAccepted {
+				condition.Event = "client-accepted"
+			}
+			rulesData.Conditions = append(rulesData.Conditions, condition)
+			continue
+		}
+
 		if c.Host {
 			condition.Name = "host"
 			var values []string
@@ -656,6 +978,12 @@ func createRuleAction(rl *Rule, rulesData *as3Rule) {
 		if v.Request {
 			action.Event = "request"
 		}
+		if v.ClientAccepted {
+			action.Event = "client-accepted"
+		}
+		if v.Reset {
+			action.Type = "drop"
+		}
 		if v.Redirect {
 			action.Type = "httpRedirect"
 		}
@@ -696,11 +1024,16 @@ func createRuleAction(rl *Rule, rulesData *as3Rule) {
 //Extract virtual address and port from host URL
 func extractVirtualAddressAndPort(str string) (string, int) {
 	destination := strings.Split(str, "/")
-	ipPort := strings.Split(destination[len(destination)-1], ":")
+	ipPort := destination[len(destination)-1]
+	// IPv4 destinations are of the form <ip>:<port>, IPv6 destinations <ip>.<port>
+	separator := ":"
+	if strings.Count(ipPort, ":") > 1 {
+		separator = "."
+	}
 	// verify that ip address and port exists else log error.
-	if len(ipPort) == 2 {
-		port, _ := strconv.Atoi(ipPort[1])
-		return ipPort[0], port
+	if idx := strings.LastIndex(ipPort, separator); idx > 0 {
+		port, _ := strconv.Atoi(ipPort[idx+1:])
+		return ipPort[:idx], port
 	} else {
 		log.Error("Invalid Virtual Server Destination IP address/Port.")
 		return "", 0
@@ -727,40 +1060,52 @@ func DeepEqualJSON(decl1, decl2 as3Declaration) bool {
 	return reflect.DeepEqual(o1, o2)
 }
 
-func processProfilesForAS3(rsCfgs ResourceConfigs, sharedApp as3Application) {
+func processProfilesForAS3(rsCfgs ResourceConfigs, sharedApp as3Application, tenant string) {
 	for _, cfg := range rsCfgs {
 		if svc, ok := sharedApp[cfg.Virtual.Name].(*as3Service); ok {
-			processTLSProfilesForAS3(&cfg.Virtual, svc, cfg.Virtual.Name)
+			processTLSProfilesForAS3(&cfg.Virtual, svc, cfg.Virtual.Name, tenant)
+			if svc.Class == "Service_HTTPS" && cfg.Virtual.ProfileSpec != nil &&
+				cfg.Virtual.ProfileSpec.HTTP2 != "" {
+				svc.ProfileHTTP2 = &as3ResourcePointer{BigIP: cfg.Virtual.ProfileSpec.HTTP2}
+			}
 		}
 	}
 }
 
-func processTLSProfilesForAS3(virtual *Virtual, svc *as3Service, profileName string) {
+func processTLSProfilesForAS3(virtual *Virtual, svc *as3Service, profileName string, tenant string) {
 	// lets discard BIGIP profile creation when there exists a custom profile.
 	as3ClientSuffix := "_tls_client"
 	as3ServerSuffix := "_tls_server"
+	var clientProfiles []as3ResourcePointer
 	for _, profile := range virtual.Profiles {
 		switch profile.Context {
 		case CustomProfileClient:
 			// Profile is stored in a k8s secret
 			if profile.Partition == "" {
 				// Incoming traffic (clientssl) from a web client will be handled by ServerTLS in AS3
-				svc.ServerTLS = fmt.Sprintf("/%v/%v/%v%v", DEFAULT_PARTITION,
+				svc.ServerTLS = fmt.Sprintf("/%v/%v/%v%v", tenant,
 					as3SharedApplication, profileName, as3ServerSuffix)
 
 			} else {
 				// Profile is a BIG-IP reference
 				// Incoming traffic (clientssl) from a web client will be handled by ServerTLS in AS3
-				svc.ServerTLS = &as3ResourcePointer{
+				clientProfile := as3ResourcePointer{
 					BigIP: fmt.Sprintf("/%v/%v", profile.Partition, profile.Name),
 				}
+				clientProfiles = append(clientProfiles, clientProfile)
+				if len(clientProfiles) == 1 {
+					svc.ServerTLS = &clientProfile
+				} else {
+					// BIG-IP selects the profile with the SNI of the clients
+					svc.ServerTLS = clientProfiles
+				}
 			}
 			updateVirtualToHTTPS(svc)
 		case CustomProfileServer:
 			// Profile is stored in a k8s secret
 			if profile.Partition == "" {
 				// Outgoing traffic (serverssl) to BackEnd Servers from BigIP will be handled by ClientTLS in AS3
-				svc.ClientTLS = fmt.Sprintf("/%v/%v/%v%v", DEFAULT_PARTITION,
+				svc.ClientTLS = fmt.Sprintf("/%v/%v/%v%v", tenant,
 					as3SharedApplication, profileName, as3ClientSuffix)
 			} else {
 				// Profile is a BIG-IP reference
@@ -777,8 +1122,20 @@ func processTLSProfilesForAS3(virtual *Virtual, svc *as3Service, profileName str
 func processCustomProfilesForAS3(customProfiles *CustomProfileStore, sharedApp as3Application) {
 	caBundleName := "serverssl_ca_bundle"
 	var tlsClient *as3TLSClient
+	// Profiles are processed in order to have the same certificates order in every declaration
+	keys := make([]SecretKey, 0, len(customProfiles.Profs))
+	for key := range customProfiles.Profs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].ResourceName != keys[j].ResourceName {
+			return keys[i].ResourceName < keys[j].ResourceName
+		}
+		return keys[i].Name < keys[j].Name
+	})
 	// TLS Certificates are available in CustomProfiles
-	for key, prof := range customProfiles.Profs {
+	for _, key := range keys {
+		prof := customProfiles.Profs[key]
 		// Create TLSServer and Certificate for each profile
 		svcName := key.ResourceName
 		if svcName == "" {
@@ -826,12 +1183,26 @@ func createUpdateTLSServer(prof CustomProfile, svcName string, sharedApp as3Appl
 			updateVirtualToHTTPS(svc)
 		}
 
-		tlsServer.Certificates = append(
-			tlsServer.Certificates,
-			as3TLSServerCertificates{
-				Certificate: certName,
-			},
-		)
+		cert := as3TLSServerCertificates{
+			Certificate: certName,
+			MatchToSNI:  prof.ServerName,
+		}
+		if prof.SNIDefault {
+			// The first certificate is used for the clients not matching the SNI of the others
+			tlsServer.Certificates = append([]as3TLSServerCertificates{cert}, tlsServer.Certificates...)
+		} else {
+			tlsServer.Certificates = append(tlsServer.Certificates, cert)
+		}
+		// Client certificates are validated with the CA bundle of the TLSProfile
+		if "" != prof.CAFile {
+			caBundleName := fmt.Sprintf("%s_client_ca_bundle", svcName)
+			sharedApp[caBundleName] = &as3CABundle{
+				Class:  "CA_Bundle",
+				Bundle: prof.CAFile,
+			}
+			tlsServer.AuthenticationMode = prof.PeerCertMode
+			tlsServer.AuthenticationTrustCA = caBundleName
+		}
 		return true
 	}
 	return false
@@ -843,7 +1214,6 @@ func createCertificateDecl(prof CustomProfile, sharedApp as3Application) {
 			Class:       "Certificate",
 			Certificate: prof.Cert,
 			PrivateKey:  prof.Key,
-			ChainCA:     prof.CAFile,
 		}
 		sharedApp[prof.Name] = cert
 	}
@@ -901,7 +1271,8 @@ func createMonitorDecl(cfg *ResourceConfig, sharedApp as3Application) {
 		monitor.MonitorType = v.Type
 		monitor.Timeout = v.Timeout
 		val := 0
-		monitor.TargetPort = &v.TargetPort
+		targetPort := v.TargetPort
+		monitor.TargetPort = &targetPort
 		targetAddressStr := ""
 		monitor.TargetAddress = &targetAddressStr
 		//Monitor type
@@ -917,18 +1288,52 @@ func createMonitorDecl(cfg *ResourceConfig, sharedApp as3Application) {
 			monitor.TimeUnitilUp = &val
 			monitor.Send = v.Send
 		case "https":
-			//Todo: For https monitor type
 			adaptiveFalse := false
 			monitor.Adaptive = &adaptiveFalse
 			if v.Recv != "" {
 				monitor.Receive = v.Recv
 			}
 			monitor.Send = v.Send
+			// SNI is sent with the server name of the TLS_Client
+			if v.ServerName != "" {
+				tlsClientName := fmt.Sprintf("%s_tls_client", v.Name)
+				sharedApp[tlsClientName] = &as3TLSClient{
+					Class:      "TLS_Client",
+					ServerName: v.ServerName,
+					SendSNI:    v.ServerName,
+				}
+				monitor.ClientTLS = &as3ResourcePointer{
+					Use: tlsClientName,
+				}
+			}
+			if v.ClientCert != "" && v.ClientKey != "" {
+				certName := fmt.Sprintf("%s_client_certificate", v.Name)
+				sharedApp[certName] = &as3Certificate{
+					Class:       "Certificate",
+					Certificate: v.ClientCert,
+					PrivateKey:  v.ClientKey,
+				}
+				monitor.ClientCertificate = certName
+			}
 		case "tcp", "udp":
 			adaptiveFalse := false
 			monitor.Adaptive = &adaptiveFalse
 			monitor.Receive = v.Recv
 			monitor.Send = v.Send
+		case "icmp", "gateway-icmp":
+			adaptiveFalse := false
+			monitor.Adaptive = &adaptiveFalse
+			// ICMP monitors have no port
+			monitor.TargetPort = nil
+		case "dns":
+			adaptiveFalse := false
+			monitor.Adaptive = &adaptiveFalse
+			monitor.QueryName = v.QueryName
+			monitor.QueryType = v.QueryType
+			if monitor.QueryType == "" {
+				monitor.QueryType = "a"
+			}
+			monitor.Receive = v.Recv
 		}
 		sharedApp[v.Name] = monitor
 	}
@@ -954,6 +1359,14 @@ func createTransportServiceDecl(cfg *ResourceConfig, sharedApp as3Application) {
 		}
 	}
 	svc.ProfileL4 = "basic"
+	// Policies of a transport virtual only drop the denied source addresses
+	if len(cfg.Virtual.Policies) != 0 {
+		var peps []as3ResourcePointer
+		for _, pep := range cfg.Virtual.Policies {
+			peps = append(peps, as3ResourcePointer{Use: pep.Name})
+		}
+		svc.PolicyEndpoint = peps
+	}
 	if cfg.Virtual.SNAT == "auto" || cfg.Virtual.SNAT == "none" {
 		svc.SNAT = cfg.Virtual.SNAT
 	} else {
@@ -970,6 +1383,11 @@ func createTransportServiceDecl(cfg *ResourceConfig, sharedApp as3Application) {
 	if cfg.Virtual.Source != "" {
 		svc.Source = cfg.Virtual.Source
 	}
+	if len(cfg.Virtual.PersistenceMethods) != 0 {
+		svc.PersistenceMethods = persistenceMethodsDecl(cfg.Virtual.PersistenceMethods)
+	}
+	processPersistenceForCRD(cfg, svc, sharedApp)
+	processProfileSpecForCRD(cfg, svc)
 	virtualAddress, port := extractVirtualAddressAndPort(cfg.Virtual.Destination)
 	// verify that ip address and port exists.
 	if virtualAddress != "" && port != 0 {
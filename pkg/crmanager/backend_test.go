@@ -1,6 +1,8 @@
 package crmanager
 
 import (
+	"encoding/json"
+
 	"github.com/F5Networks/k8s-bigip-ctlr/pkg/test"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -306,6 +308,418 @@ var _ = Describe("Backend Tests", func() {
 			Expect(string(decl)).ToNot(Equal(""), "Failed to Create AS3 Declaration")
 
 		})
+		It("Declaration with resources in different partitions", func() {
+			DEFAULT_PARTITION = "test"
+			rsCfg := &ResourceConfig{}
+			rsCfg.MetaData.ResourceType = VirtualServer
+			rsCfg.Virtual.Name = "crd_vs_172.13.14.15"
+			rsCfg.Virtual.Partition = "test"
+			rsCfg.Virtual.PoolName = "default_svc1_80"
+			rsCfg.Virtual.Destination = "/test/172.13.14.15:80"
+			rsCfg.Pools = Pools{{Name: "default_svc1_80", Members: []Member{mem1}}}
+
+			rsCfg2 := &ResourceConfig{}
+			rsCfg2.MetaData.ResourceType = VirtualServer
+			rsCfg2.Virtual.Name = "crd_vs_172.13.14.16"
+			rsCfg2.Virtual.Partition = "team1"
+			rsCfg2.Virtual.PoolName = "team1_svc2_80"
+			rsCfg2.Virtual.Destination = "/team1/172.13.14.16:80"
+			rsCfg2.Pools = Pools{{Name: "team1_svc2_80", Members: []Member{mem2}}}
+
+			customProfiles := NewCustomProfiles()
+			customProfiles.Profs[SecretKey{
+				Name:         "team1_svc2_cssl",
+				ResourceName: "crd_vs_172.13.14.16",
+			}] = CustomProfile{
+				Name:    "team1_svc2_cssl",
+				Context: "clientside",
+				Cert:    "crthash",
+				Key:     "keyhash",
+			}
+
+			config := ResourceConfigWrapper{
+				rsCfgs:         ResourceConfigs{rsCfg, rsCfg2},
+				customProfiles: customProfiles,
+				dnsConfig:      DNSConfig{},
+				partitions:     map[string]struct{}{"team2": {}},
+			}
+			Expect(getTenants(config)).To(Equal([]string{"team1", "team2", "test"}))
+
+			adc := createAS3ADC(config)
+			Expect(adc).To(HaveLen(3), "Each partition should be an AS3 Tenant")
+
+			testApp := adc["test"].(as3Tenant)[as3SharedApplication].(as3Application)
+			Expect(testApp).To(HaveKey("crd_vs_172.13.14.15"))
+			Expect(testApp).NotTo(HaveKey("crd_vs_172.13.14.16"))
+			Expect(testApp).NotTo(HaveKey("team1_svc2_cssl"))
+			Expect(testApp["crd_vs_172.13.14.15"].(*as3Service).Pool).To(Equal("/test/Shared/default_svc1_80"))
+
+			team1App := adc["team1"].(as3Tenant)[as3SharedApplication].(as3Application)
+			Expect(team1App).To(HaveKey("crd_vs_172.13.14.16"))
+			Expect(team1App).To(HaveKey("team1_svc2_cssl"), "Certificate should be in the Tenant of its virtual")
+			Expect(team1App["crd_vs_172.13.14.16"].(*as3Service).Pool).To(Equal("/team1/Shared/team1_svc2_80"))
+
+			// Partitions without resources are declared empty to remove their configuration
+			team2App := adc["team2"].(as3Tenant)[as3SharedApplication].(as3Application)
+			Expect(team2App).To(Equal(as3Application{"class": "Application", "template": "shared"}))
+		})
+
+		It("Declaration with resources in route domains", func() {
+			rsCfg := &ResourceConfig{}
+			rsCfg.MetaData.ResourceType = VirtualServer
+			rsCfg.Virtual.Name = "crd_172_13_14_15.2_80"
+			rsCfg.Virtual.Partition = "test"
+			rsCfg.Virtual.PoolName = "default_svc1_80"
+			rsCfg.Virtual.SetVirtualAddress("172.13.14.15%2", 80)
+			rsCfg.ServiceAddress = []ServiceAddress{{ArpEnabled: true}}
+			rsCfg.Pools = Pools{{Name: "default_svc1_80", Members: []Member{mem1}}}
+
+			rsCfg2 := &ResourceConfig{}
+			rsCfg2.MetaData.ResourceType = TransportServer
+			rsCfg2.Virtual.Name = "crd_172_13_14_16.2_1600"
+			rsCfg2.Virtual.Partition = "test"
+			rsCfg2.Virtual.PoolName = "default_svc2_80"
+			rsCfg2.Virtual.SetVirtualAddress("172.13.14.16%2", 1600)
+			rsCfg2.Pools = Pools{{Name: "default_svc2_80", Members: []Member{mem2}}}
+
+			config := ResourceConfigWrapper{
+				rsCfgs:             ResourceConfigs{rsCfg, rsCfg2},
+				customProfiles:     NewCustomProfiles(),
+				dnsConfig:          DNSConfig{},
+				defaultRouteDomain: 1,
+			}
+			tenant := createAS3ADC(config)["test"].(as3Tenant)
+			Expect(tenant["defaultRouteDomain"]).To(Equal(2), "Route domain of the virtuals not used by the Tenant")
+			app := tenant[as3SharedApplication].(as3Application)
+			Expect(app["default_svc1_80"].(*as3Pool).Members[0].ServerAddresses).To(Equal([]string{"1.2.3.5%2"}))
+			Expect(app["crd_172_13_14_16.2_1600"].(*as3Service).VirtualAddresses).To(Equal([]as3MultiTypeParam{"172.13.14.16%2"}))
+			Expect(app).To(HaveKey("crd_service_address_172_13_14_15.2"))
+			Expect(app["crd_service_address_172_13_14_15.2"].(*as3ServiceAddress).VirtualAddress).To(Equal("172.13.14.15%2"))
+
+			// Virtuals of different route domains keep the default route domain of the Tenant
+			rsCfg2.Virtual.SetVirtualAddress("172.13.14.16", 1600)
+			tenant = createAS3ADC(config)["test"].(as3Tenant)
+			Expect(tenant["defaultRouteDomain"]).To(Equal(1))
+		})
+
+		It("Declaration with pool load balancing settings", func() {
+			slowRampTime := int32(0)
+			rsCfg := &ResourceConfig{}
+			rsCfg.MetaData.ResourceType = TransportServer
+			rsCfg.Virtual.Name = "crd_172_13_14_16_1600"
+			rsCfg.Virtual.Partition = "test"
+			rsCfg.Virtual.Mode = "standard"
+			rsCfg.Virtual.PoolName = "default_svc1_80"
+			rsCfg.Virtual.SetVirtualAddress("172.13.14.16", 1600)
+			rsCfg.Pools = Pools{
+				{Name: "default_svc1_80", Members: []Member{mem1, mem2}},
+				{
+					Name:            "default_svc2_80",
+					Members:         []Member{mem1},
+					Balance:         "least-connections-member",
+					SlowRampTime:    &slowRampTime,
+					MinimumMonitors: 1,
+					ConnectionLimit: 100,
+					RateLimit:       10,
+				},
+			}
+
+			config := ResourceConfigWrapper{
+				rsCfgs:         ResourceConfigs{rsCfg},
+				customProfiles: NewCustomProfiles(),
+				dnsConfig:      DNSConfig{},
+			}
+			app := createAS3ADC(config)["test"].(as3Tenant)[as3SharedApplication].(as3Application)
+
+			pool := app["default_svc1_80"].(*as3Pool)
+			Expect(pool.LoadBalancingMode).To(BeEmpty())
+			Expect(pool.SlowRampTime).To(BeNil())
+			Expect(pool.Members[0].ConnectionLimit).To(BeZero())
+
+			pool = app["default_svc2_80"].(*as3Pool)
+			Expect(pool.LoadBalancingMode).To(Equal("least-connections-member"))
+			Expect(*pool.SlowRampTime).To(BeZero(), "Slow ramp time of 0 should disable slow ramp")
+			Expect(pool.MinimumMonitors).To(Equal(int32(1)))
+			Expect(pool.Members[0].ConnectionLimit).To(Equal(int32(100)))
+			Expect(pool.Members[0].RateLimit).To(Equal(int32(10)))
+
+			poolJSON, _ := json.Marshal(pool)
+			Expect(string(poolJSON)).To(ContainSubstring(`"slowRampTime":0`))
+		})
+
+		It("Declaration with multiple monitors", func() {
+			rsCfg := &ResourceConfig{}
+			rsCfg.MetaData.ResourceType = VirtualServer
+			rsCfg.Virtual.Name = "crd_172_13_14_15_80"
+			rsCfg.Virtual.Partition = "test"
+			rsCfg.Virtual.PoolName = "default_svc1_80"
+			rsCfg.Virtual.SetVirtualAddress("172.13.14.15", 80)
+			rsCfg.Pools = Pools{
+				{
+					Name:    "default_svc1_80",
+					Members: []Member{mem1},
+					MonitorNames: []string{
+						"/test/default_svc1_https_80",
+						"/Common/my_monitor",
+						"/test/default_svc1_icmp_80",
+						"/test/default_svc1_dns_80",
+					},
+				},
+				{
+					Name:            "default_svc2_80",
+					Members:         []Member{mem2},
+					MonitorNames:    []string{"/test/default_svc1_icmp_80", "/Common/my_monitor"},
+					MinimumMonitors: 1,
+				},
+			}
+			rsCfg.Monitors = Monitors{
+				{
+					Name:       "default_svc1_https_80",
+					Partition:  "test",
+					Type:       "https",
+					Send:       "GET /",
+					ServerName: "test.com",
+					ClientCert: "### cert ###",
+					ClientKey:  "#### key ####",
+					TargetPort: 8443,
+				},
+				{Name: "default_svc1_icmp_80", Partition: "test", Type: "icmp"},
+				{Name: "default_svc1_dns_80", Partition: "test", Type: "dns", QueryName: "test.com"},
+			}
+
+			config := ResourceConfigWrapper{
+				rsCfgs:         ResourceConfigs{rsCfg},
+				customProfiles: NewCustomProfiles(),
+				dnsConfig:      DNSConfig{},
+			}
+			app := createAS3ADC(config)["test"].(as3Tenant)[as3SharedApplication].(as3Application)
+
+			pool := app["default_svc1_80"].(*as3Pool)
+			Expect(pool.MinimumMonitors).To(Equal("all"), "All the monitors should be up by default")
+			Expect(pool.Monitors).To(Equal([]as3ResourcePointer{
+				{Use: "/test/Shared/default_svc1_https_80"},
+				{BigIP: "/Common/my_monitor"},
+				{Use: "/test/Shared/default_svc1_icmp_80"},
+				{Use: "/test/Shared/default_svc1_dns_80"},
+			}))
+			Expect(app["default_svc2_80"].(*as3Pool).MinimumMonitors).To(Equal(int32(1)))
+
+			https := app["default_svc1_https_80"].(*as3Monitor)
+			Expect(*https.TargetPort).To(Equal(int32(8443)))
+			Expect(https.ClientTLS).To(Equal(&as3ResourcePointer{Use: "default_svc1_https_80_tls_client"}))
+			Expect(app["default_svc1_https_80_tls_client"].(*as3TLSClient).ServerName).To(Equal("test.com"))
+			Expect(app["default_svc1_https_80_tls_client"].(*as3TLSClient).SendSNI).To(Equal("test.com"))
+			Expect(https.ClientCertificate).To(Equal("default_svc1_https_80_client_certificate"))
+			Expect(app["default_svc1_https_80_client_certificate"].(*as3Certificate).PrivateKey).To(Equal("#### key ####"))
+
+			icmp := app["default_svc1_icmp_80"].(*as3Monitor)
+			Expect(icmp.MonitorType).To(Equal("icmp"))
+			Expect(icmp.TargetPort).To(BeNil())
+
+			dns := app["default_svc1_dns_80"].(*as3Monitor)
+			Expect(dns.QueryName).To(Equal("test.com"))
+			Expect(dns.QueryType).To(Equal("a"))
+		})
+
+		It("Declaration with session persistence", func() {
+			rsCfg := &ResourceConfig{}
+			rsCfg.MetaData.ResourceType = VirtualServer
+			rsCfg.Virtual.Name = "crd_172_13_14_15_80"
+			rsCfg.Virtual.Partition = "test"
+			rsCfg.Virtual.SetVirtualAddress("172.13.14.15", 80)
+			rsCfg.Virtual.Persistence = &Persistence{
+				Method:   "cookie",
+				Duration: 3600,
+				Fallback: "source-address",
+			}
+
+			tsCfg := &ResourceConfig{}
+			tsCfg.MetaData.ResourceType = TransportServer
+			tsCfg.Virtual.Name = "crd_172_13_14_16_1600"
+			tsCfg.Virtual.Partition = "test"
+			tsCfg.Virtual.Mode = "standard"
+			tsCfg.Virtual.SetVirtualAddress("172.13.14.16", 1600)
+			tsCfg.Virtual.Persistence = &Persistence{
+				Reference: "/Common/my_persist",
+				Fallback:  "/Common/my_fallback",
+			}
+
+			config := ResourceConfigWrapper{
+				rsCfgs:         ResourceConfigs{rsCfg, tsCfg},
+				customProfiles: NewCustomProfiles(),
+				dnsConfig:      DNSConfig{},
+			}
+			app := createAS3ADC(config)["test"].(as3Tenant)[as3SharedApplication].(as3Application)
+
+			svc := app["crd_172_13_14_15_80"].(*as3Service)
+			Expect(svc.Class).To(Equal("Service_HTTP"), "Service class changed by persistence")
+			Expect(svc.PersistenceMethods).To(Equal([]as3MultiTypeParam{
+				&as3ResourcePointer{Use: "crd_172_13_14_15_80_persistence"},
+			}))
+			Expect(svc.FallbackPersistence).To(Equal("source-address"))
+			Expect(app["crd_172_13_14_15_80_persistence"]).To(Equal(&as3Persist{
+				Class:             "Persist",
+				PersistenceMethod: "cookie",
+				CookieMethod:      "insert",
+				Duration:          3600,
+			}))
+
+			ts := app["crd_172_13_14_16_1600"].(*as3Service)
+			Expect(ts.Class).To(Equal("Service_TCP"))
+			Expect(ts.PersistenceMethods).To(Equal([]as3MultiTypeParam{
+				&as3ResourcePointer{BigIP: "/Common/my_persist"},
+			}))
+			Expect(ts.FallbackPersistence).To(Equal(&as3ResourcePointer{BigIP: "/Common/my_fallback"}))
+		})
+
+		It("Declaration with profile references", func() {
+			rsCfg := &ResourceConfig{}
+			rsCfg.MetaData.ResourceType = VirtualServer
+			rsCfg.Virtual.Name = "crd_172_13_14_15_80"
+			rsCfg.Virtual.Partition = "test"
+			rsCfg.Virtual.SetVirtualAddress("172.13.14.15", 80)
+			rsCfg.Virtual.ProfileSpec = &ProfileSpec{
+				TCPClient:       "/Common/f5-tcp-mobile",
+				TCPServer:       "/Common/f5-tcp-lan",
+				HTTP:            "/Common/http-keepalive",
+				HTTP2:           "/Common/http2",
+				HTTPCompression: "/Common/httpcompression",
+				WebAcceleration: "/Common/webacceleration",
+				OneConnect:      "/Common/oneconnect",
+				RequestLogging:  "/Common/request-log",
+			}
+
+			tsCfg := &ResourceConfig{}
+			tsCfg.MetaData.ResourceType = TransportServer
+			tsCfg.Virtual.Name = "crd_172_13_14_16_1600"
+			tsCfg.Virtual.Partition = "test"
+			tsCfg.Virtual.Mode = "standard"
+			tsCfg.Virtual.SetVirtualAddress("172.13.14.16", 1600)
+			tsCfg.Virtual.ProfileSpec = &ProfileSpec{TCPClient: "/Common/f5-tcp-wan"}
+
+			config := ResourceConfigWrapper{
+				rsCfgs:         ResourceConfigs{rsCfg, tsCfg},
+				customProfiles: NewCustomProfiles(),
+				dnsConfig:      DNSConfig{},
+			}
+			app := createAS3ADC(config)["test"].(as3Tenant)[as3SharedApplication].(as3Application)
+
+			svc := app["crd_172_13_14_15_80"].(*as3Service)
+			Expect(svc.Class).To(Equal("Service_HTTP"))
+			Expect(svc.ProfileTCP).To(Equal(map[string]as3MultiTypeParam{
+				"ingress": &as3ResourcePointer{BigIP: "/Common/f5-tcp-mobile"},
+				"egress":  &as3ResourcePointer{BigIP: "/Common/f5-tcp-lan"},
+			}))
+			Expect(svc.ProfileHTTP).To(Equal(&as3ResourcePointer{BigIP: "/Common/http-keepalive"}))
+			Expect(svc.ProfileHTTP2).To(BeNil(), "HTTP/2 profile attached to Service_HTTP")
+			Expect(svc.ProfileHTTPCompression).To(Equal(&as3ResourcePointer{BigIP: "/Common/httpcompression"}))
+			Expect(svc.ProfileAcceleration).To(Equal(&as3ResourcePointer{BigIP: "/Common/webacceleration"}))
+			Expect(svc.ProfileMultiplex).To(Equal(&as3ResourcePointer{BigIP: "/Common/oneconnect"}))
+			Expect(svc.ProfileTrafficLog).To(Equal(&as3ResourcePointer{BigIP: "/Common/request-log"}))
+
+			ts := app["crd_172_13_14_16_1600"].(*as3Service)
+			Expect(ts.ProfileTCP).To(Equal(map[string]as3MultiTypeParam{
+				"ingress": &as3ResourcePointer{BigIP: "/Common/f5-tcp-wan"},
+				"egress":  "normal",
+			}))
+			Expect(ts.ProfileHTTP).To(BeNil())
+
+			rsCfg.Virtual.Profiles = ProfileRefs{
+				{Name: "clientssl", Partition: "Common", Context: CustomProfileClient},
+			}
+			app = createAS3ADC(config)["test"].(as3Tenant)[as3SharedApplication].(as3Application)
+			svc = app["crd_172_13_14_15_80"].(*as3Service)
+			Expect(svc.Class).To(Equal("Service_HTTPS"))
+			Expect(svc.ProfileHTTP2).To(Equal(&as3ResourcePointer{BigIP: "/Common/http2"}))
+		})
+
+		It("Declaration with source ranges", func() {
+			rsCfg := &ResourceConfig{}
+			rsCfg.MetaData.ResourceType = VirtualServer
+			rsCfg.Virtual.Name = "crd_172_13_14_15_80"
+			rsCfg.Virtual.Partition = "test"
+			rsCfg.Virtual.SetVirtualAddress("172.13.14.15", 80)
+			rules := Rules{&Rule{
+				Name:       "vs_test_com",
+				Conditions: []*condition{{Name: "0", Host: true, HTTPHost: true, Equals: true, Request: true, Values: []string{"test.com"}}},
+				Actions:    []*action{{Name: "0", Forward: true, Request: true, Pool: "default_svc1"}},
+			}}
+			rules = append(rules, sourceRangeRules([]string{"10.0.0.0/8"}, []string{"10.1.0.0/16", "10.2.3.4/32"})...)
+			rsCfg.SetPolicy(*createPolicy(rules, "crd_172_13_14_15_80_test.com_policy", "default"))
+
+			tsCfg := &ResourceConfig{}
+			tsCfg.MetaData.ResourceType = TransportServer
+			tsCfg.Virtual.Name = "crd_172_13_14_16_1600"
+			tsCfg.Virtual.Partition = "test"
+			tsCfg.Virtual.Mode = "standard"
+			tsCfg.Virtual.SetVirtualAddress("172.13.14.16", 1600)
+			tsCfg.SetPolicy(*createSourceRangePolicy(nil, []string{"192.168.0.0/16"},
+				"crd_172_13_14_16_1600_source_range_policy", "default"))
+
+			config := ResourceConfigWrapper{
+				rsCfgs:         ResourceConfigs{rsCfg, tsCfg},
+				customProfiles: NewCustomProfiles(),
+				dnsConfig:      DNSConfig{},
+			}
+			app := createAS3ADC(config)["test"].(as3Tenant)[as3SharedApplication].(as3Application)
+
+			svc := app["crd_172_13_14_15_80"].(*as3Service)
+			Expect(svc.PolicyEndpoint).To(Equal("/test/Shared/crd_172_13_14_15_80_test.com_policy"))
+			policy := app["crd_172_13_14_15_80_test.com_policy"].(*as3EndpointPolicy)
+			Expect(len(policy.Rules)).To(Equal(3))
+			Expect(policy.Rules[0].Name).To(Equal("deny_source_range"), "Denied ranges not dropped first")
+			Expect(policy.Rules[0].Conditions).To(Equal([]*as3Condition{{
+				Type:    "tcp",
+				Event:   "client-accepted",
+				Address: &as3PolicyCompareString{Values: []string{"10.1.0.0/16", "10.2.3.4/32"}, Operand: "match"},
+			}}))
+			Expect(policy.Rules[0].Actions).To(Equal([]*as3Action{{Type: "drop", Event: "client-accepted"}}))
+			Expect(policy.Rules[1].Name).To(Equal("allow_source_range"))
+			Expect(policy.Rules[1].Conditions[0].Address).To(Equal(
+				&as3PolicyCompareString{Values: []string{"10.0.0.0/8"}, Operand: "does-not-match"}))
+			Expect(policy.Rules[2].Name).To(Equal("vs_test_com"), "Forwarding rule evaluated before source ranges")
+
+			ts := app["crd_172_13_14_16_1600"].(*as3Service)
+			Expect(ts.PolicyEndpoint).To(Equal([]as3ResourcePointer{{Use: "crd_172_13_14_16_1600_source_range_policy"}}))
+			tsPolicy := app["crd_172_13_14_16_1600_source_range_policy"].(*as3EndpointPolicy)
+			Expect(len(tsPolicy.Rules)).To(Equal(1), "Other sources denied without allowed ranges")
+			Expect(tsPolicy.Rules[0].Name).To(Equal("deny_source_range"))
+		})
+
+		It("Posts only the changed Tenants", func() {
+			rsCfg := &ResourceConfig{}
+			rsCfg.MetaData.ResourceType = VirtualServer
+			rsCfg.Virtual.Name = "crd_vs_172.13.14.15"
+			rsCfg.Virtual.Partition = "test"
+			rsCfg.Virtual.Destination = "/test/172.13.14.15:80"
+
+			rsCfg2 := &ResourceConfig{}
+			rsCfg2.MetaData.ResourceType = VirtualServer
+			rsCfg2.Virtual.Name = "crd_vs_172.13.14.16"
+			rsCfg2.Virtual.Partition = "team1"
+			rsCfg2.Virtual.Destination = "/team1/172.13.14.16:80"
+
+			config := ResourceConfigWrapper{
+				rsCfgs:         ResourceConfigs{rsCfg, rsCfg2},
+				customProfiles: NewCustomProfiles(),
+			}
+			adc := createAS3ADC(config)
+
+			mockPM := newMockPostManger()
+			mockPM.BIGIPURL = "https://bigip.com"
+			mockPM.setupBIGIPDevices()
+			mockPM.getDevices()[0].tenantDecls = map[string]string{
+				"test": canonicalJSON(adc["test"]),
+			}
+			agent := newMockAgent(nil)
+			agent.PostManager = mockPM.PostManager
+
+			agent.postChangedTenants(config, adc)
+			cfg := <-mockPM.postChan
+			Expect(cfg.tenants).To(Equal([]string{"team1"}))
+			Expect(cfg.data).To(ContainSubstring("team1"))
+			Expect(cfg.data).NotTo(ContainSubstring(`"test"`), "Unchanged Tenant should not be posted")
+		})
 	})
 
 	Describe("Misc", func() {
@@ -314,6 +728,18 @@ var _ = Describe("Backend Tests", func() {
 			Expect(ipAddr).To(Equal("1.2.3.4"), "Wrong or invalid virtual Address")
 		})
 
+		It("Extracting virtual address and port", func() {
+			ipAddr, port := extractVirtualAddressAndPort("/test/1.2.3.4:80")
+			Expect(ipAddr).To(Equal("1.2.3.4"))
+			Expect(port).To(Equal(80))
+			ipAddr, port = extractVirtualAddressAndPort("/test/2001:db8::4%10.443")
+			Expect(ipAddr).To(Equal("2001:db8::4%10"), "Wrong IPv6 virtual Address")
+			Expect(port).To(Equal(443))
+			ipAddr, port = extractVirtualAddressAndPort("/test/1.2.3.4")
+			Expect(ipAddr).To(BeEmpty())
+			Expect(port).To(Equal(0))
+		})
+
 		It("Service Address declaration", func() {
 			rsCfg := &ResourceConfig{
 				ServiceAddress: []ServiceAddress{
@@ -331,6 +757,90 @@ var _ = Describe("Backend Tests", func() {
 		})
 	})
 
+	Describe("TLS Server", func() {
+		It("Client certificate authentication", func() {
+			sharedApp := as3Application{}
+			sharedApp["crd_vs_443"] = &as3Service{Class: "Service_HTTP"}
+			prof := CustomProfile{
+				Name:         "clientsecret",
+				Cert:         "### cert ###",
+				Key:          "#### key ####",
+				PeerCertMode: PeerCertRequired,
+				CAFile:       "### ca ###",
+			}
+			Expect(createUpdateTLSServer(prof, "crd_vs_443", sharedApp)).To(BeTrue())
+
+			tlsServer := sharedApp["crd_vs_443_tls_server"].(*as3TLSServer)
+			Expect(tlsServer.AuthenticationMode).To(Equal(PeerCertRequired))
+			Expect(tlsServer.AuthenticationTrustCA).To(Equal("crd_vs_443_client_ca_bundle"))
+			caBundle := sharedApp["crd_vs_443_client_ca_bundle"].(*as3CABundle)
+			Expect(caBundle.Bundle).To(Equal("### ca ###"))
+		})
+
+		It("Certificates selected by SNI", func() {
+			sharedApp := as3Application{}
+			sharedApp["crd_vs_443"] = &as3Service{Class: "Service_HTTP"}
+			sniProf := CustomProfile{
+				Name:       "snisecret-wildcard.example.com",
+				Cert:       "### sni cert ###",
+				Key:        "#### sni key ####",
+				ServerName: "*.example.com",
+			}
+			defaultProf := CustomProfile{
+				Name:       "clientsecret",
+				Cert:       "### cert ###",
+				Key:        "#### key ####",
+				SNIDefault: true,
+			}
+			Expect(createUpdateTLSServer(sniProf, "crd_vs_443", sharedApp)).To(BeTrue())
+			Expect(createUpdateTLSServer(defaultProf, "crd_vs_443", sharedApp)).To(BeTrue())
+
+			tlsServer := sharedApp["crd_vs_443_tls_server"].(*as3TLSServer)
+			Expect(tlsServer.Certificates).To(Equal([]as3TLSServerCertificates{
+				{Certificate: "clientsecret"},
+				{Certificate: "snisecret-wildcard.example.com", MatchToSNI: "*.example.com"},
+			}), "Default certificate should be the first one")
+		})
+
+		It("Multiple BIG-IP client SSL profiles", func() {
+			virtual := &Virtual{
+				Profiles: ProfileRefs{
+					{Name: "clientssl", Partition: "Common", Context: CustomProfileClient},
+					{Name: "clientssl-sni", Partition: "Common", Context: CustomProfileClient},
+				},
+			}
+			svc := &as3Service{Class: "Service_HTTP"}
+			processTLSProfilesForAS3(virtual, svc, "crd_vs_443", "test")
+			Expect(svc.ServerTLS).To(Equal([]as3ResourcePointer{
+				{BigIP: "/Common/clientssl"},
+				{BigIP: "/Common/clientssl-sni"},
+			}))
+
+			virtual.Profiles = ProfileRefs{
+				{Name: "clientsecret", Context: CustomProfileClient},
+			}
+			svc = &as3Service{Class: "Service_HTTP"}
+			processTLSProfilesForAS3(virtual, svc, "crd_vs_443", "test")
+			Expect(svc.ServerTLS).To(Equal("/test/Shared/crd_vs_443_tls_server"),
+				"Profiles from secrets should use the TLS Server")
+		})
+
+		It("Without client certificate authentication", func() {
+			sharedApp := as3Application{}
+			sharedApp["crd_vs_443"] = &as3Service{Class: "Service_HTTP"}
+			prof := CustomProfile{
+				Name: "clientsecret",
+				Cert: "### cert ###",
+				Key:  "#### key ####",
+			}
+			Expect(createUpdateTLSServer(prof, "crd_vs_443", sharedApp)).To(BeTrue())
+
+			tlsServer := sharedApp["crd_vs_443_tls_server"].(*as3TLSServer)
+			Expect(tlsServer.AuthenticationMode).To(BeEmpty())
+			Expect(sharedApp).NotTo(HaveKey("crd_vs_443_client_ca_bundle"))
+		})
+	})
+
 	Describe("JSON comparision of AS3 declaration", func() {
 		It("Verify with two empty declarations", func() {
 			ok := DeepEqualJSON("", "")
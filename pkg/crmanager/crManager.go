@@ -18,23 +18,21 @@ package crmanager
 
 import (
 	"fmt"
-	"os"
 	"strings"
 	"time"
-	"unicode"
 
-	ficV1 "github.com/F5Networks/f5-ipam-controller/pkg/ipamapis/apis/fic/v1"
 	"github.com/F5Networks/f5-ipam-controller/pkg/ipammachinery"
 	"github.com/F5Networks/k8s-bigip-ctlr/config/client/clientset/versioned"
+	cisscheme "github.com/F5Networks/k8s-bigip-ctlr/config/client/clientset/versioned/scheme"
 	apm "github.com/F5Networks/k8s-bigip-ctlr/pkg/appmanager"
 	log "github.com/F5Networks/k8s-bigip-ctlr/pkg/vlogger"
 	v1 "k8s.io/api/core/v1"
 	extClient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
-	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/util/workqueue"
 )
@@ -58,8 +56,12 @@ const (
 	Service = "Service"
 	// Endpoints is a k8s native Endpoint Resource.
 	Endpoints = "Endpoints"
+	// K8sSecret is a k8s native Secret Resource.
+	K8sSecret = "Secret"
 	// Namespace is k8s namespace
 	Namespace = "Namespace"
+	// Leader is queued when CIS is elected as the leader to post the configuration
+	Leader = "Leader"
 
 	NodePortMode = "nodeport"
 
@@ -81,8 +83,13 @@ const (
 	HTTPRequest    = "HTTPRequest"
 	TLSClientHello = "TLSClientHello"
 
-	LBServiceIPAMLabelAnnotation = "cis.f5.com/ipamLabel"
-	HealthMonitorAnnotation      = "cis.f5.com/health"
+	LBServiceIPAMLabelAnnotation        = "cis.f5.com/ipamLabel"
+	LBServiceIPAnnotation               = "cis.f5.com/ip"
+	HealthMonitorAnnotation             = "cis.f5.com/health"
+	LBServiceSNATAnnotation             = "cis.f5.com/snat"
+	LBServiceIRulesAnnotation           = "cis.f5.com/irules"
+	LBServicePersistenceAnnotation      = "cis.f5.com/persistenceMethod"
+	LBServiceAllowSourceRangeAnnotation = "cis.f5.com/allowSourceRange"
 )
 
 // NewCRManager creates a new CRManager Instance.
@@ -95,18 +102,24 @@ func NewCRManager(params Params) *CRManager {
 			workqueue.DefaultControllerRateLimiter(), "custom-resource-controller"),
 		resources:          NewResources(),
 		Agent:              params.Agent,
+		Partition:          params.Partition,
 		ControllerMode:     params.ControllerMode,
 		UseNodeInternal:    params.UseNodeInternal,
 		initState:          true,
-		SSLContext:         make(map[string]*v1.Secret),
-		dgPath:             strings.Join([]string{DEFAULT_PARTITION, "Shared"}, "/"),
 		shareNodes:         params.ShareNodes,
 		eventNotifier:      apm.NewEventNotifier(nil),
 		defaultRouteDomain: params.DefaultRouteDomain,
+		dryRun:             params.DryRun,
+		standby:            params.LeaderElection,
 	}
 
 	log.Debug("Custom Resource Manager Created")
 
+	// Register the custom resources to record events on them
+	if err := cisscheme.AddToScheme(scheme.Scheme); err != nil {
+		log.Errorf("Failed to register custom resources to scheme: %v", err)
+	}
+
 	crMgr.resourceSelector, _ = createLabelSelector(DefaultCustomResourceLabel)
 
 	if err := crMgr.setupClients(params.Config); err != nil {
@@ -147,19 +160,27 @@ func NewCRManager(params Params) *CRManager {
 	if err != nil {
 		log.Errorf("Failed to Setup Node Polling: %v", err)
 	}
-	if params.IPAM {
+	if params.IPAM && params.IPAMConfigMap != "" {
+		// Allocate the addresses from the ranges in the ConfigMap without F5 IPAM Controller
+		cfgMap := strings.Split(params.IPAMConfigMap, "/")
+		if len(cfgMap) == 2 {
+			crMgr.ipamProvider = newStaticIPAMProvider(crMgr.kubeClient, cfgMap[0], cfgMap[1])
+		} else {
+			log.Errorf("[ipam] Invalid IPAM ConfigMap %v, expected <namespace>/<configmap-name>", params.IPAMConfigMap)
+		}
+	} else if params.IPAM {
 		ipamParams := ipammachinery.Params{
 			Config:        params.Config,
 			EventHandlers: crMgr.getEventHandlerForIPAM(),
 			Namespaces:    []string{IPAMNamespace},
 		}
 
-		ipamClient := ipammachinery.NewIPAMClient(ipamParams)
-		crMgr.ipamCli = ipamClient
+		ipamProvider := newIPAMCRProvider(ipammachinery.NewIPAMClient(ipamParams))
+		crMgr.ipamProvider = ipamProvider
 
 		crMgr.registerIPAMCRD()
 		time.Sleep(3 * time.Second)
-		_ = crMgr.createIPAMResource()
+		_ = ipamProvider.createIPAMResource()
 	}
 
 	go crMgr.Start()
@@ -174,77 +195,6 @@ func (crMgr *CRManager) registerIPAMCRD() {
 	}
 }
 
-//Create IPAM CRD
-func (crMgr *CRManager) createIPAMResource() error {
-
-	frameIPAMResourceName := func() string {
-		prtn := ""
-		for _, ch := range DEFAULT_PARTITION {
-			elem := string(ch)
-			if unicode.IsUpper(ch) {
-				elem = strings.ToLower(elem) + "-"
-			}
-			prtn += elem
-		}
-		if string(prtn[len(prtn)-1]) == "-" {
-			prtn = prtn + ipamCRName
-		} else {
-			prtn = prtn + "." + ipamCRName
-		}
-
-		prtn = strings.Replace(prtn, "_", "-", -1)
-		prtn = strings.Replace(prtn, "--", "-", -1)
-
-		hostsplit := strings.Split(os.Getenv("HOSTNAME"), "-")
-		var host string
-		if len(hostsplit) > 2 {
-			host = strings.Join(hostsplit[0:len(hostsplit)-2], "-")
-		} else {
-			host = strings.Join(hostsplit, "-")
-		}
-		return strings.Join([]string{host, prtn}, ".")
-	}
-
-	crName := frameIPAMResourceName()
-	f5ipam := &ficV1.IPAM{
-		ObjectMeta: metaV1.ObjectMeta{
-			Name:      crName,
-			Namespace: IPAMNamespace,
-		},
-		Spec: ficV1.IPAMSpec{
-			HostSpecs: make([]*ficV1.HostSpec, 0),
-		},
-		Status: ficV1.IPAMStatus{
-			IPStatus: make([]*ficV1.IPSpec, 0),
-		},
-	}
-	crMgr.ipamCR = IPAMNamespace + "/" + crName
-
-	ipamCR, err := crMgr.ipamCli.Create(f5ipam)
-	if err == nil {
-		log.Debugf("[ipam] Created IPAM Custom Resource: \n%v\n", ipamCR)
-		return nil
-	}
-
-	if strings.Contains(err.Error(), "already exists") {
-		err = crMgr.ipamCli.Delete(IPAMNamespace, crName, metaV1.DeleteOptions{})
-		if err != nil {
-			log.Debugf("[ipam] Delete failed. Error: %s", err.Error())
-		}
-
-		time.Sleep(3 * time.Second)
-
-		ipamCR, err = crMgr.ipamCli.Create(f5ipam)
-		if err == nil {
-			log.Debugf("[ipam] Created IPAM Custom Resource: \n%v\n", ipamCR)
-			return nil
-		}
-	}
-
-	log.Debugf("[ipam] error while creating IPAM custom resource. %v", err.Error())
-	return err
-}
-
 // createLabelSelector returns label used to identify F5 specific
 // Custom Resources.
 func createLabelSelector(label string) (labels.Selector, error) {
@@ -313,12 +263,16 @@ func (crMgr *CRManager) Start() {
 		crMgr.nsInformer.start()
 	}
 
-	if crMgr.ipamCli != nil {
-		go crMgr.ipamCli.Start()
+	if crMgr.ipamProvider != nil {
+		go crMgr.ipamProvider.Start()
 	}
 
 	crMgr.nodePoller.Run()
 
+	if crMgr.Agent != nil && crMgr.Agent.PostManager != nil {
+		go crMgr.responseHandler(crMgr.Agent.respChan)
+	}
+
 	stopChan := make(chan struct{})
 	go wait.Until(crMgr.customResourceWorker, time.Second, stopChan)
 
@@ -337,7 +291,68 @@ func (crMgr *CRManager) Stop() {
 
 	crMgr.nodePoller.Stop()
 	crMgr.Agent.Stop()
-	if crMgr.ipamCli != nil {
-		crMgr.ipamCli.Stop()
+	if crMgr.ipamProvider != nil {
+		crMgr.ipamProvider.Stop()
+	}
+}
+
+// StartLeading posts the configuration to BIG-IP once CIS is elected as the leader.
+// Until then the resources are processed in standby without posting them.
+func (crMgr *CRManager) StartLeading() {
+	crMgr.standbyMutex.Lock()
+	crMgr.standby = false
+	crMgr.standbyMutex.Unlock()
+	crMgr.rscQueue.Add(&rqKey{kind: Leader})
+}
+
+func (crMgr *CRManager) isStandby() bool {
+	crMgr.standbyMutex.Lock()
+	defer crMgr.standbyMutex.Unlock()
+	return crMgr.standby
+}
+
+// skipReleaseInStandby records the IPAM release of a resource deleted in standby for
+// the leader to replay it, returns false if the release is not skipped
+func (crMgr *CRManager) skipReleaseInStandby(release ipamRelease) bool {
+	crMgr.standbyMutex.Lock()
+	defer crMgr.standbyMutex.Unlock()
+	if !crMgr.standby {
+		return false
+	}
+	crMgr.skippedReleases = append(crMgr.skippedReleases, release)
+	return true
+}
+
+// dropSkippedRelease drops the skipped IPAM release of a resource which is created
+// again in standby, as it still uses the address
+func (crMgr *CRManager) dropSkippedRelease(release ipamRelease) {
+	crMgr.standbyMutex.Lock()
+	defer crMgr.standbyMutex.Unlock()
+	var releases []ipamRelease
+	for _, skipped := range crMgr.skippedReleases {
+		if skipped != release {
+			releases = append(releases, skipped)
+		}
+	}
+	crMgr.skippedReleases = releases
+}
+
+// replaySkippedReleases releases the addresses of the resources deleted in standby
+func (crMgr *CRManager) replaySkippedReleases() {
+	crMgr.standbyMutex.Lock()
+	releases := crMgr.skippedReleases
+	crMgr.skippedReleases = nil
+	crMgr.standbyMutex.Unlock()
+	for _, release := range releases {
+		crMgr.releaseIP(release.ipamLabel, release.host, release.key)
+	}
+}
+
+// getPartition returns the BIG-IP partition given in the custom resource,
+// custom resources without a partition use the partition of CIS
+func (crMgr *CRManager) getPartition(partition string) string {
+	if partition != "" {
+		return partition
 	}
+	return crMgr.Partition
 }
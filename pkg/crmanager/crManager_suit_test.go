@@ -47,6 +47,7 @@ func newMockPostManger() *mockPostManager {
 	}
 
 	mockPM.postChan = make(chan config, 1)
+	mockPM.setupBIGIPDevices()
 
 	return mockPM
 }
@@ -31,6 +31,9 @@ import (
 	"k8s.io/client-go/tools/cache"
 )
 
+// TLSSecretIndex indexes the TLSProfiles by the Secrets they reference as namespace/name
+const TLSSecretIndex = "tlsSecret"
+
 var K8SCoreServices = [...]string{"kube-dns", "kube-scheduler", "kube-controller-manager", "docker-registry", "kubernetes", "registry-console", "router", "kubelet", "console", "alertmanager-main", "alertmanager-operated", "cluster-monitoring-operator", "grafana", "kube-state-metrics", "node-exporter", "prometheus-k8s", "prometheus-operated", "prometheus-operatorwebconsole"}
 
 // start the VirtualServer informer
@@ -70,6 +73,10 @@ func (crInfr *CRInformer) start() {
 		go crInfr.epsInformer.Run(crInfr.stopCh)
 		cacheSyncs = append(cacheSyncs, crInfr.epsInformer.HasSynced)
 	}
+	if crInfr.secretInformer != nil {
+		go crInfr.secretInformer.Run(crInfr.stopCh)
+		cacheSyncs = append(cacheSyncs, crInfr.secretInformer.HasSynced)
+	}
 
 	cache.WaitForNamedCacheSync(
 		"F5 CIS CRD Controller",
@@ -151,6 +158,17 @@ func (crMgr *CRManager) newNamespacedInformer(
 			resyncPeriod,
 			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
 		),
+		secretInformer: cache.NewSharedIndexInformer(
+			cache.NewFilteredListWatchFromClient(
+				restClientv1,
+				"secrets",
+				namespace,
+				everything,
+			),
+			&corev1.Secret{},
+			resyncPeriod,
+			cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+		),
 	}
 
 	crInf.ilInformer = cisinfv1.NewFilteredIngressLinkInformer(
@@ -172,7 +190,10 @@ func (crMgr *CRManager) newNamespacedInformer(
 		crMgr.kubeCRClient,
 		namespace,
 		resyncPeriod,
-		cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc},
+		cache.Indexers{
+			cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+			TLSSecretIndex:       tlsSecretIndexFunc,
+		},
 		crOptions,
 	)
 	crInf.tsInformer = cisinfv1.NewFilteredTransportServerInformer(
@@ -208,7 +229,7 @@ func (crMgr *CRManager) addEventHandlers(crInf *CRInformer) {
 		crInf.tlsInformer.AddEventHandler(
 			&cache.ResourceEventHandlerFuncs{
 				AddFunc:    func(obj interface{}) { crMgr.enqueueTLSServer(obj) },
-				UpdateFunc: func(old, cur interface{}) { crMgr.enqueueTLSServer(cur) },
+				UpdateFunc: func(old, cur interface{}) { crMgr.enqueueUpdatedTLSServer(old, cur) },
 				// DeleteFunc: func(obj interface{}) { crMgr.enqueueTLSServer(obj) },
 			},
 		)
@@ -262,6 +283,36 @@ func (crMgr *CRManager) addEventHandlers(crInf *CRInformer) {
 			},
 		)
 	}
+
+	if crInf.secretInformer != nil {
+		crInf.secretInformer.AddEventHandler(
+			&cache.ResourceEventHandlerFuncs{
+				AddFunc:    func(obj interface{}) { crMgr.enqueueSecret(obj, false) },
+				UpdateFunc: func(obj, cur interface{}) { crMgr.enqueueUpdatedSecret(obj, cur) },
+				DeleteFunc: func(obj interface{}) { crMgr.enqueueSecret(obj, true) },
+			},
+		)
+	}
+}
+
+// tlsSecretIndexFunc indexes the TLSProfiles referencing Secrets by the
+// namespace/name of the Secrets
+func tlsSecretIndexFunc(obj interface{}) ([]string, error) {
+	tls, ok := obj.(*cisapiv1.TLSProfile)
+	if !ok || tls.Spec.TLS.Reference != Secret {
+		return nil, nil
+	}
+	secrets := []string{tls.Spec.TLS.ClientSSL, tls.Spec.TLS.ServerSSL, tls.Spec.TLS.ClientCASecret}
+	for _, sniCert := range tls.Spec.TLS.SNICertificates {
+		secrets = append(secrets, sniCert.ClientSSL)
+	}
+	var keys []string
+	for _, secret := range secrets {
+		if secret != "" {
+			keys = append(keys, tls.ObjectMeta.Namespace+"/"+secret)
+		}
+	}
+	return keys, nil
 }
 
 func (crMgr *CRManager) getEventHandlerForIPAM() *cache.ResourceEventHandlerFuncs {
@@ -275,7 +326,7 @@ func (crMgr *CRManager) getEventHandlerForIPAM() *cache.ResourceEventHandlerFunc
 func (crMgr *CRManager) enqueueIPAM(obj interface{}) {
 	ipamObj := obj.(*ficV1.IPAM)
 
-	if ipamObj.Namespace+"/"+ipamObj.Name != crMgr.ipamCR {
+	if ipamObj.Namespace+"/"+ipamObj.Name != crMgr.getIPAMCRKey() {
 		return
 	}
 
@@ -294,7 +345,7 @@ func (crMgr *CRManager) enqueueUpdatedIPAM(oldObj, newObj interface{}) {
 	oldIpam := oldObj.(*ficV1.IPAM)
 	curIpam := newObj.(*ficV1.IPAM)
 
-	if curIpam.Namespace+"/"+curIpam.Name != crMgr.ipamCR {
+	if curIpam.Namespace+"/"+curIpam.Name != crMgr.getIPAMCRKey() {
 		return
 	}
 
@@ -316,7 +367,7 @@ func (crMgr *CRManager) enqueueUpdatedIPAM(oldObj, newObj interface{}) {
 func (crMgr *CRManager) enqueueDeletedIPAM(obj interface{}) {
 	ipamObj := obj.(*ficV1.IPAM)
 
-	if ipamObj.Namespace+"/"+ipamObj.Name != crMgr.ipamCR {
+	if ipamObj.Namespace+"/"+ipamObj.Name != crMgr.getIPAMCRKey() {
 		return
 	}
 
@@ -359,12 +410,20 @@ func (crMgr *CRManager) enqueueUpdatedVirtualServer(oldObj, newObj interface{})
 	oldVS := oldObj.(*cisapiv1.VirtualServer)
 	newVS := newObj.(*cisapiv1.VirtualServer)
 
+	// Status updates made by CIS need not be processed
+	if reflect.DeepEqual(oldVS.Spec, newVS.Spec) && !reflect.DeepEqual(oldVS.Status, newVS.Status) {
+		return
+	}
+
 	if oldVS.Spec.VirtualServerAddress != newVS.Spec.VirtualServerAddress ||
 		oldVS.Spec.VirtualServerHTTPPort != newVS.Spec.VirtualServerHTTPPort ||
 		oldVS.Spec.VirtualServerHTTPSPort != newVS.Spec.VirtualServerHTTPSPort ||
 		oldVS.Spec.VirtualServerName != newVS.Spec.VirtualServerName ||
 		oldVS.Spec.Host != newVS.Spec.Host ||
-		oldVS.Spec.IPAMLabel != newVS.Spec.IPAMLabel {
+		oldVS.Spec.IPAMLabel != newVS.Spec.IPAMLabel ||
+		oldVS.Spec.IPv6VirtualServerAddress != newVS.Spec.IPv6VirtualServerAddress ||
+		oldVS.Spec.IPv6IPAMLabel != newVS.Spec.IPv6IPAMLabel ||
+		oldVS.Spec.RouteDomain != newVS.Spec.RouteDomain {
 		log.Debugf("Enqueueing Old VirtualServer: %v", oldVS)
 		key := &rqKey{
 			namespace: oldVS.ObjectMeta.Namespace,
@@ -414,6 +473,17 @@ func (crMgr *CRManager) enqueueTLSServer(obj interface{}) {
 	crMgr.rscQueue.Add(key)
 }
 
+func (crMgr *CRManager) enqueueUpdatedTLSServer(oldObj, newObj interface{}) {
+	oldTLS := oldObj.(*cisapiv1.TLSProfile)
+	newTLS := newObj.(*cisapiv1.TLSProfile)
+
+	// Status updates made by CIS need not be processed
+	if reflect.DeepEqual(oldTLS.Spec, newTLS.Spec) && !reflect.DeepEqual(oldTLS.Status, newTLS.Status) {
+		return
+	}
+	crMgr.enqueueTLSServer(newObj)
+}
+
 func (crMgr *CRManager) enqueueTransportServer(obj interface{}) {
 	ts := obj.(*cisapiv1.TransportServer)
 	log.Infof("Enqueueing TransportServer: %v", ts)
@@ -431,10 +501,18 @@ func (crMgr *CRManager) enqueueUpdatedTransportServer(oldObj, newObj interface{}
 	oldVS := oldObj.(*cisapiv1.TransportServer)
 	newVS := newObj.(*cisapiv1.TransportServer)
 
+	// Status updates made by CIS need not be processed
+	if reflect.DeepEqual(oldVS.Spec, newVS.Spec) && !reflect.DeepEqual(oldVS.Status, newVS.Status) {
+		return
+	}
+
 	if oldVS.Spec.VirtualServerAddress != newVS.Spec.VirtualServerAddress ||
 		oldVS.Spec.VirtualServerPort != newVS.Spec.VirtualServerPort ||
 		oldVS.Spec.VirtualServerName != newVS.Spec.VirtualServerName ||
-		oldVS.Spec.IPAMLabel != newVS.Spec.IPAMLabel {
+		oldVS.Spec.IPAMLabel != newVS.Spec.IPAMLabel ||
+		oldVS.Spec.IPv6VirtualServerAddress != newVS.Spec.IPv6VirtualServerAddress ||
+		oldVS.Spec.IPv6IPAMLabel != newVS.Spec.IPv6IPAMLabel ||
+		oldVS.Spec.RouteDomain != newVS.Spec.RouteDomain {
 		log.Debugf("Enqueueing TransportServer: %v", oldVS)
 		key := &rqKey{
 			namespace: oldVS.ObjectMeta.Namespace,
@@ -502,8 +580,14 @@ func (crMgr *CRManager) enqueueUpdatedIngressLink(oldObj, newObj interface{}) {
 	oldIngLink := oldObj.(*cisapiv1.IngressLink)
 	newIngLink := newObj.(*cisapiv1.IngressLink)
 
+	// Status updates made by CIS need not be processed
+	if reflect.DeepEqual(oldIngLink.Spec, newIngLink.Spec) && !reflect.DeepEqual(oldIngLink.Status, newIngLink.Status) {
+		return
+	}
+
 	if oldIngLink.Spec.VirtualServerAddress != newIngLink.Spec.VirtualServerAddress ||
-		oldIngLink.Spec.IPAMLabel != newIngLink.Spec.IPAMLabel {
+		oldIngLink.Spec.IPAMLabel != newIngLink.Spec.IPAMLabel ||
+		oldIngLink.Spec.RouteDomain != newIngLink.Spec.RouteDomain {
 		key := &rqKey{
 			namespace: oldIngLink.ObjectMeta.Namespace,
 			kind:      IngressLink,
@@ -543,6 +627,11 @@ func (crMgr *CRManager) enqueueUpdatedExternalDNS(oldObj, newObj interface{}) {
 	oldEDNS := oldObj.(*cisapiv1.ExternalDNS)
 	edns := newObj.(*cisapiv1.ExternalDNS)
 
+	// Status updates made by CIS need not be processed
+	if reflect.DeepEqual(oldEDNS.Spec, edns.Spec) && !reflect.DeepEqual(oldEDNS.Status, edns.Status) {
+		return
+	}
+
 	if oldEDNS.Spec.DomainName != edns.Spec.DomainName {
 		key := &rqKey{
 			namespace: oldEDNS.ObjectMeta.Namespace,
@@ -610,6 +699,8 @@ func (crMgr *CRManager) enqueueUpdatedService(obj, cur interface{}) {
 
 	if (svc.Spec.Type != curSvc.Spec.Type && svc.Spec.Type == corev1.ServiceTypeLoadBalancer) ||
 		(svc.Annotations[LBServiceIPAMLabelAnnotation] != curSvc.Annotations[LBServiceIPAMLabelAnnotation]) ||
+		(svc.Annotations[LBServiceIPAnnotation] != curSvc.Annotations[LBServiceIPAnnotation]) ||
+		(svc.Spec.LoadBalancerIP != curSvc.Spec.LoadBalancerIP) ||
 		!reflect.DeepEqual(svc.Spec.Ports, curSvc.Spec.Ports) {
 		log.Debugf("Enqueueing Old Service: %v", svc)
 		key := &rqKey{
@@ -670,6 +761,47 @@ func (crMgr *CRManager) enqueueEndpoints(obj interface{}) {
 	crMgr.rscQueue.Add(key)
 }
 
+// enqueueSecret enqueues the Secret only when it is referenced by a TLSProfile
+// or a pool monitor of a VirtualServer or TransportServer
+func (crMgr *CRManager) enqueueSecret(obj interface{}, rscDelete bool) {
+	secret, ok := obj.(*corev1.Secret)
+	if !ok {
+		tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+		if !ok {
+			return
+		}
+		if secret, ok = tombstone.Obj.(*corev1.Secret); !ok {
+			return
+		}
+	}
+	if len(crMgr.getTLSProfilesForSecret(secret.ObjectMeta.Namespace, secret.ObjectMeta.Name)) == 0 &&
+		len(crMgr.getVirtualsForMonitorSecret(secret.ObjectMeta.Namespace, secret.ObjectMeta.Name)) == 0 &&
+		len(crMgr.getTransportServersForMonitorSecret(secret.ObjectMeta.Namespace, secret.ObjectMeta.Name)) == 0 {
+		return
+	}
+	log.Debugf("Enqueueing Secret: %v/%v", secret.ObjectMeta.Namespace, secret.ObjectMeta.Name)
+	key := &rqKey{
+		namespace: secret.ObjectMeta.Namespace,
+		kind:      K8sSecret,
+		rscName:   secret.ObjectMeta.Name,
+		rsc:       secret,
+		rscDelete: rscDelete,
+	}
+
+	crMgr.rscQueue.Add(key)
+}
+
+func (crMgr *CRManager) enqueueUpdatedSecret(oldObj, newObj interface{}) {
+	oldSecret := oldObj.(*corev1.Secret)
+	newSecret := newObj.(*corev1.Secret)
+
+	// Only a change in the certificates and keys needs the VirtualServers to be processed
+	if reflect.DeepEqual(oldSecret.Data, newSecret.Data) {
+		return
+	}
+	crMgr.enqueueSecret(newObj, false)
+}
+
 func (nsInfr *NSInformer) start() {
 	if nsInfr.nsInformer != nil {
 		log.Infof("Starting Namespace Informer")
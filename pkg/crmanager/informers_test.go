@@ -109,6 +109,47 @@ var _ = Describe("Informers Tests", func() {
 			Expect(quit).To(BeFalse(), "Enqueue TLS  Failed")
 		})
 
+		It("Secret", func() {
+			mockCRM.kubeCRClient = crdfake.NewSimpleClientset()
+			mockCRM.kubeClient = k8sfake.NewSimpleClientset()
+			mockCRM.crInformers = make(map[string]*CRInformer)
+			mockCRM.resourceSelector, _ = createLabelSelector(DefaultCustomResourceLabel)
+			_ = mockCRM.addNamespacedInformer(namespace)
+			tlsp := test.NewTLSProfile(
+				"SampleTLS",
+				namespace,
+				cisapiv1.TLSProfileSpec{
+					Hosts: []string{"test.com"},
+					TLS: cisapiv1.TLS{
+						Termination: "edge",
+						Reference:   Secret,
+						ClientSSL:   "clientsecret",
+					},
+				})
+			_ = mockCRM.crInformers[namespace].tlsInformer.GetIndexer().Add(tlsp)
+
+			unusedSecret := test.NewSecret("unusedsecret", namespace, "### cert ###", "#### key ####")
+			mockCRM.enqueueSecret(unusedSecret, false)
+			Expect(mockCRM.rscQueue.Len()).To(Equal(0), "Secret not referenced by TLSProfiles enqueued")
+
+			secret := test.NewSecret("clientsecret", namespace, "### cert ###", "#### key ####")
+			mockCRM.enqueueUpdatedSecret(secret, secret.DeepCopy())
+			Expect(mockCRM.rscQueue.Len()).To(Equal(0), "Secret without changes enqueued")
+
+			newSecret := test.NewSecret("clientsecret", namespace, "### new cert ###", "#### key ####")
+			mockCRM.enqueueUpdatedSecret(secret, newSecret)
+			key, quit := mockCRM.rscQueue.Get()
+			Expect(key).ToNot(BeNil(), "Enqueue Updated Secret Failed")
+			Expect(quit).To(BeFalse(), "Enqueue Updated Secret Failed")
+			Expect(key.(*rqKey).kind).To(Equal(K8sSecret), "Enqueue Updated Secret Failed")
+
+			mockCRM.enqueueSecret(newSecret, true)
+			key, quit = mockCRM.rscQueue.Get()
+			Expect(key).ToNot(BeNil(), "Enqueue Deleted Secret Failed")
+			Expect(quit).To(BeFalse(), "Enqueue Deleted Secret Failed")
+			Expect(key.(*rqKey).rscDelete).To(BeTrue(), "Enqueue Deleted Secret Failed")
+		})
+
 		It("TransportServer", func() {
 			ts := test.NewTransportServer(
 				"SampleTS",
@@ -300,7 +341,7 @@ var _ = Describe("Informers Tests", func() {
 		})
 
 		It("IPAM", func() {
-			mockCRM.ipamCR = "default/SampleIPAM"
+			mockCRM.ipamProvider = &ipamCRProvider{ipamCR: "default/SampleIPAM"}
 
 			hostSpec := &ficV1.HostSpec{
 				Host:      "test.com",
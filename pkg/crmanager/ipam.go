@@ -0,0 +1,770 @@
+/*-
+ * Copyright (c) 2016-2021, F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package crmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	ficV1 "github.com/F5Networks/f5-ipam-controller/pkg/ipamapis/apis/fic/v1"
+	"github.com/F5Networks/f5-ipam-controller/pkg/ipammachinery"
+	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/config/apis/cis/v1"
+	bigIPPrometheus "github.com/F5Networks/k8s-bigip-ctlr/pkg/prometheus"
+	log "github.com/F5Networks/k8s-bigip-ctlr/pkg/vlogger"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// Suffix of the ConfigMap holding the addresses allocated by the static IPAM provider
+	ipamAllocationsSuffix = "-allocations"
+	// Key of the allocations in the allocations ConfigMap
+	ipamAllocationsKey = "allocations"
+	// Time after which an address still not allocated is reported as stuck
+	ipamPendingTimeout = 2 * time.Minute
+	// Suffix of the IPAM key and custom virtual server name of the IPv6 address of dual-stack resources
+	ipv6Suffix = "_ipv6"
+)
+
+// errIPPoolExhausted is returned by the IPAM providers when the range of the ipamLabel has no free address
+var errIPPoolExhausted = fmt.Errorf("IP pool exhausted")
+
+type (
+	// IPAMProvider allocates the virtual addresses of the resources with an ipamLabel.
+	// Resources are identified by host for VirtualServers and by key for the others.
+	IPAMProvider interface {
+		// Start starts the provider, it must not block.
+		Start()
+		// Stop stops the provider.
+		Stop()
+		// RequestIP returns the address allocated from the ipamLabel range,
+		// or an empty string while the allocation is pending or when it failed with the error.
+		// errIPPoolExhausted is returned when the range has no free address.
+		RequestIP(ipamLabel, host, key string) (string, error)
+		// ReleaseIP frees the address allocated from the ipamLabel range and returns it.
+		ReleaseIP(ipamLabel, host, key string) string
+		// LookupIP returns the address allocated from the ipamLabel range without requesting one.
+		LookupIP(ipamLabel, host, key string) string
+	}
+
+	// ipamCRProvider requests addresses from F5 IPAM Controller through the IPAM Custom Resource.
+	// Allocations are reported asynchronously in the status of the IPAM Custom Resource.
+	ipamCRProvider struct {
+		ipamCli  *ipammachinery.IPAMClient
+		ipamCR   string
+		requests map[string]time.Time
+	}
+
+	// staticIPAMProvider allocates addresses from the CIDR ranges of ipamLabels given in a ConfigMap
+	// and stores the allocations in another ConfigMap, without an IPAM controller.
+	// The allocations are read from the ConfigMap on every request, so that they are never stale,
+	// and written with its resourceVersion, so that concurrent writes are retried instead of lost.
+	staticIPAMProvider struct {
+		sync.Mutex
+		kubeClient kubernetes.Interface
+		namespace  string
+		rangesName string
+	}
+
+	// ipamPendingRequest is a resource waiting for IPAM to allocate its address
+	ipamPendingRequest struct {
+		rKey  *rqKey
+		since time.Time
+		// waiting is set when processing finds the address of the resource still missing
+		waiting bool
+		requeue bool
+	}
+
+	// ipamAllocation is an address allocated by the static IPAM provider
+	ipamAllocation struct {
+		IPAMLabel string `json:"ipamLabel"`
+		Host      string `json:"host,omitempty"`
+		Key       string `json:"key,omitempty"`
+		IP        string `json:"ip"`
+	}
+)
+
+func newIPAMCRProvider(ipamCli *ipammachinery.IPAMClient) *ipamCRProvider {
+	return &ipamCRProvider{
+		ipamCli:  ipamCli,
+		requests: make(map[string]time.Time),
+	}
+}
+
+// setIPAMPending records that the resource is waiting for IPAM to allocate its address, so that
+// it is requeued with backoff, and returns the IPAllocated condition describing the missing address
+func (crMgr *CRManager) setIPAMPending(kind string, rsc interface{}, namespace, name, target string, err error) metaV1.Condition {
+	if crMgr.ipamPending == nil {
+		crMgr.ipamPending = make(map[string]*ipamPendingRequest)
+	}
+	pendingKey := kind + "/" + namespace + "/" + name
+	req, ok := crMgr.ipamPending[pendingKey]
+	if !ok {
+		req = &ipamPendingRequest{
+			rKey:  &rqKey{namespace: namespace, kind: kind, rscName: name},
+			since: time.Now(),
+		}
+		crMgr.ipamPending[pendingKey] = req
+		crMgr.updateIPAMPendingMetric(kind)
+	}
+	req.rKey.rsc = rsc
+	req.waiting = true
+	req.requeue = true
+
+	waiting := time.Since(req.since)
+	switch {
+	case err == errIPPoolExhausted:
+		log.Warningf("[ipam] IP pool exhausted, no address allocated for %s of %s %s", target, kind, pendingKey)
+		return newCondition(cisapiv1.ConditionIPAllocated, false, ReasonIPPoolExhausted,
+			fmt.Sprintf("IP pool exhausted, no address available for %s", target))
+	case err != nil:
+		log.Errorf("[ipam] Unable to allocate an address for %s of %s %s: %v", target, kind, pendingKey, err)
+		return newCondition(cisapiv1.ConditionIPAllocated, false, ReasonIPAMPending,
+			fmt.Sprintf("Waiting for IPAM to allocate an address for %s: %v", target, err))
+	case waiting > ipamPendingTimeout:
+		log.Warningf("[ipam] No address allocated for %s of %s %s in %v", target, kind, pendingKey, waiting.Round(time.Second))
+		return newCondition(cisapiv1.ConditionIPAllocated, false, ReasonIPAMPending,
+			fmt.Sprintf("Waiting for IPAM to allocate an address for %s since %v, the IP pool may be exhausted",
+				target, waiting.Round(time.Second)))
+	}
+	return newCondition(cisapiv1.ConditionIPAllocated, false, ReasonIPAMPending,
+		fmt.Sprintf("Waiting for IPAM to allocate an address for %s", target))
+}
+
+// refreshIPAMPending updates a resource requeued while waiting for IPAM with its latest version in the
+// informer, as the resource may have changed or been deleted since it was found waiting for IPAM.
+// Returns false when the resource is gone, it is no longer tracked and must not be processed.
+func (crMgr *CRManager) refreshIPAMPending(rKey *rqKey) bool {
+	pendingKey := rKey.kind + "/" + rKey.namespace + "/" + rKey.rscName
+	if req, ok := crMgr.ipamPending[pendingKey]; !ok || req.rKey != rKey {
+		return true
+	}
+	obj, found := crMgr.getResource(rKey.kind, rKey.namespace+"/"+rKey.rscName)
+	if !found {
+		log.Debugf("[ipam] %s no longer exists, dropping its pending allocation", pendingKey)
+		delete(crMgr.ipamPending, pendingKey)
+		crMgr.updateIPAMPendingMetric(rKey.kind)
+		crMgr.rscQueue.Forget(rKey)
+		return false
+	}
+	rKey.rsc = obj
+	return true
+}
+
+// resetIPAMPending clears the waiting state of the resource before it is processed again
+func (crMgr *CRManager) resetIPAMPending(rKey *rqKey) {
+	if req, ok := crMgr.ipamPending[rKey.kind+"/"+rKey.namespace+"/"+rKey.rscName]; ok {
+		req.waiting = false
+	}
+}
+
+// requeueIPAMPending requeues with backoff the resources found waiting for IPAM while processing rKey,
+// in case the IPAM event allocating the address is missed. The resource of rKey is no longer tracked
+// when its processing did not wait for IPAM, i.e. it got its address, was deleted or became invalid.
+// Returns whether the resource of rKey is still waiting for IPAM.
+func (crMgr *CRManager) requeueIPAMPending(rKey *rqKey) bool {
+	pendingKey := rKey.kind + "/" + rKey.namespace + "/" + rKey.rscName
+	if req, ok := crMgr.ipamPending[pendingKey]; ok && (!req.waiting || rKey.rscDelete) {
+		delete(crMgr.ipamPending, pendingKey)
+		crMgr.updateIPAMPendingMetric(rKey.kind)
+		crMgr.rscQueue.Forget(req.rKey)
+	}
+	for _, req := range crMgr.ipamPending {
+		if req.requeue {
+			req.requeue = false
+			crMgr.rscQueue.AddRateLimited(req.rKey)
+		}
+	}
+	_, ok := crMgr.ipamPending[pendingKey]
+	return ok
+}
+
+// updateIPAMPendingMetric reports the number of resources of the kind waiting for IPAM
+func (crMgr *CRManager) updateIPAMPendingMetric(kind string) {
+	count := 0
+	for _, req := range crMgr.ipamPending {
+		if req.rKey.kind == kind {
+			count++
+		}
+	}
+	bigIPPrometheus.IPAMPendingAllocations.WithLabelValues(kind).Set(float64(count))
+}
+
+// getIPAMCRKey returns the namespace/name of the IPAM Custom Resource requesting the addresses of CIS
+func (crMgr *CRManager) getIPAMCRKey() string {
+	if prov, ok := crMgr.ipamProvider.(*ipamCRProvider); ok {
+		return prov.ipamCR
+	}
+	return ""
+}
+
+// requestIP requests the address of the resource from the IPAM provider. Addresses are allocated
+// by the leader only, standby replicas and dry-run mode get the address already allocated to the resource if any.
+func (crMgr *CRManager) requestIP(ipamLabel, host, key string) (string, error) {
+	if crMgr.dryRun {
+		return crMgr.ipamProvider.LookupIP(ipamLabel, host, key), nil
+	}
+	if crMgr.isStandby() {
+		crMgr.dropSkippedRelease(ipamRelease{ipamLabel: ipamLabel, host: host, key: key})
+		return crMgr.ipamProvider.LookupIP(ipamLabel, host, key), nil
+	}
+	return crMgr.ipamProvider.RequestIP(ipamLabel, host, key)
+}
+
+// releaseIP releases the address of the resource from the IPAM provider and returns it.
+// Addresses are released by the leader only, which replays the releases skipped in standby.
+func (crMgr *CRManager) releaseIP(ipamLabel, host, key string) string {
+	if crMgr.dryRun {
+		return crMgr.ipamProvider.LookupIP(ipamLabel, host, key)
+	}
+	if crMgr.skipReleaseInStandby(ipamRelease{ipamLabel: ipamLabel, host: host, key: key}) {
+		return crMgr.ipamProvider.LookupIP(ipamLabel, host, key)
+	}
+	return crMgr.ipamProvider.ReleaseIP(ipamLabel, host, key)
+}
+
+func (prov *ipamCRProvider) Start() {
+	prov.ipamCli.Start()
+}
+
+func (prov *ipamCRProvider) Stop() {
+	prov.ipamCli.Stop()
+}
+
+// Create IPAM CRD
+func (prov *ipamCRProvider) createIPAMResource() error {
+
+	frameIPAMResourceName := func() string {
+		prtn := ""
+		for _, ch := range DEFAULT_PARTITION {
+			elem := string(ch)
+			if unicode.IsUpper(ch) {
+				elem = strings.ToLower(elem) + "-"
+			}
+			prtn += elem
+		}
+		if string(prtn[len(prtn)-1]) == "-" {
+			prtn = prtn + ipamCRName
+		} else {
+			prtn = prtn + "." + ipamCRName
+		}
+
+		prtn = strings.Replace(prtn, "_", "-", -1)
+		prtn = strings.Replace(prtn, "--", "-", -1)
+
+		hostsplit := strings.Split(os.Getenv("HOSTNAME"), "-")
+		var host string
+		if len(hostsplit) > 2 {
+			host = strings.Join(hostsplit[0:len(hostsplit)-2], "-")
+		} else {
+			host = strings.Join(hostsplit, "-")
+		}
+		return strings.Join([]string{host, prtn}, ".")
+	}
+
+	crName := frameIPAMResourceName()
+	f5ipam := &ficV1.IPAM{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      crName,
+			Namespace: IPAMNamespace,
+		},
+		Spec: ficV1.IPAMSpec{
+			HostSpecs: make([]*ficV1.HostSpec, 0),
+		},
+		Status: ficV1.IPAMStatus{
+			IPStatus: make([]*ficV1.IPSpec, 0),
+		},
+	}
+	prov.ipamCR = IPAMNamespace + "/" + crName
+
+	ipamCR, err := prov.ipamCli.Create(f5ipam)
+	if err == nil {
+		log.Debugf("[ipam] Created IPAM Custom Resource: \n%v\n", ipamCR)
+		return nil
+	}
+
+	if strings.Contains(err.Error(), "already exists") {
+		err = prov.ipamCli.Delete(IPAMNamespace, crName, metaV1.DeleteOptions{})
+		if err != nil {
+			log.Debugf("[ipam] Delete failed. Error: %s", err.Error())
+		}
+
+		time.Sleep(3 * time.Second)
+
+		ipamCR, err = prov.ipamCli.Create(f5ipam)
+		if err == nil {
+			log.Debugf("[ipam] Created IPAM Custom Resource: \n%v\n", ipamCR)
+			return nil
+		}
+	}
+
+	log.Debugf("[ipam] error while creating IPAM custom resource. %v", err.Error())
+	return err
+}
+
+func (prov *ipamCRProvider) getIPAMCR() *ficV1.IPAM {
+	cr := strings.Split(prov.ipamCR, "/")
+	if len(cr) != 2 {
+		log.Errorf("[ipam] error while retrieving IPAM namespace and name.")
+		return nil
+	}
+	ipamCR, err := prov.ipamCli.Get(cr[0], cr[1])
+	if err != nil {
+		log.Errorf("[ipam] error while retrieving IPAM custom resource.")
+		return nil
+	}
+	return ipamCR
+}
+
+// Request IPAM for virtual IP address
+func (prov *ipamCRProvider) RequestIP(ipamLabel string, host string, key string) (string, error) {
+	if ipamLabel == "" {
+		return "", nil
+	}
+	ipamCR := prov.getIPAMCR()
+	if ipamCR == nil {
+		return "", fmt.Errorf("IPAM Custom Resource %v not found", prov.ipamCR)
+	}
+
+	if host != "" {
+		//For VS server
+		for _, ipst := range ipamCR.Status.IPStatus {
+			if ipst.IPAMLabel == ipamLabel && ipst.Host == host {
+				prov.observeIPAMRequest(ipamLabel, host, key)
+				return ipst.IP, nil
+			}
+		}
+
+		for _, hst := range ipamCR.Spec.HostSpecs {
+			if hst.Host == host {
+				if hst.IPAMLabel == ipamLabel {
+					//Check if HostSpec is already updated with IPAMLabel and Host
+					return "", nil
+				} else {
+					//Check this for key and host both
+					prov.ReleaseIP(hst.IPAMLabel, hst.Host, "")
+					break
+				}
+			}
+		}
+
+		ipamCR.SetResourceVersion(ipamCR.ResourceVersion)
+		ipamCR.Spec.HostSpecs = append(ipamCR.Spec.HostSpecs, &ficV1.HostSpec{
+			Host:      host,
+			IPAMLabel: ipamLabel,
+		})
+	} else if key != "" {
+		//For Transport Server
+		for _, ipst := range ipamCR.Status.IPStatus {
+			if ipst.IPAMLabel == ipamLabel && ipst.Key == key {
+				prov.observeIPAMRequest(ipamLabel, host, key)
+				return ipst.IP, nil
+			}
+		}
+
+		for _, hst := range ipamCR.Spec.HostSpecs {
+			if hst.Key == key {
+				if hst.IPAMLabel == ipamLabel {
+					//Check if HostSpec is already updated with IPAMLabel and Key
+					return "", nil
+				} else {
+					//Check this for key and host both
+					prov.ReleaseIP(hst.IPAMLabel, "", hst.Key)
+					break
+				}
+			}
+		}
+
+		ipamCR.SetResourceVersion(ipamCR.ResourceVersion)
+		ipamCR.Spec.HostSpecs = append(ipamCR.Spec.HostSpecs, &ficV1.HostSpec{
+			Key:       key,
+			IPAMLabel: ipamLabel,
+		})
+
+	} else {
+		log.Debugf("[IPAM] Invalid host and key.")
+		return "", nil
+	}
+
+	_, err := prov.ipamCli.Update(ipamCR)
+	if err != nil {
+		log.Errorf("[ipam] Error updating IPAM CR : %v", err)
+		return "", fmt.Errorf("unable to update IPAM Custom Resource: %v", err)
+	}
+	log.Debugf("[ipam] Updated IPAM CR.")
+	prov.startIPAMRequest(ipamLabel, host, key)
+	return "", nil
+
+}
+
+// startIPAMRequest records the time of requesting an IP address to the IPAM controller
+func (prov *ipamCRProvider) startIPAMRequest(ipamLabel, host, key string) {
+	if prov.requests == nil {
+		prov.requests = make(map[string]time.Time)
+	}
+	prov.requests[ipamLabel+"/"+host+"/"+key] = time.Now()
+}
+
+// observeIPAMRequest reports the latency of the IPAM controller allocating a requested IP address
+func (prov *ipamCRProvider) observeIPAMRequest(ipamLabel, host, key string) {
+	reqKey := ipamLabel + "/" + host + "/" + key
+	if startTime, ok := prov.requests[reqKey]; ok {
+		bigIPPrometheus.IPAMRequestDuration.Observe(time.Since(startTime).Seconds())
+		delete(prov.requests, reqKey)
+	}
+}
+
+func (prov *ipamCRProvider) ReleaseIP(ipamLabel string, host string, key string) string {
+	delete(prov.requests, ipamLabel+"/"+host+"/"+key)
+	ipamCR := prov.getIPAMCR()
+	var ip string
+	if ipamCR == nil || ipamLabel == "" {
+		return ip
+	}
+	index := -1
+	if host != "" {
+		//Find index for deleted host
+		for i, hostSpec := range ipamCR.Spec.HostSpecs {
+			if hostSpec.IPAMLabel == ipamLabel && hostSpec.Host == host {
+				index = i
+				break
+			}
+		}
+		//Find IP address for deleted host
+		for _, ipst := range ipamCR.Status.IPStatus {
+			if ipst.IPAMLabel == ipamLabel && ipst.Host == host {
+				ip = ipst.IP
+			}
+		}
+		if index != -1 {
+			ipamCR.Spec.HostSpecs = append(ipamCR.Spec.HostSpecs[:index], ipamCR.Spec.HostSpecs[index+1:]...)
+			ipamCR.SetResourceVersion(ipamCR.ResourceVersion)
+			_, err := prov.ipamCli.Update(ipamCR)
+			if err != nil {
+				log.Errorf("[ipam] ipam hostspec update error: %v", err)
+				return ""
+			}
+			log.Debug("[ipam] Updated IPAM CR hostspec while releasing IP.")
+		}
+	} else if key != "" {
+		//Find index for deleted key
+		for i, hostSpec := range ipamCR.Spec.HostSpecs {
+			if hostSpec.IPAMLabel == ipamLabel && hostSpec.Key == key {
+				index = i
+				break
+			}
+		}
+		//Find IP address for deleted host
+		for _, ipst := range ipamCR.Status.IPStatus {
+			if ipst.IPAMLabel == ipamLabel && ipst.Key == key {
+				ip = ipst.IP
+			}
+		}
+		if index != -1 {
+			ipamCR.Spec.HostSpecs = append(ipamCR.Spec.HostSpecs[:index], ipamCR.Spec.HostSpecs[index+1:]...)
+			ipamCR.SetResourceVersion(ipamCR.ResourceVersion)
+			_, err := prov.ipamCli.Update(ipamCR)
+			if err != nil {
+				log.Errorf("[ipam] ipam hostspec update error: %v", err)
+				return ""
+			}
+			log.Debug("[ipam] Updated IPAM CR hostspec while releasing IP.")
+		}
+
+	} else {
+		log.Debugf("[IPAM] Invalid host and key.")
+	}
+
+	return ip
+}
+
+func (prov *ipamCRProvider) LookupIP(ipamLabel string, host string, key string) string {
+	if ipamLabel == "" || (host == "" && key == "") {
+		return ""
+	}
+	ipamCR := prov.getIPAMCR()
+	if ipamCR == nil {
+		return ""
+	}
+	for _, ipst := range ipamCR.Status.IPStatus {
+		if ipst.IPAMLabel == ipamLabel && ((host != "" && ipst.Host == host) || (host == "" && ipst.Key == key)) {
+			return ipst.IP
+		}
+	}
+	return ""
+}
+
+// newStaticIPAMProvider returns the static IPAM provider for the ranges ConfigMap namespace/name.
+// The ConfigMap maps each ipamLabel to comma separated CIDR ranges.
+func newStaticIPAMProvider(kubeClient kubernetes.Interface, namespace, name string) *staticIPAMProvider {
+	return &staticIPAMProvider{
+		kubeClient: kubeClient,
+		namespace:  namespace,
+		rangesName: name,
+	}
+}
+
+func (prov *staticIPAMProvider) Start() {
+	if _, _, err := prov.loadAllocations(); err != nil {
+		log.Errorf("[ipam] %v", err)
+	}
+}
+
+func (prov *staticIPAMProvider) Stop() {}
+
+// loadAllocations reads the stored allocations along with the allocations ConfigMap,
+// which is nil when no address has been allocated yet
+func (prov *staticIPAMProvider) loadAllocations() ([]ipamAllocation, *v1.ConfigMap, error) {
+	cm, err := prov.kubeClient.CoreV1().ConfigMaps(prov.namespace).Get(
+		context.TODO(), prov.rangesName+ipamAllocationsSuffix, metaV1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to get the IPAM allocations: %v", err)
+	}
+	var allocations []ipamAllocation
+	if cm.Data[ipamAllocationsKey] != "" {
+		if err = json.Unmarshal([]byte(cm.Data[ipamAllocationsKey]), &allocations); err != nil {
+			return nil, nil, fmt.Errorf("invalid IPAM allocations in ConfigMap %v/%v: %v",
+				prov.namespace, prov.rangesName+ipamAllocationsSuffix, err)
+		}
+	}
+	return allocations, cm, nil
+}
+
+// updateAllocations reads the stored allocations, applies update to them and stores the result.
+// The allocations are read again and update is retried when another writer stored them meanwhile.
+// update returns the allocations to store, or nil when they are unchanged.
+func (prov *staticIPAMProvider) updateAllocations(update func([]ipamAllocation) ([]ipamAllocation, error)) error {
+	prov.Lock()
+	defer prov.Unlock()
+	return retryOnConflict(func() error {
+		allocations, cm, err := prov.loadAllocations()
+		if err != nil {
+			return err
+		}
+		allocations, err = update(allocations)
+		if err != nil || allocations == nil {
+			return err
+		}
+		return prov.saveAllocations(cm, allocations)
+	})
+}
+
+// saveAllocations stores the allocations in the ConfigMap read with them, creating it when missing.
+// A conflict error is returned when the ConfigMap has been written since it was read.
+func (prov *staticIPAMProvider) saveAllocations(cm *v1.ConfigMap, allocations []ipamAllocation) error {
+	sort.Slice(allocations, func(i, j int) bool {
+		if allocations[i].IPAMLabel != allocations[j].IPAMLabel {
+			return allocations[i].IPAMLabel < allocations[j].IPAMLabel
+		}
+		return allocations[i].IP < allocations[j].IP
+	})
+	data, err := json.Marshal(allocations)
+	if err != nil {
+		return err
+	}
+	cmClient := prov.kubeClient.CoreV1().ConfigMaps(prov.namespace)
+	name := prov.rangesName + ipamAllocationsSuffix
+	if cm == nil {
+		cm = &v1.ConfigMap{
+			ObjectMeta: metaV1.ObjectMeta{
+				Name:      name,
+				Namespace: prov.namespace,
+			},
+			Data: map[string]string{ipamAllocationsKey: string(data)},
+		}
+		_, err = cmClient.Create(context.TODO(), cm, metaV1.CreateOptions{})
+		if errors.IsAlreadyExists(err) {
+			return errors.NewConflict(v1.Resource("configmaps"), name, err)
+		}
+		return err
+	}
+	cm = cm.DeepCopy()
+	if cm.Data == nil {
+		cm.Data = make(map[string]string)
+	}
+	cm.Data[ipamAllocationsKey] = string(data)
+	_, err = cmClient.Update(context.TODO(), cm, metaV1.UpdateOptions{})
+	return err
+}
+
+// getRanges returns the CIDR ranges of the ipamLabel
+func (prov *staticIPAMProvider) getRanges(ipamLabel string) ([]*net.IPNet, error) {
+	cm, err := prov.kubeClient.CoreV1().ConfigMaps(prov.namespace).Get(
+		context.TODO(), prov.rangesName, metaV1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the IPAM ranges: %v", err)
+	}
+	rangesStr, ok := cm.Data[ipamLabel]
+	if !ok {
+		return nil, fmt.Errorf("ipamLabel %v not found in ConfigMap %v/%v", ipamLabel, prov.namespace, prov.rangesName)
+	}
+	var ranges []*net.IPNet
+	for _, cidr := range strings.Split(rangesStr, ",") {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid range %v of ipamLabel %v: %v", cidr, ipamLabel, err)
+		}
+		ranges = append(ranges, ipNet)
+	}
+	return ranges, nil
+}
+
+// findAllocation returns the index of the allocation of host, or of key when host is empty
+func findAllocation(allocations []ipamAllocation, host, key string) int {
+	for i, alloc := range allocations {
+		if (host != "" && alloc.Host == host) || (host == "" && alloc.Key == key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// nextFreeIP returns the first address of the ranges which is not allocated
+func nextFreeIP(ranges []*net.IPNet, allocations []ipamAllocation) string {
+	allocated := make(map[string]bool)
+	for _, alloc := range allocations {
+		allocated[alloc.IP] = true
+	}
+	for _, ipNet := range ranges {
+		ones, bits := ipNet.Mask.Size()
+		size := new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+		first, last := big.NewInt(0), new(big.Int).Sub(size, big.NewInt(1))
+		// Skip the network (or IPv6 subnet-router anycast) and IPv4 broadcast addresses
+		if bits-ones > 1 {
+			first.SetInt64(1)
+			if bits == 32 {
+				last.Sub(last, big.NewInt(1))
+			}
+		}
+		base := new(big.Int).SetBytes(ipNet.IP)
+		for offset := first; offset.Cmp(last) <= 0; offset.Add(offset, big.NewInt(1)) {
+			ipBytes := new(big.Int).Add(base, offset).Bytes()
+			ip := make(net.IP, len(ipNet.IP))
+			copy(ip[len(ip)-len(ipBytes):], ipBytes)
+			if !allocated[ip.String()] {
+				return ip.String()
+			}
+		}
+	}
+	return ""
+}
+
+// RequestIP allocates the next free address of the ipamLabel ranges, the
+// address is returned right away as there is no controller to wait for
+func (prov *staticIPAMProvider) RequestIP(ipamLabel string, host string, key string) (string, error) {
+	if ipamLabel == "" {
+		return "", nil
+	}
+	if host == "" && key == "" {
+		log.Debugf("[IPAM] Invalid host and key.")
+		return "", nil
+	}
+	var ip string
+	err := prov.updateAllocations(func(allocations []ipamAllocation) ([]ipamAllocation, error) {
+		ip = ""
+		if index := findAllocation(allocations, host, key); index != -1 {
+			if allocations[index].IPAMLabel == ipamLabel {
+				ip = allocations[index].IP
+				return nil, nil
+			}
+			// The ipamLabel has changed, allocate again from the new ranges
+			allocations = append(append([]ipamAllocation{}, allocations[:index]...), allocations[index+1:]...)
+		}
+
+		ranges, err := prov.getRanges(ipamLabel)
+		if err != nil {
+			return nil, err
+		}
+		if ip = nextFreeIP(ranges, allocations); ip == "" {
+			return nil, errIPPoolExhausted
+		}
+		return append(append([]ipamAllocation{}, allocations...), ipamAllocation{
+			IPAMLabel: ipamLabel,
+			Host:      host,
+			Key:       key,
+			IP:        ip,
+		}), nil
+	})
+	switch {
+	case err == errIPPoolExhausted:
+		log.Errorf("[ipam] No free address left in the ranges of ipamLabel %v", ipamLabel)
+		return "", err
+	case err != nil:
+		log.Errorf("[ipam] Error allocating an address of ipamLabel %v: %v", ipamLabel, err)
+		return "", err
+	}
+	log.Debugf("[ipam] Allocated %v for ipamLabel %v", ip, ipamLabel)
+	return ip, nil
+}
+
+func (prov *staticIPAMProvider) ReleaseIP(ipamLabel string, host string, key string) string {
+	if ipamLabel == "" {
+		return ""
+	}
+	if host == "" && key == "" {
+		log.Debugf("[IPAM] Invalid host and key.")
+		return ""
+	}
+	var ip string
+	err := prov.updateAllocations(func(allocations []ipamAllocation) ([]ipamAllocation, error) {
+		ip = ""
+		index := findAllocation(allocations, host, key)
+		if index == -1 || allocations[index].IPAMLabel != ipamLabel {
+			return nil, nil
+		}
+		ip = allocations[index].IP
+		return append(append([]ipamAllocation{}, allocations[:index]...), allocations[index+1:]...), nil
+	})
+	if err != nil {
+		log.Errorf("[ipam] Error storing the IPAM allocations: %v", err)
+		return ""
+	}
+	if ip != "" {
+		log.Debugf("[ipam] Released %v of ipamLabel %v", ip, ipamLabel)
+	}
+	return ip
+}
+
+func (prov *staticIPAMProvider) LookupIP(ipamLabel string, host string, key string) string {
+	if ipamLabel == "" || (host == "" && key == "") {
+		return ""
+	}
+	allocations, _, err := prov.loadAllocations()
+	if err != nil {
+		log.Errorf("[ipam] %v", err)
+		return ""
+	}
+	index := findAllocation(allocations, host, key)
+	if index == -1 || allocations[index].IPAMLabel != ipamLabel {
+		return ""
+	}
+	return allocations[index].IP
+}
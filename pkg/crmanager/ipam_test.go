@@ -0,0 +1,228 @@
+package crmanager
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/config/apis/cis/v1"
+	crdfake "github.com/F5Networks/k8s-bigip-ctlr/config/client/clientset/versioned/fake"
+	"github.com/F5Networks/k8s-bigip-ctlr/pkg/test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/util/workqueue"
+)
+
+var _ = Describe("IPAM Provider Tests", func() {
+	namespace := "kube-system"
+
+	Describe("Static IPAM Provider", func() {
+		var kubeClient kubernetes.Interface
+		var prov *staticIPAMProvider
+
+		getAllocations := func() string {
+			cm, err := kubeClient.CoreV1().ConfigMaps(namespace).Get(
+				context.TODO(), "cis-ipam"+ipamAllocationsSuffix, metav1.GetOptions{})
+			Expect(err).To(BeNil(), "Allocations ConfigMap not found")
+			return cm.Data[ipamAllocationsKey]
+		}
+
+		BeforeEach(func() {
+			kubeClient = k8sfake.NewSimpleClientset(&v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cis-ipam",
+					Namespace: namespace,
+				},
+				Data: map[string]string{
+					"test": "10.10.10.0/30, 10.10.20.8/31",
+					"dev":  "2001:db8::/126",
+					"bad":  "10.10.30.0",
+				},
+			})
+			prov = newStaticIPAMProvider(kubeClient, namespace, "cis-ipam")
+			prov.Start()
+		})
+
+		It("Request IP Address", func() {
+			Expect(prov.RequestIP("test", "foo.com", "")).To(Equal("10.10.10.1"))
+			Expect(prov.RequestIP("test", "foo.com", "")).To(Equal("10.10.10.1"), "Allocation not reused")
+			Expect(prov.RequestIP("test", "", "default/ts")).To(Equal("10.10.10.2"))
+			// Network and broadcast addresses of /30 are skipped, /31 uses both
+			Expect(prov.RequestIP("test", "", "default/svc_svc")).To(Equal("10.10.20.8"))
+			Expect(prov.RequestIP("test", "bar.com", "")).To(Equal("10.10.20.9"))
+			ip, err := prov.RequestIP("test", "baz.com", "")
+			Expect(ip).To(BeEmpty(), "Address allocated from exhausted ranges")
+			Expect(err).To(Equal(errIPPoolExhausted))
+			Expect(prov.RequestIP("dev", "foo.com", "")).To(Equal("2001:db8::1"), "ipamLabel change not handled")
+			Expect(prov.RequestIP("test", "baz.com", "")).To(Equal("10.10.10.1"), "Address of old ipamLabel not released")
+
+			Expect(prov.RequestIP("", "qux.com", "")).To(BeEmpty(), "Address allocated without ipamLabel")
+			Expect(prov.RequestIP("test", "", "")).To(BeEmpty(), "Address allocated without host and key")
+			ip, err = prov.RequestIP("unknown", "qux.com", "")
+			Expect(ip).To(BeEmpty(), "Address allocated for unknown ipamLabel")
+			Expect(err).NotTo(BeNil())
+			ip, err = prov.RequestIP("bad", "qux.com", "")
+			Expect(ip).To(BeEmpty(), "Address allocated from invalid range")
+			Expect(err).NotTo(BeNil())
+
+			Expect(getAllocations()).To(ContainSubstring(
+				`{"ipamLabel":"dev","host":"foo.com","ip":"2001:db8::1"}`))
+			Expect(getAllocations()).To(ContainSubstring(
+				`{"ipamLabel":"test","key":"default/ts","ip":"10.10.10.2"}`))
+		})
+
+		It("Release IP Address", func() {
+			Expect(prov.RequestIP("test", "foo.com", "")).To(Equal("10.10.10.1"))
+			Expect(prov.RequestIP("test", "", "default/ts")).To(Equal("10.10.10.2"))
+
+			Expect(prov.ReleaseIP("dev", "foo.com", "")).To(BeEmpty(), "Address released for wrong ipamLabel")
+			Expect(prov.ReleaseIP("test", "bar.com", "")).To(BeEmpty(), "Unexpected IP address released")
+			Expect(prov.ReleaseIP("test", "foo.com", "")).To(Equal("10.10.10.1"))
+			Expect(getAllocations()).NotTo(ContainSubstring("foo.com"), "Release not stored")
+			Expect(prov.RequestIP("test", "bar.com", "")).To(Equal("10.10.10.1"), "Released address not reused")
+			Expect(prov.ReleaseIP("test", "", "default/ts")).To(Equal("10.10.10.2"))
+		})
+
+		It("Restore allocations", func() {
+			Expect(prov.RequestIP("test", "foo.com", "")).To(Equal("10.10.10.1"))
+			Expect(prov.RequestIP("test", "bar.com", "")).To(Equal("10.10.10.2"))
+
+			restarted := newStaticIPAMProvider(kubeClient, namespace, "cis-ipam")
+			restarted.Start()
+			Expect(restarted.RequestIP("test", "bar.com", "")).To(Equal("10.10.10.2"), "Allocation not restored")
+			Expect(restarted.RequestIP("test", "baz.com", "")).To(Equal("10.10.20.8"), "Allocated address reused")
+		})
+
+		It("Reads the allocations stored by other replicas", func() {
+			other := newStaticIPAMProvider(kubeClient, namespace, "cis-ipam")
+			other.Start()
+			Expect(prov.RequestIP("test", "foo.com", "")).To(Equal("10.10.10.1"))
+			Expect(other.LookupIP("test", "foo.com", "")).To(Equal("10.10.10.1"))
+			Expect(other.RequestIP("test", "bar.com", "")).To(Equal("10.10.10.2"), "Allocated address reused")
+			Expect(prov.ReleaseIP("test", "bar.com", "")).To(Equal("10.10.10.2"))
+			Expect(other.LookupIP("test", "bar.com", "")).To(BeEmpty(), "Released address still allocated")
+		})
+
+		It("Retries conflicting writes of the allocations", func() {
+			Expect(prov.RequestIP("test", "foo.com", "")).To(Equal("10.10.10.1"))
+
+			// Another writer allocates an address between the read and the write of the allocations
+			conflicted := false
+			kubeClient.(*k8sfake.Clientset).PrependReactor("update", "configmaps",
+				func(action k8stesting.Action) (bool, runtime.Object, error) {
+					if conflicted {
+						return false, nil, nil
+					}
+					conflicted = true
+					cm := action.(k8stesting.UpdateAction).GetObject().(*v1.ConfigMap).DeepCopy()
+					cm.Data[ipamAllocationsKey] = `[{"ipamLabel":"test","host":"foo.com","ip":"10.10.10.1"},` +
+						`{"ipamLabel":"test","host":"bar.com","ip":"10.10.10.2"}]`
+					_ = kubeClient.(*k8sfake.Clientset).Tracker().Update(v1.SchemeGroupVersion.WithResource("configmaps"),
+						cm, namespace)
+					return true, nil, errors.NewConflict(v1.Resource("configmaps"), cm.Name, fmt.Errorf("modified"))
+				})
+			Expect(prov.RequestIP("test", "baz.com", "")).To(Equal("10.10.20.8"), "Conflicting allocation not read")
+			Expect(conflicted).To(BeTrue())
+			Expect(getAllocations()).To(ContainSubstring(`"host":"bar.com"`), "Allocation of other writer lost")
+			Expect(getAllocations()).To(ContainSubstring(`"host":"baz.com"`))
+		})
+	})
+
+	Describe("Pending IPAM allocations", func() {
+		var mockCRM *mockCRManager
+		var vs *cisapiv1.VirtualServer
+
+		BeforeEach(func() {
+			mockCRM = newMockCRManager()
+			mockCRM.rscQueue = workqueue.NewNamedRateLimitingQueue(
+				workqueue.DefaultControllerRateLimiter(), "custom-resource-controller")
+			vs = test.NewVirtualServer("SampleVS", "default", cisapiv1.VirtualServerSpec{
+				Host:      "foo.com",
+				IPAMLabel: "test",
+			})
+		})
+
+		It("Report pending allocation", func() {
+			cond := mockCRM.setIPAMPending(VirtualServer, vs, vs.Namespace, vs.Name, "host foo.com", nil)
+			Expect(cond.Reason).To(Equal(ReasonIPAMPending))
+			Expect(cond.Message).To(Equal("Waiting for IPAM to allocate an address for host foo.com"))
+
+			mockCRM.ipamPending[VirtualServer+"/default/SampleVS"].since = time.Now().Add(-2 * ipamPendingTimeout)
+			cond = mockCRM.setIPAMPending(VirtualServer, vs, vs.Namespace, vs.Name, "host foo.com", nil)
+			Expect(cond.Reason).To(Equal(ReasonIPAMPending))
+			Expect(cond.Message).To(ContainSubstring("the IP pool may be exhausted"), "Timeout not reported")
+
+			cond = mockCRM.setIPAMPending(VirtualServer, vs, vs.Namespace, vs.Name, "host foo.com", errIPPoolExhausted)
+			Expect(cond.Reason).To(Equal(ReasonIPPoolExhausted))
+			Expect(cond.Message).To(Equal("IP pool exhausted, no address available for host foo.com"))
+			Expect(len(mockCRM.ipamPending)).To(Equal(1), "Pending allocation tracked more than once")
+		})
+
+		It("Requeue pending allocation", func() {
+			rKey := &rqKey{namespace: vs.Namespace, kind: VirtualServer, rscName: vs.Name, rsc: vs}
+			mockCRM.resetIPAMPending(rKey)
+			_ = mockCRM.setIPAMPending(VirtualServer, vs, vs.Namespace, vs.Name, "host foo.com", nil)
+			Expect(mockCRM.requeueIPAMPending(rKey)).To(BeTrue(), "Pending allocation not tracked")
+			pendingKey := mockCRM.ipamPending[VirtualServer+"/default/SampleVS"].rKey
+			Expect(pendingKey.rsc).To(Equal(vs))
+			Expect(mockCRM.rscQueue.NumRequeues(pendingKey)).To(Equal(1), "Pending allocation not requeued")
+
+			// Processing of other resources does not requeue it again
+			svcKey := &rqKey{namespace: vs.Namespace, kind: Service, rscName: "svc1"}
+			mockCRM.resetIPAMPending(svcKey)
+			Expect(mockCRM.requeueIPAMPending(svcKey)).To(BeFalse())
+			Expect(mockCRM.rscQueue.NumRequeues(pendingKey)).To(Equal(1), "Pending allocation requeued again")
+
+			// Retry with backoff while the address is still missing
+			mockCRM.resetIPAMPending(pendingKey)
+			_ = mockCRM.setIPAMPending(VirtualServer, vs, vs.Namespace, vs.Name, "host foo.com", nil)
+			Expect(mockCRM.requeueIPAMPending(pendingKey)).To(BeTrue())
+			Expect(mockCRM.rscQueue.NumRequeues(pendingKey)).To(Equal(2), "Pending allocation not retried")
+
+			// Allocated address
+			mockCRM.resetIPAMPending(pendingKey)
+			Expect(mockCRM.requeueIPAMPending(pendingKey)).To(BeFalse(), "Allocated address still pending")
+			Expect(mockCRM.ipamPending).To(BeEmpty())
+			Expect(mockCRM.rscQueue.NumRequeues(pendingKey)).To(Equal(0), "Backoff not reset")
+
+			// Deleted resource
+			_ = mockCRM.setIPAMPending(VirtualServer, vs, vs.Namespace, vs.Name, "host foo.com", nil)
+			rKey.rscDelete = true
+			Expect(mockCRM.requeueIPAMPending(rKey)).To(BeFalse(), "Deleted resource still pending")
+			Expect(mockCRM.ipamPending).To(BeEmpty())
+		})
+
+		It("Refresh requeued pending allocation", func() {
+			mockCRM.kubeCRClient = crdfake.NewSimpleClientset(vs)
+			mockCRM.kubeClient = k8sfake.NewSimpleClientset()
+			mockCRM.crInformers = make(map[string]*CRInformer)
+			mockCRM.resourceSelector, _ = createLabelSelector(DefaultCustomResourceLabel)
+			_ = mockCRM.addNamespacedInformer(vs.Namespace)
+			_ = mockCRM.crInformers[vs.Namespace].vsInformer.GetIndexer().Add(vs)
+
+			_ = mockCRM.setIPAMPending(VirtualServer, vs, vs.Namespace, vs.Name, "host foo.com", nil)
+			pendingKey := mockCRM.ipamPending[VirtualServer+"/default/SampleVS"].rKey
+			Expect(mockCRM.refreshIPAMPending(&rqKey{namespace: vs.Namespace, kind: VirtualServer,
+				rscName: vs.Name, rsc: vs})).To(BeTrue(), "Informer event should be processed")
+
+			// Requeued resource is processed with its latest version
+			updated := vs.DeepCopy()
+			updated.Spec.IPAMLabel = "dev"
+			_ = mockCRM.crInformers[vs.Namespace].vsInformer.GetIndexer().Update(updated)
+			Expect(mockCRM.refreshIPAMPending(pendingKey)).To(BeTrue())
+			Expect(pendingKey.rsc).To(Equal(updated))
+
+			// Requeued resource is dropped once deleted
+			_ = mockCRM.crInformers[vs.Namespace].vsInformer.GetIndexer().Delete(updated)
+			Expect(mockCRM.refreshIPAMPending(pendingKey)).To(BeFalse(), "Deleted resource should not be processed")
+			Expect(mockCRM.ipamPending).To(BeEmpty())
+		})
+	})
+})
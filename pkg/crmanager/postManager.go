@@ -24,9 +24,16 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	bigIPPrometheus "github.com/F5Networks/k8s-bigip-ctlr/pkg/prometheus"
+	rsc "github.com/F5Networks/k8s-bigip-ctlr/pkg/resource"
+	"github.com/F5Networks/k8s-bigip-ctlr/pkg/tokenmanager"
 	log "github.com/F5Networks/k8s-bigip-ctlr/pkg/vlogger"
 )
 
@@ -34,11 +41,35 @@ const (
 	timeoutSmall  = 3 * time.Second
 	timeoutMedium = 30 * time.Second
 	timeoutLarge  = 60 * time.Second
+
+	// MultiBIGIPModeActive posts the declarations only to the active BIG-IP of the HA group
+	MultiBIGIPModeActive = "active"
+	// MultiBIGIPModeAll posts the declarations to each of the BIG-IPs independently
+	MultiBIGIPModeAll = "all"
+
+	failoverStatusActive = "ACTIVE"
 )
 
 type PostManager struct {
 	postChan   chan config
+	respChan   chan resourceStatusMeta
 	httpClient *http.Client
+	// last response published on respChan
+	lastRespStatus resourceStatusMeta
+	// BIG-IPs the declarations are posted to, set up once by NewPostManager
+	devices []*bigIPDevice
+	// index of the BIG-IP last found active, read while preparing the declarations
+	activeDevice int
+	activeMutex  sync.Mutex
+	// BIG-IPs which failed the declaration being posted, they are retried after retryTimeout
+	failedDevices []*bigIPDevice
+	retryTimeout  time.Duration
+	// guards the Tenant declarations accepted by the BIG-IPs
+	tenantsMutex sync.Mutex
+	// guards the credentials and the HTTP client, which are updated in place
+	credsMutex sync.RWMutex
+	// authenticates with auth tokens instead of Basic auth when set
+	tokenManager *tokenmanager.TokenManager
 	PostParams
 }
 
@@ -46,11 +77,18 @@ type PostParams struct {
 	BIGIPUsername string
 	BIGIPPassword string
 	BIGIPURL      string
-	TrustedCerts  string
-	SSLInsecure   bool
-	AS3PostDelay  int
+	// BIG-IPs configured with the same credentials, BIGIPURL is used when empty
+	BIGIPURLs []string
+	// Posts to the active BIG-IP or to all the BIG-IPs
+	MultiBIGIPMode string
+	TrustedCerts   string
+	SSLInsecure    bool
+	AS3PostDelay   int
 	//Log the AS3 response body in Controller logs
 	LogResponse bool
+	// Authenticate with an auth token of the login provider instead of Basic auth
+	TokenAuth     bool
+	LoginProvider string
 }
 
 type GTMParams struct {
@@ -62,15 +100,50 @@ type GTMParams struct {
 type config struct {
 	data      string
 	routesMap map[string][]string
-	as3APIURL string
+	tenants   []string
+	reqMeta   requestMeta
+}
+
+// bigIPDevice tracks the response of a BIG-IP to the declarations posted to it
+type bigIPDevice struct {
+	url string
+	// declaration last posted and the response of BIG-IP to it
+	postedData string
+	accepted   bool
+	message    string
+	failures   []rsc.AS3Failure
+	// declarations of the Tenants last accepted by the BIG-IP
+	tenantDecls map[string]string
+}
+
+// requestMeta holds the custom resources included in a declaration
+type requestMeta struct {
+	// resources maps namespace/name of the custom resource to its kind
+	resources map[string]string
+	// objects maps the AS3 object names to namespace/name of the custom resources producing them
+	objects map[string][]string
+}
+
+// resourceStatusMeta holds the response of BIG-IP for the custom resources of a declaration
+type resourceStatusMeta struct {
+	accepted  bool
+	message   string
+	resources map[string]string
+	// failures maps namespace/name of the custom resources to the AS3 errors reported for them
+	failures map[string]string
 }
 
 func NewPostManager(params PostParams) *PostManager {
 	pm := &PostManager{
 		postChan:   make(chan config, 1),
+		respChan:   make(chan resourceStatusMeta, 1),
 		PostParams: params,
 	}
 	pm.setupBIGIPRESTClient()
+	pm.setupBIGIPDevices()
+	if params.TokenAuth {
+		pm.tokenManager = tokenmanager.NewTokenManager(params.BIGIPUsername, params.BIGIPPassword, params.LoginProvider)
+	}
 
 	// configWorker runs as a separate go routine
 	// blocks on postChan to get new/updated configuration to be posted to BIG-IP
@@ -105,8 +178,83 @@ func (postMgr *PostManager) setupBIGIPRESTClient() {
 	}
 }
 
-func (postMgr *PostManager) getAS3APIURL(tenants []string) string {
-	apiURL := postMgr.BIGIPURL + "/mgmt/shared/appsvcs/declare/" + strings.Join(tenants, ",")
+// updateCredentials updates the credentials and the trusted certificates of the BIG-IPs
+// and rebuilds the HTTP client, the declarations being posted are retried with them
+func (postMgr *PostManager) updateCredentials(username, password, trustedCerts string, sslInsecure bool) {
+	postMgr.credsMutex.Lock()
+	defer postMgr.credsMutex.Unlock()
+	postMgr.BIGIPUsername = username
+	postMgr.BIGIPPassword = password
+	if postMgr.tokenManager != nil {
+		postMgr.tokenManager.SetCredentials(username, password)
+	}
+	if postMgr.TrustedCerts == trustedCerts && postMgr.SSLInsecure == sslInsecure && postMgr.httpClient != nil {
+		return
+	}
+	postMgr.TrustedCerts = trustedCerts
+	postMgr.SSLInsecure = sslInsecure
+	oldClient := postMgr.httpClient
+	postMgr.setupBIGIPRESTClient()
+	if oldClient != nil {
+		oldClient.CloseIdleConnections()
+	}
+	log.Info("[AS3] Updated the HTTP client with the trusted certificates of BIG-IP")
+}
+
+// setBasicAuth sets the credentials of the BIG-IPs on the request, unless auth tokens are used
+func (postMgr *PostManager) setBasicAuth(req *http.Request) {
+	if postMgr.tokenManager != nil {
+		return
+	}
+	postMgr.credsMutex.RLock()
+	defer postMgr.credsMutex.RUnlock()
+	req.SetBasicAuth(postMgr.BIGIPUsername, postMgr.BIGIPPassword)
+}
+
+func (postMgr *PostManager) getHTTPClient() *http.Client {
+	postMgr.credsMutex.RLock()
+	defer postMgr.credsMutex.RUnlock()
+	return postMgr.httpClient
+}
+
+// doRequest sends the request to BIG-IP, with the auth token when auth tokens are used
+func (postMgr *PostManager) doRequest(request *http.Request) (*http.Response, error) {
+	if postMgr.tokenManager != nil {
+		return postMgr.tokenManager.Do(postMgr.getHTTPClient(), request)
+	}
+	return postMgr.getHTTPClient().Do(request)
+}
+
+// setupBIGIPDevices sets up the BIG-IPs the declarations are posted to
+func (postMgr *PostManager) setupBIGIPDevices() {
+	urls := postMgr.BIGIPURLs
+	if len(urls) == 0 {
+		urls = []string{postMgr.BIGIPURL}
+	}
+	postMgr.devices = nil
+	for _, url := range urls {
+		postMgr.devices = append(postMgr.devices, &bigIPDevice{url: url})
+	}
+}
+
+func (postMgr *PostManager) getDevices() []*bigIPDevice {
+	return postMgr.devices
+}
+
+func (postMgr *PostManager) getActiveDeviceIndex() int {
+	postMgr.activeMutex.Lock()
+	defer postMgr.activeMutex.Unlock()
+	return postMgr.activeDevice
+}
+
+func (postMgr *PostManager) setActiveDeviceIndex(idx int) {
+	postMgr.activeMutex.Lock()
+	defer postMgr.activeMutex.Unlock()
+	postMgr.activeDevice = idx
+}
+
+func (postMgr *PostManager) getAS3APIURL(bigipURL string, tenants []string) string {
+	apiURL := bigipURL + "/mgmt/shared/appsvcs/declare/" + strings.Join(tenants, ",")
 	return apiURL
 }
 
@@ -115,10 +263,12 @@ func (postMgr *PostManager) getAS3APIURL(tenants []string) string {
 func (postMgr *PostManager) Write(
 	data string,
 	partitions []string,
+	reqMeta requestMeta,
 ) {
 	activeConfig := config{
-		data:      data,
-		as3APIURL: postMgr.getAS3APIURL(partitions),
+		data:    data,
+		tenants: partitions,
+		reqMeta: reqMeta,
 	}
 
 	// Always push latest activeConfig to channel
@@ -154,53 +304,148 @@ func (postMgr *PostManager) configWorker() {
 		}
 
 		posted := postMgr.postConfig(cfg)
-		// To handle general errors
+		// Retry the BIG-IPs which failed the declaration, until a newer one is received
 		for !posted {
-			posted = postMgr.postOnEventOrTimeout(timeoutMedium, cfg)
+			cfg, posted = postMgr.postOnEventOrTimeout(postMgr.retryTimeout, cfg)
 		}
 		firstPost = false
 	}
 }
 
-func (postMgr *PostManager) postOnEventOrTimeout(timeout time.Duration, cfg config) bool {
+// postOnEventOrTimeout posts a newer configuration as soon as it is received,
+// otherwise it posts the configuration again to the BIG-IPs which failed it after the timeout
+func (postMgr *PostManager) postOnEventOrTimeout(timeout time.Duration, cfg config) (config, bool) {
 	select {
 	case newCfg := <-postMgr.postChan:
-		return postMgr.postConfig(newCfg)
+		return newCfg, postMgr.postConfig(newCfg)
 	case <-time.After(timeout):
-		return postMgr.postConfig(cfg)
+		return cfg, postMgr.retryConfig(cfg)
 	}
 }
 
+// postConfig posts the configuration to the active BIG-IP, or to each of the BIG-IPs
+// Returns whether all of them processed it, the ones which failed are retried with retryConfig
 func (postMgr *PostManager) postConfig(cfg config) bool {
+	devices, err := postMgr.getPostDevices()
+	if err != nil {
+		log.Errorf("[AS3] %v", err)
+		postMgr.failedDevices = nil
+		postMgr.retryTimeout = timeoutMedium
+		return false
+	}
+	return postMgr.postConfigToDevices(cfg, devices)
+}
+
+// retryConfig posts the configuration again to the BIG-IPs which failed it
+func (postMgr *PostManager) retryConfig(cfg config) bool {
+	if len(postMgr.failedDevices) == 0 {
+		return postMgr.postConfig(cfg)
+	}
+	return postMgr.postConfigToDevices(cfg, postMgr.failedDevices)
+}
+
+// postConfigToDevices posts the configuration to each of the BIG-IPs independently and
+// records the ones which failed it, so that a BIG-IP rejecting it does not hold up the others
+func (postMgr *PostManager) postConfigToDevices(cfg config, devices []*bigIPDevice) bool {
+	var failed []*bigIPDevice
+	postMgr.retryTimeout = timeoutMedium
+	for _, dev := range devices {
+		retryTimeout := postMgr.postConfigToDevice(cfg, dev)
+		if retryTimeout == 0 {
+			continue
+		}
+		failed = append(failed, dev)
+		if retryTimeout < postMgr.retryTimeout {
+			postMgr.retryTimeout = retryTimeout
+		}
+	}
+	postMgr.failedDevices = failed
+	return len(failed) == 0
+}
+
+// getPostDevices returns the BIG-IPs to post the configuration to
+func (postMgr *PostManager) getPostDevices() ([]*bigIPDevice, error) {
+	devices := postMgr.getDevices()
+	if len(devices) == 1 || postMgr.MultiBIGIPMode == MultiBIGIPModeAll {
+		return devices, nil
+	}
+	if dev := postMgr.getActiveDevice(); dev != nil {
+		return []*bigIPDevice{dev}, nil
+	}
+	return nil, fmt.Errorf("None of the BIG-IPs is active, unable to post the declaration")
+}
+
+// getActiveDevice returns the active BIG-IP of the HA group,
+// the BIG-IP last found active is checked first
+func (postMgr *PostManager) getActiveDevice() *bigIPDevice {
+	devices := postMgr.getDevices()
+	activeDevice := postMgr.getActiveDeviceIndex()
+	for i := range devices {
+		idx := (activeDevice + i) % len(devices)
+		status, err := postMgr.getFailoverStatus(devices[idx].url)
+		if err != nil {
+			log.Warningf("[AS3] Unable to get the failover status of BIG-IP %v: %v", devices[idx].url, err)
+			continue
+		}
+		log.Debugf("[AS3] Failover status of BIG-IP %v: %v", devices[idx].url, status)
+		if status == failoverStatusActive {
+			if idx != activeDevice {
+				log.Infof("[AS3] BIG-IP %v is active, posting the declarations to it", devices[idx].url)
+			}
+			postMgr.setActiveDeviceIndex(idx)
+			return devices[idx]
+		}
+	}
+	return nil
+}
+
+// postConfigToDevice posts the configuration to the BIG-IP and returns the time to wait
+// before posting it again, zero when the BIG-IP processed it
+func (postMgr *PostManager) postConfigToDevice(cfg config, dev *bigIPDevice) time.Duration {
 	httpReqBody := bytes.NewBuffer([]byte(cfg.data))
 
-	req, err := http.NewRequest("POST", cfg.as3APIURL, httpReqBody)
+	as3APIURL := postMgr.getAS3APIURL(dev.url, cfg.tenants)
+	req, err := http.NewRequest("POST", as3APIURL, httpReqBody)
 	if err != nil {
 		log.Errorf("[AS3] Creating new HTTP request error: %v ", err)
-		return false
+		return timeoutMedium
 	}
-	log.Debugf("[AS3] posting request to %v", cfg.as3APIURL)
-	req.SetBasicAuth(postMgr.BIGIPUsername, postMgr.BIGIPPassword)
+	log.Debugf("[AS3] posting request to %v", as3APIURL)
+	postMgr.setBasicAuth(req)
 
+	bigIPPrometheus.AS3DeclarationSize.Set(float64(len(cfg.data)))
+	startTime := time.Now()
 	httpResp, responseMap := postMgr.httpPOST(req)
+	code := "error"
+	if httpResp != nil {
+		code = strconv.Itoa(httpResp.StatusCode)
+	}
+	bigIPPrometheus.AS3PostDuration.WithLabelValues(dev.url, code).Observe(time.Since(startTime).Seconds())
 	if httpResp == nil || responseMap == nil {
-		return false
+		return timeoutMedium
 	}
 
+	var posted bool
+	retryTimeout := timeoutMedium
 	switch httpResp.StatusCode {
 	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
-		return postMgr.handleResponseStatusOK(responseMap, cfg)
+		posted = postMgr.handleResponseStatusOK(responseMap, cfg, dev)
 	case http.StatusServiceUnavailable:
-		return postMgr.handleResponseStatusServiceUnavailable(responseMap, cfg)
+		posted = postMgr.handleResponseStatusServiceUnavailable(responseMap)
+		retryTimeout = timeoutSmall
 	case http.StatusNotFound:
-		return postMgr.handleResponseStatusNotFound(responseMap)
+		posted = postMgr.handleResponseStatusNotFound(responseMap, cfg, dev)
 	default:
-		return postMgr.handleResponseOthers(responseMap, cfg)
+		posted = postMgr.handleResponseOthers(responseMap, cfg, dev)
+	}
+	if posted {
+		return 0
 	}
+	return retryTimeout
 }
 
 func (postMgr *PostManager) httpPOST(request *http.Request) (*http.Response, map[string]interface{}) {
-	httpResp, err := postMgr.httpClient.Do(request)
+	httpResp, err := postMgr.doRequest(request)
 	if err != nil {
 		log.Errorf("[AS3] REST call error: %v ", err)
 		return nil, nil
@@ -224,7 +469,7 @@ func (postMgr *PostManager) httpPOST(request *http.Request) (*http.Response, map
 	return httpResp, response
 }
 
-func (postMgr *PostManager) handleResponseStatusOK(responseMap map[string]interface{}, cfg config) bool {
+func (postMgr *PostManager) handleResponseStatusOK(responseMap map[string]interface{}, cfg config, dev *bigIPDevice) bool {
 	//traverse all response results
 	results := (responseMap["results"]).([]interface{})
 	for _, value := range results {
@@ -232,22 +477,26 @@ func (postMgr *PostManager) handleResponseStatusOK(responseMap map[string]interf
 		//log result with code, tenant and message
 		log.Debugf("[AS3] Response from BIG-IP: code: %v --- tenant:%v --- message: %v", v["code"], v["tenant"], v["message"])
 	}
+	postMgr.updateAcceptedTenants(cfg, dev, cfg.tenants)
+	bigIPPrometheus.AS3LastSuccessfulPost.WithLabelValues(dev.url).SetToCurrentTime()
+	postMgr.notifyResponse(cfg, dev, true, "", nil)
 
 	return true
 }
 
-func (postMgr *PostManager) handleResponseStatusServiceUnavailable(responseMap map[string]interface{}, cfg config) bool {
+func (postMgr *PostManager) handleResponseStatusServiceUnavailable(responseMap map[string]interface{}) bool {
 	log.Errorf("[AS3] Big-IP Responded with error code: %v", responseMap["code"])
 	log.Debugf("[AS3] Response from BIG-IP: BIG-IP is busy, waiting %v seconds and re-posting the declaration", timeoutSmall)
-	return postMgr.postOnEventOrTimeout(timeoutSmall, cfg)
+	return false
 }
 
-func (postMgr *PostManager) handleResponseStatusNotFound(responseMap map[string]interface{}) bool {
+func (postMgr *PostManager) handleResponseStatusNotFound(responseMap map[string]interface{}, cfg config, dev *bigIPDevice) bool {
 	if err, ok := (responseMap["error"]).(map[string]interface{}); ok {
 		log.Errorf("[AS3] Big-IP Responded with error code: %v", err["code"])
 	} else {
 		log.Errorf("[AS3] Big-IP Responded with error code: %v", http.StatusNotFound)
 	}
+	postMgr.notifyResponse(cfg, dev, false, getResponseMessage(responseMap), rsc.ParseAS3Failures(responseMap))
 
 	if postMgr.LogResponse {
 		log.Errorf("[AS3] Raw response from Big-IP: %v ", responseMap)
@@ -255,7 +504,7 @@ func (postMgr *PostManager) handleResponseStatusNotFound(responseMap map[string]
 	return true
 }
 
-func (postMgr *PostManager) handleResponseOthers(responseMap map[string]interface{}, cfg config) bool {
+func (postMgr *PostManager) handleResponseOthers(responseMap map[string]interface{}, cfg config, dev *bigIPDevice) bool {
 	if results, ok := (responseMap["results"]).([]interface{}); ok {
 		for _, value := range results {
 			v := value.(map[string]interface{})
@@ -271,12 +520,193 @@ func (postMgr *PostManager) handleResponseOthers(responseMap map[string]interfac
 	if postMgr.LogResponse {
 		log.Errorf("[AS3] Raw response from Big-IP: %v ", responseMap)
 	}
-	return postMgr.postOnEventOrTimeout(timeoutMedium, cfg)
+	// Tenants are deployed independently, the ones without errors are accepted
+	postMgr.updateAcceptedTenants(cfg, dev, getAcceptedTenants(responseMap))
+	postMgr.notifyResponse(cfg, dev, false, getResponseMessage(responseMap), rsc.ParseAS3Failures(responseMap))
+	return false
+}
+
+// getAcceptedTenants returns the Tenants deployed without errors in the AS3 response
+func getAcceptedTenants(responseMap map[string]interface{}) []string {
+	var tenants []string
+	results, _ := (responseMap["results"]).([]interface{})
+	for _, value := range results {
+		v, ok := value.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		tenant, _ := v["tenant"].(string)
+		if code, ok := v["code"].(float64); ok && int(code) == http.StatusOK && tenant != "" {
+			tenants = append(tenants, tenant)
+		}
+	}
+	return tenants
 }
 
-// GetBigipAS3Version ...
+// updateAcceptedTenants caches the declarations of the Tenants accepted by the BIG-IP
+func (postMgr *PostManager) updateAcceptedTenants(cfg config, dev *bigIPDevice, tenants []string) {
+	if len(tenants) == 0 {
+		return
+	}
+	var as3Config map[string]interface{}
+	if err := json.Unmarshal([]byte(cfg.data), &as3Config); err != nil {
+		log.Errorf("[AS3] Unable to parse the posted declaration: %v", err)
+		return
+	}
+	adc, _ := as3Config["declaration"].(map[string]interface{})
+
+	postMgr.tenantsMutex.Lock()
+	defer postMgr.tenantsMutex.Unlock()
+	if dev.tenantDecls == nil {
+		dev.tenantDecls = make(map[string]string)
+	}
+	for _, tenant := range tenants {
+		if tenantDecl, ok := adc[tenant]; ok {
+			dev.tenantDecls[tenant] = canonicalJSON(tenantDecl)
+		}
+	}
+}
+
+// getChangedTenants returns the Tenants whose declaration differs from the one last accepted
+// by the BIG-IPs, the active BIG-IP or each of the BIG-IPs depending on the multi BIG-IP mode
+func (postMgr *PostManager) getChangedTenants(adc as3ADC) []string {
+	devices := postMgr.getDevices()
+	if postMgr.MultiBIGIPMode != MultiBIGIPModeAll && len(devices) > 1 {
+		activeDevice := postMgr.getActiveDeviceIndex()
+		devices = devices[activeDevice : activeDevice+1]
+	}
+
+	postMgr.tenantsMutex.Lock()
+	defer postMgr.tenantsMutex.Unlock()
+	var tenants []string
+	for tenant, tenantDecl := range adc {
+		decl := canonicalJSON(tenantDecl)
+		for _, dev := range devices {
+			if dev.tenantDecls[tenant] != decl {
+				tenants = append(tenants, tenant)
+				break
+			}
+		}
+	}
+	sort.Strings(tenants)
+	return tenants
+}
+
+// canonicalJSON returns the JSON of the object with the keys sorted, to compare declarations
+func canonicalJSON(obj interface{}) string {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return ""
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return ""
+	}
+	data, _ = json.Marshal(generic)
+	return string(data)
+}
+
+// getResponseMessage returns the failure reported by BIG-IP in the AS3 response
+func getResponseMessage(responseMap map[string]interface{}) string {
+	var messages []string
+	if results, ok := (responseMap["results"]).([]interface{}); ok {
+		for _, value := range results {
+			v, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if code, ok := v["code"].(float64); ok && int(code) == http.StatusOK {
+				continue
+			}
+			messages = append(messages, fmt.Sprintf("code: %v tenant: %v message: %v", v["code"], v["tenant"], v["message"]))
+		}
+	}
+	if len(messages) > 0 {
+		return strings.Join(messages, "; ")
+	}
+	if err, ok := (responseMap["error"]).(map[string]interface{}); ok {
+		return fmt.Sprintf("BIG-IP responded with error code: %v", err["code"])
+	}
+	return fmt.Sprintf("BIG-IP responded with code: %v", responseMap["code"])
+}
+
+// notifyResponse publishes the response of BIG-IP for the custom resources in the posted configuration
+func (postMgr *PostManager) notifyResponse(
+	cfg config,
+	dev *bigIPDevice,
+	accepted bool,
+	message string,
+	failures []rsc.AS3Failure,
+) {
+	dev.postedData = cfg.data
+	dev.accepted = accepted
+	dev.message = message
+	dev.failures = failures
+	if postMgr.respChan == nil || len(cfg.reqMeta.resources) == 0 {
+		return
+	}
+	accepted, message, failures = postMgr.getDevicesResponse(cfg, dev)
+	rscStatus := resourceStatusMeta{
+		accepted:  accepted,
+		message:   message,
+		resources: cfg.reqMeta.resources,
+		failures:  getResourceFailures(cfg.reqMeta, failures),
+	}
+	// Retries of a declaration get the same response, which is already published
+	if reflect.DeepEqual(postMgr.lastRespStatus, rscStatus) {
+		return
+	}
+	postMgr.lastRespStatus = rscStatus
+	// Never block posting on the response handler, a response not yet handled is replaced by the latest one
+	select {
+	case postMgr.respChan <- rscStatus:
+	case <-postMgr.respChan:
+		postMgr.respChan <- rscStatus
+	}
+}
+
+// getDevicesResponse combines the responses of the BIG-IPs to the declaration.
+// With the declaration posted to all the BIG-IPs, it is accepted only when each of them accepts it.
+func (postMgr *PostManager) getDevicesResponse(cfg config, dev *bigIPDevice) (bool, string, []rsc.AS3Failure) {
+	if len(postMgr.devices) <= 1 {
+		return dev.accepted, dev.message, dev.failures
+	}
+	devices := []*bigIPDevice{dev}
+	if postMgr.MultiBIGIPMode == MultiBIGIPModeAll {
+		devices = postMgr.devices
+	}
+	accepted := true
+	var messages []string
+	var failures []rsc.AS3Failure
+	for _, d := range devices {
+		// BIG-IPs yet to respond to the declaration
+		if d.postedData != cfg.data {
+			continue
+		}
+		accepted = accepted && d.accepted
+		if d.message != "" {
+			messages = append(messages, fmt.Sprintf("BIG-IP %v: %v", d.url, d.message))
+		}
+		failures = append(failures, d.failures...)
+	}
+	return accepted, strings.Join(messages, "; "), failures
+}
+
+// GetBigipAS3Version verifies AS3 is available on each of the BIG-IPs
 func (postMgr *PostManager) GetBigipAS3Version() error {
-	url := postMgr.getAS3VersionURL()
+	for _, dev := range postMgr.getDevices() {
+		if err := postMgr.getBigipAS3Version(dev.url); err != nil {
+			if len(postMgr.devices) > 1 {
+				return fmt.Errorf("BIG-IP %v: %v", dev.url, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (postMgr *PostManager) getBigipAS3Version(bigipURL string) error {
+	url := postMgr.getAS3VersionURL(bigipURL)
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		log.Errorf("Creating new HTTP request error: %v ", err)
@@ -284,7 +714,7 @@ func (postMgr *PostManager) GetBigipAS3Version() error {
 	}
 
 	log.Infof("Posting GET BIGIP AS3 Version request on %v", url)
-	req.SetBasicAuth(postMgr.BIGIPUsername, postMgr.BIGIPPassword)
+	postMgr.setBasicAuth(req)
 
 	httpResp, responseMap := postMgr.httpReq(req)
 	if httpResp == nil || responseMap == nil {
@@ -309,7 +739,7 @@ func (postMgr *PostManager) GetBigipAS3Version() error {
 }
 
 func (postMgr *PostManager) httpReq(request *http.Request) (*http.Response, map[string]interface{}) {
-	httpResp, err := postMgr.httpClient.Do(request)
+	httpResp, err := postMgr.doRequest(request)
 	if err != nil {
 		log.Errorf("REST call error: %v ", err)
 		return nil, nil
@@ -333,8 +763,70 @@ func (postMgr *PostManager) httpReq(request *http.Request) (*http.Response, map[
 	return httpResp, response
 }
 
-func (postMgr *PostManager) getAS3VersionURL() string {
-	apiURL := postMgr.BIGIPURL + "/mgmt/shared/appsvcs/info"
+func (postMgr *PostManager) getAS3VersionURL(bigipURL string) string {
+	apiURL := bigipURL + "/mgmt/shared/appsvcs/info"
 	return apiURL
 
 }
+
+// getFailoverStatus returns the failover status of the BIG-IP, like ACTIVE or STANDBY
+func (postMgr *PostManager) getFailoverStatus(bigipURL string) (string, error) {
+	req, err := http.NewRequest("GET", bigipURL+"/mgmt/tm/cm/failover-status", nil)
+	if err != nil {
+		return "", err
+	}
+	postMgr.setBasicAuth(req)
+
+	httpResp, responseMap := postMgr.httpReq(req)
+	if httpResp == nil || responseMap == nil {
+		return "", fmt.Errorf("Internal Error")
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("Error response from BIGIP with status code %v", httpResp.StatusCode)
+	}
+	// {"entries":{"<selfLink>":{"nestedStats":{"entries":{"status":{"description":"ACTIVE"}}}}}}
+	entries, _ := responseMap["entries"].(map[string]interface{})
+	for _, entry := range entries {
+		stats, _ := entry.(map[string]interface{})
+		nestedStats, _ := stats["nestedStats"].(map[string]interface{})
+		nestedEntries, _ := nestedStats["entries"].(map[string]interface{})
+		status, _ := nestedEntries["status"].(map[string]interface{})
+		if desc, ok := status["description"].(string); ok {
+			return desc, nil
+		}
+	}
+	return "", fmt.Errorf("Failover status not found in the response from BIGIP")
+}
+
+// getResourceFailures maps the AS3 failures to the custom resources producing the failed objects
+// A failure without any known object is reported on all the custom resources of the tenant
+func getResourceFailures(reqMeta requestMeta, failures []rsc.AS3Failure) map[string]string {
+	if len(failures) == 0 {
+		return nil
+	}
+	rscFailures := make(map[string]string)
+	addFailure := func(key, message string) {
+		if msg, ok := rscFailures[key]; ok {
+			if strings.Contains(msg, message) {
+				return
+			}
+			message = msg + "; " + message
+		}
+		rscFailures[key] = message
+	}
+	for _, failure := range failures {
+		found := false
+		for _, obj := range failure.Objects {
+			for _, key := range reqMeta.objects[obj] {
+				addFailure(key, failure.Message)
+				found = true
+			}
+		}
+		if !found {
+			for key := range reqMeta.resources {
+				addFailure(key, failure.Message)
+			}
+		}
+	}
+	return rscFailures
+}
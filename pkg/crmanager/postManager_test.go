@@ -1,11 +1,30 @@
 package crmanager
 
 import (
+	"bytes"
+	"fmt"
+	mockhc "github.com/f5devcentral/mockhttpclient"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"io/ioutil"
 	"net/http"
+
+	"github.com/F5Networks/k8s-bigip-ctlr/pkg/tokenmanager"
 )
 
+func newMockResponse(statusCode int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: statusCode,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+	}
+}
+
+func failoverStatusBody(status string) string {
+	return fmt.Sprintf(`{"entries":{"https://localhost/mgmt/tm/cm/failover-status/0":`+
+		`{"nestedStats":{"entries":{"status":{"description":"%v"}}}}}}`, status)
+}
+
 var _ = Describe("PostManager Tests", func() {
 	var mockPM *mockPostManager
 
@@ -18,41 +37,68 @@ var _ = Describe("PostManager Tests", func() {
 		mockPM.setupBIGIPRESTClient()
 	})
 
+	It("Updates the credentials in place", func() {
+		mockPM.setupBIGIPRESTClient()
+		httpClient := mockPM.httpClient
+		mockPM.updateCredentials("user", "pswd", "", false)
+		Expect(mockPM.BIGIPUsername).To(Equal("user"))
+		Expect(mockPM.BIGIPPassword).To(Equal("pswd"))
+		Expect(mockPM.httpClient).To(BeIdenticalTo(httpClient), "HTTP client should not be rebuilt")
+
+		mockPM.updateCredentials("user", "rotated", "", true)
+		Expect(mockPM.BIGIPPassword).To(Equal("rotated"))
+		Expect(mockPM.httpClient).NotTo(BeIdenticalTo(httpClient), "HTTP client should be rebuilt")
+
+		req, _ := http.NewRequest("GET", "https://bigip.com", nil)
+		mockPM.setBasicAuth(req)
+		username, password, _ := req.BasicAuth()
+		Expect(username).To(Equal("user"))
+		Expect(password).To(Equal("rotated"))
+	})
+
 	It("Wirte Config", func() {
 		mockPM.BIGIPURL = "bigip.com"
-		mockPM.Write("", []string{"test"})
-		mockPM.Write("{}", []string{"test"})
+		mockPM.setupBIGIPDevices()
+		mockPM.Write("", []string{"test"}, requestMeta{})
+		mockPM.Write("{}", []string{"test"}, requestMeta{})
 	})
 
 	Describe("Post Config and Handle Response", func() {
 		BeforeEach(func() {
 			mockPM.BIGIPURL = "bigip.com"
+			mockPM.setupBIGIPDevices()
 			mockPM.BIGIPUsername = "user"
 			mockPM.BIGIPPassword = "pswd"
-			mockPM.Write("{}", []string{"test"})
+			mockPM.Write("{}", []string{"test"}, requestMeta{})
 
 		})
 		It("Handle HTTP StatusOK", func() {
 			mockPM.setResponses([]int{http.StatusOK}, "", http.MethodPost)
-			ok := mockPM.postOnEventOrTimeout(0, config{})
+			_, ok := mockPM.postOnEventOrTimeout(0, config{})
 			Expect(ok).To(BeTrue(), "Posting Failed")
 		})
 
 		It("Handle HTTP StatusServiceUnavailable", func() {
 			mockPM.setResponses([]int{http.StatusServiceUnavailable, http.StatusOK}, "", http.MethodPost)
-			ok := mockPM.postOnEventOrTimeout(0, config{})
+			_, ok := mockPM.postOnEventOrTimeout(0, config{})
+			Expect(ok).To(BeFalse(), "Busy BIG-IP should be retried")
+			Expect(mockPM.retryTimeout).To(Equal(timeoutSmall))
+			_, ok = mockPM.postOnEventOrTimeout(0, config{})
 			Expect(ok).To(BeTrue(), "Posting Failed")
 		})
 
 		It("Handle HTTP StatusNotFound", func() {
 			mockPM.setResponses([]int{http.StatusNotFound}, "", http.MethodPost)
-			ok := mockPM.postOnEventOrTimeout(0, config{})
+			_, ok := mockPM.postOnEventOrTimeout(0, config{})
 			Expect(ok).To(BeTrue(), "Posting Failed")
 		})
 
 		It("Handle HTTP StatusTimeout", func() {
 			mockPM.setResponses([]int{http.StatusRequestTimeout, http.StatusOK}, "", http.MethodPost)
-			ok := mockPM.postOnEventOrTimeout(0, config{})
+			_, ok := mockPM.postOnEventOrTimeout(0, config{})
+			Expect(ok).To(BeFalse(), "Failed declaration should be retried")
+			Expect(mockPM.retryTimeout).To(Equal(timeoutMedium))
+			_, ok = mockPM.postOnEventOrTimeout(0, config{})
 			Expect(ok).To(BeTrue(), "Posting Failed")
 		})
 	})
@@ -60,6 +106,7 @@ var _ = Describe("PostManager Tests", func() {
 	Describe("BIGIP AS3 Version", func() {
 		BeforeEach(func() {
 			mockPM.BIGIPURL = "bigip.com"
+			mockPM.setupBIGIPDevices()
 			mockPM.BIGIPUsername = "user"
 			mockPM.BIGIPPassword = "pswd"
 		})
@@ -88,4 +135,205 @@ var _ = Describe("PostManager Tests", func() {
 			Expect(err).NotTo(BeNil(), "Failed Validation while get BIG-IP AS3 Version")
 		})
 	})
+
+	Describe("Multiple BIG-IPs", func() {
+		var cfg config
+
+		BeforeEach(func() {
+			mockPM.BIGIPURLs = []string{"https://bigip1.com", "https://bigip2.com"}
+			mockPM.setupBIGIPDevices()
+			mockPM.BIGIPUsername = "user"
+			mockPM.BIGIPPassword = "pswd"
+			mockPM.respChan = make(chan resourceStatusMeta, 1)
+			cfg = config{
+				data:    "{}",
+				tenants: []string{"test"},
+				reqMeta: requestMeta{
+					resources: map[string]string{"default/vs": VirtualServer},
+				},
+			}
+		})
+
+		It("Gets the failover status of BIG-IP", func() {
+			mockPM.setResponses([]int{http.StatusOK}, failoverStatusBody("STANDBY"), http.MethodGet)
+			status, err := mockPM.getFailoverStatus("https://bigip1.com")
+			Expect(err).To(BeNil())
+			Expect(status).To(Equal("STANDBY"))
+
+			mockPM.setResponses([]int{http.StatusOK}, `{"entries":{}}`, http.MethodGet)
+			_, err = mockPM.getFailoverStatus("https://bigip1.com")
+			Expect(err).NotTo(BeNil(), "Failover status should not be found")
+
+			mockPM.setResponses([]int{http.StatusUnauthorized}, `{"code":401}`, http.MethodGet)
+			_, err = mockPM.getFailoverStatus("https://bigip1.com")
+			Expect(err).NotTo(BeNil(), "Failover status should not be found")
+		})
+
+		It("Posts only to the active BIG-IP", func() {
+			mockPM.MultiBIGIPMode = MultiBIGIPModeActive
+			mockPM.httpClient, _ = mockhc.NewMockHTTPClient(mockhc.ResponseConfigMap{
+				http.MethodGet: &mockhc.ResponseConfig{Responses: []*http.Response{
+					newMockResponse(http.StatusOK, failoverStatusBody("STANDBY")),
+					newMockResponse(http.StatusOK, failoverStatusBody(failoverStatusActive)),
+				}},
+				http.MethodPost: &mockhc.ResponseConfig{Responses: []*http.Response{
+					newMockResponse(http.StatusOK, `{"results":[{"code":200,"message":"success"}]}`),
+				}},
+			})
+			Expect(mockPM.postConfig(cfg)).To(BeTrue())
+			Expect(mockPM.getActiveDeviceIndex()).To(Equal(1))
+			Expect(mockPM.devices[0].postedData).To(BeEmpty(), "Standby BIG-IP should not be posted to")
+			Expect(mockPM.devices[1].accepted).To(BeTrue())
+			rscStatus := <-mockPM.respChan
+			Expect(rscStatus.accepted).To(BeTrue())
+		})
+
+		It("Does not post without an active BIG-IP", func() {
+			mockPM.MultiBIGIPMode = MultiBIGIPModeActive
+			mockPM.setResponses([]int{http.StatusOK, http.StatusOK}, failoverStatusBody("STANDBY"), http.MethodGet)
+			Expect(mockPM.postConfig(cfg)).To(BeFalse())
+			Expect(mockPM.devices[0].postedData).To(BeEmpty())
+			Expect(mockPM.devices[1].postedData).To(BeEmpty())
+		})
+
+		It("Posts to all the BIG-IPs and tracks the response of each", func() {
+			mockPM.MultiBIGIPMode = MultiBIGIPModeAll
+			mockPM.httpClient, _ = mockhc.NewMockHTTPClient(mockhc.ResponseConfigMap{
+				http.MethodPost: &mockhc.ResponseConfig{Responses: []*http.Response{
+					newMockResponse(http.StatusOK, `{"results":[{"code":200,"message":"success"}]}`),
+					newMockResponse(http.StatusNotFound, `{"code":404,"message":"AS3 not installed"}`),
+				}},
+			})
+			Expect(mockPM.postConfig(cfg)).To(BeTrue())
+			Expect(mockPM.devices[0].accepted).To(BeTrue())
+			Expect(mockPM.devices[1].accepted).To(BeFalse())
+			Expect(mockPM.devices[1].postedData).To(Equal(cfg.data))
+
+			// Declaration is rejected when any of the BIG-IPs rejects it
+			var rscStatus resourceStatusMeta
+			Eventually(mockPM.respChan).Should(Receive(&rscStatus))
+			Expect(rscStatus.accepted).To(BeFalse())
+			Expect(rscStatus.message).To(ContainSubstring("BIG-IP https://bigip2.com"))
+			Expect(rscStatus.message).NotTo(ContainSubstring("BIG-IP https://bigip1.com"))
+		})
+
+		It("Retries only the BIG-IPs which failed the declaration", func() {
+			mockPM.MultiBIGIPMode = MultiBIGIPModeAll
+			mockPM.respChan = nil
+			failedBody := `{"results":[{"code":422,"tenant":"test","message":"declaration failed"}]}`
+			mockPM.httpClient, _ = mockhc.NewMockHTTPClient(mockhc.ResponseConfigMap{
+				http.MethodPost: &mockhc.ResponseConfig{Responses: []*http.Response{
+					newMockResponse(http.StatusUnprocessableEntity, failedBody),
+					newMockResponse(http.StatusOK, `{"results":[{"code":200,"message":"success"}]}`),
+					newMockResponse(http.StatusUnprocessableEntity, failedBody),
+				}},
+			})
+			Expect(mockPM.postConfig(cfg)).To(BeFalse())
+			Expect(mockPM.devices[0].accepted).To(BeFalse())
+			Expect(mockPM.devices[1].accepted).To(BeTrue(), "Declaration not posted to the second BIG-IP")
+			Expect(mockPM.failedDevices).To(Equal([]*bigIPDevice{mockPM.devices[0]}))
+
+			// Only the first BIG-IP is posted to again, which still rejects the declaration
+			Expect(mockPM.retryConfig(cfg)).To(BeFalse())
+			Expect(mockPM.failedDevices).To(Equal([]*bigIPDevice{mockPM.devices[0]}))
+			Expect(mockPM.devices[1].accepted).To(BeTrue())
+		})
+
+		It("Gets the AS3 version of all the BIG-IPs", func() {
+			mockPM.httpClient, _ = mockhc.NewMockHTTPClient(mockhc.ResponseConfigMap{
+				http.MethodGet: &mockhc.ResponseConfig{Responses: []*http.Response{
+					newMockResponse(http.StatusOK, `{"version":"v1", "release":"r1"}`),
+					newMockResponse(http.StatusNotFound, `{"code":404}`),
+				}},
+			})
+			err := mockPM.GetBigipAS3Version()
+			Expect(err).NotTo(BeNil())
+			Expect(err.Error()).To(ContainSubstring("https://bigip2.com"))
+		})
+	})
+
+	It("Posts with an auth token", func() {
+		mockPM.BIGIPURL = "https://bigip.com"
+		mockPM.setupBIGIPDevices()
+		mockPM.tokenManager = tokenmanager.NewTokenManager("user", "pswd", "radius")
+		okBody := `{"results":[{"code":200,"message":"success"}]}`
+		mockPM.httpClient, _ = mockhc.NewMockHTTPClient(mockhc.ResponseConfigMap{
+			http.MethodPost: &mockhc.ResponseConfig{Responses: []*http.Response{
+				newMockResponse(http.StatusOK, `{"token":{"token":"token1","timeout":1200}}`),
+				newMockResponse(http.StatusOK, okBody),
+				newMockResponse(http.StatusOK, okBody),
+			}},
+		})
+		Expect(mockPM.postConfig(config{data: `{"id":1}`})).To(BeTrue())
+		Expect(mockPM.postConfig(config{data: `{"id":2}`})).To(BeTrue(), "Auth token should be reused")
+
+		req, _ := http.NewRequest("GET", "https://bigip.com", nil)
+		mockPM.setBasicAuth(req)
+		_, _, ok := req.BasicAuth()
+		Expect(ok).To(BeFalse(), "Basic auth should not be used with auth tokens")
+	})
+
+	Describe("Tenant declarations", func() {
+		var adc as3ADC
+
+		BeforeEach(func() {
+			mockPM.BIGIPURL = "https://bigip.com"
+			mockPM.setupBIGIPDevices()
+			mockPM.BIGIPUsername = "user"
+			mockPM.BIGIPPassword = "pswd"
+			adc = as3ADC{
+				"tenant1": as3Tenant{"class": "Tenant", "label": "one"},
+				"tenant2": as3Tenant{"class": "Tenant", "label": "two"},
+			}
+		})
+
+		It("Caches the Tenants accepted by BIG-IP", func() {
+			Expect(mockPM.getChangedTenants(adc)).To(Equal([]string{"tenant1", "tenant2"}))
+
+			mockPM.setResponses([]int{http.StatusOK}, "", http.MethodPost)
+			cfg := config{
+				data:    string(createTenantDeclaration(adc, "as3")),
+				tenants: []string{"tenant1", "tenant2"},
+			}
+			Expect(mockPM.postConfig(cfg)).To(BeTrue())
+			Expect(mockPM.getChangedTenants(adc)).To(BeEmpty(), "Accepted Tenants should not be changed")
+
+			adc["tenant2"] = as3Tenant{"class": "Tenant", "label": "changed"}
+			Expect(mockPM.getChangedTenants(adc)).To(Equal([]string{"tenant2"}))
+		})
+
+		It("Caches only the Tenants deployed without errors", func() {
+			mockPM.httpClient, _ = mockhc.NewMockHTTPClient(mockhc.ResponseConfigMap{
+				http.MethodPost: &mockhc.ResponseConfig{Responses: []*http.Response{
+					newMockResponse(http.StatusUnprocessableEntity, `{"results":[`+
+						`{"code":200,"tenant":"tenant1","message":"success"},`+
+						`{"code":422,"tenant":"tenant2","message":"declaration failed"}]}`),
+				}},
+			})
+			cfg := config{
+				data:    string(createTenantDeclaration(adc, "as3")),
+				tenants: []string{"tenant1", "tenant2"},
+			}
+			mockPM.postConfig(cfg)
+			Expect(mockPM.getChangedTenants(adc)).To(Equal([]string{"tenant2"}),
+				"Rejected Tenant should be posted again")
+		})
+
+		It("Gets the changed Tenants while the active BIG-IP is updated", func() {
+			mockPM.BIGIPURLs = []string{"https://bigip1.com", "https://bigip2.com"}
+			mockPM.setupBIGIPDevices()
+			mockPM.MultiBIGIPMode = MultiBIGIPModeActive
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				for i := 0; i < 100; i++ {
+					mockPM.setActiveDeviceIndex(i % 2)
+				}
+			}()
+			for i := 0; i < 100; i++ {
+				Expect(mockPM.getChangedTenants(adc)).To(Equal([]string{"tenant1", "tenant2"}))
+			}
+			<-done
+		})
+	})
 })
@@ -2,6 +2,8 @@ package crmanager
 
 import (
 	"fmt"
+	"strings"
+
 	v1 "k8s.io/api/core/v1"
 )
 
@@ -9,6 +11,10 @@ import (
 func (crMgr *CRManager) createSecretClientSSLProfile(
 	rsCfg *ResourceConfig,
 	secret *v1.Secret,
+	serverName string,
+	sniDefault bool,
+	peerCertMode,
+	caFile,
 	context string,
 ) (error, bool) {
 
@@ -43,10 +49,9 @@ func (crMgr *CRManager) createSecretClientSSLProfile(
 	// TODO
 	//rsCfg.Virtual.AddOrUpdateProfile(sni)
 
-	// Now add the resource profile
+	// Now add the resource profile, profiles from secrets have no BIG-IP partition
 	profRef := ProfileRef{
-		Name:      secret.ObjectMeta.Name,
-		Partition: rsCfg.Virtual.Partition,
+		Name:      formatSNICertificateName(secret.ObjectMeta.Name, serverName),
 		Context:   context,
 		Namespace: secret.ObjectMeta.Namespace,
 	}
@@ -54,10 +59,10 @@ func (crMgr *CRManager) createSecretClientSSLProfile(
 		profRef,
 		string(secret.Data["tls.crt"]),
 		string(secret.Data["tls.key"]),
-		"",    // serverName
-		false, // sni
-		"",    // peerCertMode
-		"",    // caFile
+		serverName,
+		sniDefault,
+		peerCertMode,
+		caFile,
 	)
 	skey = SecretKey{
 		Name:         cp.Name,
@@ -109,10 +114,9 @@ func (crMgr *CRManager) createSecretServerSSLProfile(
 	// TODO
 	//rsCfg.Virtual.AddOrUpdateProfile(sni)
 
-	// Now add the resource profile
+	// Now add the resource profile, profiles from secrets have no BIG-IP partition
 	profRef := ProfileRef{
 		Name:      secret.ObjectMeta.Name,
-		Partition: rsCfg.Virtual.Partition,
 		Context:   context,
 		Namespace: secret.ObjectMeta.Namespace,
 	}
@@ -142,3 +146,12 @@ func (crMgr *CRManager) createSecretServerSSLProfile(
 	rsCfg.Virtual.AddOrUpdateProfile(profRef)
 	return nil, false
 }
+
+// formatSNICertificateName returns the name of the certificate of the secret
+// selected for the server name, the default certificate is named after the secret
+func formatSNICertificateName(secretName, serverName string) string {
+	if serverName == "" {
+		return secretName
+	}
+	return fmt.Sprintf("%s-%s", secretName, strings.Replace(serverName, "*", "wildcard", -1))
+}
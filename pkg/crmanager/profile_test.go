@@ -44,27 +44,27 @@ var _ = Describe("Profile", func() {
 		secret.Data["tls.key"] = []byte("fawiueh9wuan;kasjf;")
 		secret.Data["tls.crt"] = []byte("ahfa;osejfn;kahse;ha")
 
-		err, updated := mockCRM.createSecretClientSSLProfile(rsCfg, secret, "clientside")
+		err, updated := mockCRM.createSecretClientSSLProfile(rsCfg, secret, "", false, "", "", "clientside")
 		Expect(err).To(BeNil(), "Failed to Create Client SSL")
 		Expect(updated).To(BeFalse(), "Failed to Create Client SSL")
 
-		err, updated = mockCRM.createSecretClientSSLProfile(rsCfg, secret, "clientside")
+		err, updated = mockCRM.createSecretClientSSLProfile(rsCfg, secret, "", false, "", "", "clientside")
 		Expect(err).To(BeNil(), "Failed to Create Client SSL")
 		Expect(updated).To(BeFalse(), "Failed to Create Client SSL")
 
 		secret.Data["tls.crt"] = []byte("dfaf")
-		err, updated = mockCRM.createSecretClientSSLProfile(rsCfg, secret, "clientside")
+		err, updated = mockCRM.createSecretClientSSLProfile(rsCfg, secret, "", false, "", "", "clientside")
 		Expect(err).To(BeNil(), "Failed to Update Client SSL")
 		Expect(updated).To(BeTrue(), "Failed to Update Client SSL")
 
 		// Negative Cases
 		delete(secret.Data, "tls.crt")
-		err, updated = mockCRM.createSecretClientSSLProfile(rsCfg, secret, "clientside")
+		err, updated = mockCRM.createSecretClientSSLProfile(rsCfg, secret, "", false, "", "", "clientside")
 		Expect(err).ToNot(BeNil(), "Failed to Validate Client SSL")
 		Expect(updated).To(BeFalse(), "Failed to Validate Client SSL")
 
 		delete(secret.Data, "tls.key")
-		err, updated = mockCRM.createSecretClientSSLProfile(rsCfg, secret, "clientside")
+		err, updated = mockCRM.createSecretClientSSLProfile(rsCfg, secret, "", false, "", "", "clientside")
 		Expect(err).ToNot(BeNil(), "Failed to Validate Client SSL")
 		Expect(updated).To(BeFalse(), "Failed to Validate Client SSL")
 
@@ -50,6 +50,11 @@ func initializeDriverConfig(
 		sections["gtm_bigip"] = gtm
 	}
 
+	return writeDriverSections(configWriter, sections)
+}
+
+// writeDriverSections writes the sections of the python driver configuration
+func writeDriverSections(configWriter writer.Writer, sections map[string]interface{}) error {
 	for k, v := range sections {
 		doneCh, errCh, err := configWriter.SendSection(k, v)
 		if nil != err {
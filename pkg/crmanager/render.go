@@ -0,0 +1,174 @@
+/*-
+ * Copyright (c) 2016-2021, F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package crmanager
+
+import (
+	"fmt"
+
+	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/config/apis/cis/v1"
+	crdfake "github.com/F5Networks/k8s-bigip-ctlr/config/client/clientset/versioned/fake"
+	apm "github.com/F5Networks/k8s-bigip-ctlr/pkg/appmanager"
+	"github.com/F5Networks/k8s-bigip-ctlr/pkg/teem"
+	log "github.com/F5Networks/k8s-bigip-ctlr/pkg/vlogger"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+// RenderDeclaration processes the Kubernetes objects with the Custom Resource
+// Manager, without a cluster or a BIG-IP, and returns the AS3 declaration
+// that would be posted for them.
+// Custom Resources are read from fake clientsets seeded with the objects, so the
+// declaration is the same one CIS generates for the objects in a cluster.
+// Pool members are created for the nodes given as Node objects.
+func RenderDeclaration(params Params, userAgent string, objs []runtime.Object) (string, error) {
+	DEFAULT_PARTITION = params.Partition
+
+	var crObjs, k8sObjs []runtime.Object
+	var nodes []v1.Node
+	for _, obj := range objs {
+		switch rsc := obj.(type) {
+		case *cisapiv1.VirtualServer, *cisapiv1.TLSProfile, *cisapiv1.TransportServer,
+			*cisapiv1.IngressLink:
+			crObjs = append(crObjs, obj)
+		case *v1.Node:
+			nodes = append(nodes, *rsc)
+			k8sObjs = append(k8sObjs, obj)
+		case *v1.Service, *v1.Endpoints, *v1.Secret:
+			k8sObjs = append(k8sObjs, obj)
+		}
+	}
+
+	crMgr := &CRManager{
+		namespaces:         make(map[string]bool),
+		crInformers:        make(map[string]*CRInformer),
+		resources:          NewResources(),
+		Partition:          params.Partition,
+		ControllerMode:     params.ControllerMode,
+		UseNodeInternal:    params.UseNodeInternal,
+		initState:          true,
+		shareNodes:         params.ShareNodes,
+		eventNotifier:      apm.NewEventNotifier(nil),
+		defaultRouteDomain: params.DefaultRouteDomain,
+		kubeCRClient:       crdfake.NewSimpleClientset(crObjs...),
+		kubeClient:         k8sfake.NewSimpleClientset(k8sObjs...),
+		TeemData: &teem.TeemsData{
+			ResourceType: teem.ResourceTypes{
+				VirtualServer:   make(map[string]int),
+				TransportServer: make(map[string]int),
+				ExternalDNS:     make(map[string]int),
+				IngressLink:     make(map[string]int),
+				IPAMVS:          make(map[string]int),
+				IPAMTS:          make(map[string]int),
+				IPAMSvcLB:       make(map[string]int),
+			},
+		},
+	}
+	crMgr.resourceSelector, _ = createLabelSelector(DefaultCustomResourceLabel)
+
+	namespaces := params.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{""}
+	}
+	for _, ns := range namespaces {
+		crMgr.namespaces[ns] = true
+		if err := crMgr.addNamespacedInformer(ns); err != nil {
+			return "", err
+		}
+	}
+
+	// Informers are not started, the objects are added to their stores the
+	// same way the informers filter them in a cluster
+	for _, obj := range objs {
+		if err := crMgr.addRenderObject(obj); err != nil {
+			return "", err
+		}
+	}
+
+	crMgr.ProcessNodeUpdate(nodes, nil)
+
+	for _, ns := range namespaces {
+		for _, vs := range crMgr.getAllVirtualServers(ns) {
+			if err := crMgr.processVirtualServers(vs, false); err != nil {
+				return "", err
+			}
+		}
+		for _, ts := range crMgr.getAllTransportServers(ns) {
+			if err := crMgr.processTransportServers(ts, false); err != nil {
+				return "", err
+			}
+		}
+		for _, il := range crMgr.getAllIngressLinks(ns) {
+			if err := crMgr.processIngressLink(il, false); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return string(createAS3Declaration(crMgr.getResourceConfigWrapper(), userAgent)), nil
+}
+
+// addRenderObject adds the object to the store of its informer
+func (crMgr *CRManager) addRenderObject(obj runtime.Object) error {
+	metaObj, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	key := metaObj.GetNamespace() + "/" + metaObj.GetName()
+	crInf, ok := crMgr.getNamespacedInformer(metaObj.GetNamespace())
+	if !ok {
+		log.Debugf("Skipping %v from a namespace not in CIS scope", key)
+		return nil
+	}
+
+	var store cache.Store
+	isCustomResource := true
+	switch obj.(type) {
+	case *cisapiv1.VirtualServer:
+		store = crInf.vsInformer.GetStore()
+	case *cisapiv1.TLSProfile:
+		store = crInf.tlsInformer.GetStore()
+	case *cisapiv1.TransportServer:
+		store = crInf.tsInformer.GetStore()
+	case *cisapiv1.IngressLink:
+		// IngressLinks are watched irrespective of the labels
+		store = crInf.ilInformer.GetStore()
+		isCustomResource = false
+	case *v1.Service:
+		store = crInf.svcInformer.GetStore()
+		isCustomResource = false
+	case *v1.Endpoints:
+		store = crInf.epsInformer.GetStore()
+		isCustomResource = false
+	case *v1.Secret:
+		store = crInf.secretInformer.GetStore()
+		isCustomResource = false
+	default:
+		return nil
+	}
+	if isCustomResource && !crMgr.resourceSelector.Matches(labels.Set(metaObj.GetLabels())) {
+		log.Warningf("Skipping %v without the label %v", key, DefaultCustomResourceLabel)
+		return nil
+	}
+	if err := store.Add(obj); err != nil {
+		return fmt.Errorf("failed to add %v: %v", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package crmanager
+
+import (
+	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/config/apis/cis/v1"
+	"github.com/F5Networks/k8s-bigip-ctlr/pkg/test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+var _ = Describe("Render Tests", func() {
+	var vs *cisapiv1.VirtualServer
+	var objs []runtime.Object
+	namespace := "default"
+	params := Params{
+		Partition:      "test",
+		ControllerMode: "cluster",
+	}
+
+	BeforeEach(func() {
+		vs = test.NewVirtualServer(
+			"SampleVS",
+			namespace,
+			cisapiv1.VirtualServerSpec{
+				Host:                 "test.com",
+				VirtualServerAddress: "1.2.3.4",
+				Pools: []cisapiv1.Pool{
+					{
+						Path:        "/path",
+						Service:     "svc1",
+						ServicePort: 80,
+					},
+				},
+			},
+		)
+		vs.Labels = map[string]string{"f5cr": "true"}
+		svc := test.NewService("svc1", "1", namespace, v1.ServiceTypeClusterIP,
+			[]v1.ServicePort{{Name: "http", Port: 80}})
+		eps := test.NewEndpoints("svc1", "1", "node1", namespace, []string{"10.10.10.1"}, nil,
+			[]v1.EndpointPort{{Name: "http", Port: 80}})
+		node := test.NewNode("node1", "1", false,
+			[]v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "192.168.1.1"}}, nil)
+		objs = []runtime.Object{vs, svc, eps, node}
+	})
+
+	It("Renders the declaration of Virtual Servers", func() {
+		params.UseNodeInternal = true
+		decl, err := RenderDeclaration(params, "test-agent", objs)
+		Expect(err).To(BeNil())
+		Expect(decl).To(ContainSubstring(`"test":{`), "Tenant not found in declaration")
+		Expect(decl).To(ContainSubstring(`"crd_1_2_3_4_80"`), "Virtual not found in declaration")
+		Expect(decl).To(ContainSubstring(`"serverAddresses":["10.10.10.1"]`),
+			"Pool member not found in declaration")
+		Expect(decl).To(ContainSubstring(`"userAgent":"test-agent"`))
+	})
+
+	It("Skips Virtual Servers without the Custom Resource label", func() {
+		vs.Labels = nil
+		decl, err := RenderDeclaration(params, "test-agent", objs)
+		Expect(err).To(BeNil())
+		Expect(decl).NotTo(ContainSubstring(`"crd_1_2_3_4_80"`))
+	})
+})
@@ -17,7 +17,6 @@
 package crmanager
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -26,9 +25,8 @@ import (
 	"strings"
 	"sync"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-
 	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/config/apis/cis/v1"
+	rsc "github.com/F5Networks/k8s-bigip-ctlr/pkg/resource"
 	log "github.com/F5Networks/k8s-bigip-ctlr/pkg/vlogger"
 	v1 "k8s.io/api/core/v1"
 )
@@ -90,6 +88,10 @@ const (
 	DEFAULT_HTTP_PORT  int32  = 80
 	DEFAULT_HTTPS_PORT int32  = 443
 	DEFAULT_SNAT       string = "auto"
+	DEFAULT_AB_WEIGHT  int32  = 100
+
+	// Highest route domain ID supported by BIG-IP
+	maxRouteDomain int32 = 65534
 
 	urlRewriteRulePrefix      = "url-rewrite-rule-"
 	appRootForwardRulePrefix  = "app-root-forward-rule-"
@@ -104,9 +106,10 @@ const (
 	CustomProfileServer string = "serverside"
 
 	// Constants for CustomProfile.PeerCertMode
-	PeerCertRequired = "require"
-	PeerCertIgnored  = "ignore"
-	PeerCertDefault  = PeerCertIgnored
+	PeerCertRequired  = "require"
+	PeerCertRequested = "request"
+	PeerCertIgnored   = "ignore"
+	PeerCertDefault   = PeerCertIgnored
 
 	// Constants
 	HttpRedirectIRuleName = "http_redirect_irule"
@@ -115,6 +118,10 @@ const (
 	// Internal data group for https redirect
 	HttpsRedirectDgName = "https_redirect_dg"
 	TLSIRuleName        = "tls_irule"
+	// iRule to select pools of A/B deployments based on host and path
+	AbDeploymentPathIRuleName = "ab_deployment_path_irule"
+	// iRule to reject clients outside the allowed source ranges
+	SourceRangeIRuleName = "source_range_irule"
 )
 
 // constants for TLS references
@@ -153,7 +160,7 @@ func NewCustomProfile(
 		SNIDefault:   sni,
 		PeerCertMode: peerCertMode,
 	}
-	if peerCertMode == PeerCertRequired {
+	if peerCertMode == PeerCertRequired || peerCertMode == PeerCertRequested {
 		cp.CAFile = caFile
 	}
 	return cp
@@ -334,48 +341,37 @@ func (crMgr *CRManager) prepareRSConfigFromVirtualServer(
 	var pools Pools
 	var rules *Rules
 	var plcy *Policy
-	var poolExist bool
 	var monitors []Monitor
-	for _, pl := range vs.Spec.Pools {
+	addPool := func(svcName string, svcPort int32, pl cisapiv1.Pool) {
 		pool := Pool{
 			Name: formatVirtualServerPoolName(
 				vs.ObjectMeta.Namespace,
-				pl.Service,
-				pl.ServicePort,
+				svcName,
+				svcPort,
 				pl.NodeMemberLabel,
 			),
 			Partition:       rsCfg.Virtual.Partition,
-			ServiceName:     pl.Service,
-			ServicePort:     pl.ServicePort,
+			ServiceName:     svcName,
+			ServicePort:     svcPort,
 			NodeMemberLabel: pl.NodeMemberLabel,
 		}
+		setPoolLBSettings(&pool, pl)
 		for _, p := range pools {
 			if pool.Name == p.Name {
-				poolExist = true
-				break
+				return
 			}
 		}
-		if poolExist {
-			poolExist = false
-			continue
-		}
 
-		if pl.Monitor.Send != "" && pl.Monitor.Type != "" {
-			pool.MonitorNames = append(pool.MonitorNames, JoinBigipPath(DEFAULT_PARTITION,
-				formatMonitorName(vs.ObjectMeta.Namespace, pl.Service, pl.Monitor.Type, pl.ServicePort)))
-			monitor := Monitor{
-				Name:      formatMonitorName(vs.ObjectMeta.Namespace, pl.Service, pl.Monitor.Type, pl.ServicePort),
-				Partition: rsCfg.Virtual.Partition,
-				Type:      pl.Monitor.Type,
-				Interval:  pl.Monitor.Interval,
-				Send:      pl.Monitor.Send,
-				Recv:      pl.Monitor.Recv,
-				Timeout:   pl.Monitor.Timeout,
-			}
-			monitors = append(monitors, monitor)
-		}
+		monitors = append(monitors, crMgr.preparePoolMonitors(&pool, vs.ObjectMeta.Namespace, pl)...)
 		pools = append(pools, pool)
 	}
+	for _, pl := range vs.Spec.Pools {
+		addPool(pl.Service, pl.ServicePort, pl)
+		// Alternate backends share the monitor of the pool they belong to
+		for _, ab := range pl.AlternateBackends {
+			addPool(ab.Service, getAlternateBackendPort(ab, pl), pl)
+		}
+	}
 	rsCfg.Pools = append(rsCfg.Pools, pools...)
 	rsCfg.Monitors = append(rsCfg.Monitors, monitors...)
 
@@ -401,6 +397,25 @@ func (crMgr *CRManager) prepareRSConfigFromVirtualServer(
 	//Attach allowVlans.
 	rsCfg.Virtual.AllowVLANs = vs.Spec.AllowVLANs
 
+	// set the session persistence
+	if vs.Spec.Persistence != nil {
+		persistence := Persistence(*vs.Spec.Persistence)
+		rsCfg.Virtual.Persistence = &persistence
+	}
+
+	// set the BIG-IP profiles
+	if vs.Spec.Profiles != nil {
+		rsCfg.Virtual.ProfileSpec = newProfileSpec(vs.Spec.Profiles)
+	}
+
+	// set the source address ranges allowed and denied on the virtual
+	if len(vs.Spec.AllowSourceRange) != 0 {
+		rsCfg.Virtual.AllowSourceRange = parseSourceRanges(vs.Spec.AllowSourceRange)
+	}
+	if len(vs.Spec.DenySourceRange) != 0 {
+		rsCfg.Virtual.DenySourceRange = parseSourceRanges(vs.Spec.DenySourceRange)
+	}
+
 	// Do not Create Virtual Server L7 Forwarding policies if HTTPTraffic is set to None or Redirect
 	if len(vs.Spec.TLSProfileName) > 0 &&
 		rsCfg.Virtual.VirtualAddress.Port == httpPort &&
@@ -408,6 +423,9 @@ func (crMgr *CRManager) prepareRSConfigFromVirtualServer(
 		return nil
 	}
 
+	// Weighted traffic splitting for pools with alternate backends
+	crMgr.handleVirtualServerABDeployment(rsCfg, vs)
+
 	rules = crMgr.prepareVirtualServerRules(vs)
 	if rules == nil {
 		return fmt.Errorf("failed to create LTM Rules")
@@ -421,6 +439,9 @@ func (crMgr *CRManager) prepareRSConfigFromVirtualServer(
 		return nil
 	}
 
+	// The VirtualServers of a virtual share its source ranges, their rules are added once with the policy
+	*rules = append(*rules, sourceRangeRules(rsCfg.Virtual.AllowSourceRange, rsCfg.Virtual.DenySourceRange)...)
+
 	policyName := rsCfg.Virtual.Name + "_" + vs.Spec.Host + "_policy"
 	plcy = createPolicy(*rules, policyName, vs.ObjectMeta.Namespace)
 	if plcy != nil {
@@ -434,6 +455,26 @@ func (crMgr *CRManager) prepareRSConfigFromVirtualServer(
 	return nil
 }
 
+// newProfileSpec returns the BIG-IP profiles referenced by a custom resource
+func newProfileSpec(profiles *cisapiv1.ProfileSpec) *ProfileSpec {
+	return &ProfileSpec{
+		TCPClient:       profiles.TCP.Client,
+		TCPServer:       profiles.TCP.Server,
+		HTTP:            profiles.HTTP,
+		HTTP2:           profiles.HTTP2,
+		HTTPCompression: profiles.HTTPCompression,
+		WebAcceleration: profiles.WebAcceleration,
+		OneConnect:      profiles.OneConnect,
+		RequestLogging:  profiles.RequestLogging,
+	}
+}
+
+// hasHTTPProfiles returns true if any of the profiles requires HTTP traffic
+func (profiles *ProfileSpec) hasHTTPProfiles() bool {
+	return profiles.HTTP != "" || profiles.HTTP2 != "" || profiles.HTTPCompression != "" ||
+		profiles.WebAcceleration != "" || profiles.OneConnect != "" || profiles.RequestLogging != ""
+}
+
 // handleVirtualServerTLS handles TLS configuration for the Virtual Server resource
 // Return value is whether or not a custom profile was updated
 func (crMgr *CRManager) handleVirtualServerTLS(
@@ -472,6 +513,20 @@ func (crMgr *CRManager) handleVirtualServerTLS(
 
 		if tls.Spec.TLS.Termination == TLSPassthrough {
 			rsCfg.Virtual.PersistenceMethods = []string{"tls-session-id"}
+			// Cookies cannot be inserted into passthrough traffic
+			if rsCfg.Virtual.Persistence != nil && rsCfg.Virtual.Persistence.Method == "cookie" {
+				log.Warningf("Cookie persistence is not supported with TLS passthrough for Virtual '%s', "+
+					"using tls-session-id persistence", vsName)
+				rsCfg.Virtual.Persistence = nil
+			}
+			// HTTP profiles cannot process passthrough traffic
+			if profiles := rsCfg.Virtual.ProfileSpec; profiles != nil && profiles.hasHTTPProfiles() {
+				log.Warningf("HTTP profiles are not supported with TLS passthrough for Virtual '%s'", vsName)
+				rsCfg.Virtual.ProfileSpec = &ProfileSpec{
+					TCPClient: profiles.TCPClient,
+					TCPServer: profiles.TCPServer,
+				}
+			}
 			return true
 		}
 
@@ -489,6 +544,13 @@ func (crMgr *CRManager) handleVirtualServerTLS(
 					clientSSL, CustomProfileClient, vsNamespace)
 				rsCfg.Virtual.AddOrUpdateProfile(clientProfRef)
 			}
+			// BIG-IP profiles of the SNI certificates are expected to have their
+			// server names, the default one with SNI default enabled
+			for _, sniCert := range tls.Spec.TLS.SNICertificates {
+				sniProfRef := ConvertStringToProfileRef(
+					sniCert.ClientSSL, CustomProfileClient, vsNamespace)
+				rsCfg.Virtual.AddOrUpdateProfile(sniProfRef)
+			}
 			// Process referenced BIG-IP serverSSL
 			if serverSSL != "" {
 				serverProfRef := ConvertStringToProfileRef(
@@ -498,36 +560,44 @@ func (crMgr *CRManager) handleVirtualServerTLS(
 			log.Debugf("Updated BIGIP referenced profiles for Virtual '%s' using TLSProfile '%s'",
 				vsName, tlsName)
 		case Secret:
-			// Prepare SSL Transient Context
-			// Check if TLS Secret already exists
 			// Process ClientSSL stored as kubernetes secret
 			clientSSL := tls.Spec.TLS.ClientSSL
 			if clientSSL != "" {
-				if secret, ok := crMgr.SSLContext[clientSSL]; ok {
-					log.Debugf("clientSSL secret %s for TLSProfile '%s' is already available with CIS in "+
-						"SSLContext as clientSSL", secret.ObjectMeta.Name, tlsName)
-					err, _ := crMgr.createSecretClientSSLProfile(rsCfg, secret, CustomProfileClient)
-					if err != nil {
-						log.Debugf("error %v encountered for '%s' using TLSProfile '%s'",
-							err, vsName, tlsName)
-						return false
-					}
-				} else {
-					// Check if profile is contained in a Secret
-					// Update the SSL Context if secret found, This is used to avoid api calls
-					log.Debugf("saving clientSSL secret for TLSProfile '%s' into SSLContext", tlsName)
-					secret, err := crMgr.kubeClient.CoreV1().Secrets(vsNamespace).
-						Get(context.TODO(), clientSSL, metav1.GetOptions{})
+				// Secrets are read from the informer cache to have the rotated certificates
+				secret, err := crMgr.getSecret(vsNamespace, clientSSL)
+				if err != nil {
+					log.Errorf("secret %s not found for Virtual '%s' using TLSProfile '%s': %v",
+						clientSSL, vsName, tlsName, err)
+					return false
+				}
+				peerCertMode, caBundle, err := crMgr.getClientCertAuth(tls)
+				if err != nil {
+					log.Errorf("client certificate authentication failed for Virtual '%s' using "+
+						"TLSProfile '%s': %v", vsName, tlsName, err)
+					return false
+				}
+				// With SNI certificates, the clientSSL is the default certificate
+				sniDefault := len(tls.Spec.TLS.SNICertificates) > 0
+				err, _ = crMgr.createSecretClientSSLProfile(rsCfg, secret, "", sniDefault,
+					peerCertMode, caBundle, CustomProfileClient)
+				if err != nil {
+					log.Errorf("error %v encountered for '%s' using TLSProfile '%s'",
+						err, vsName, tlsName)
+					return false
+				}
+				// Process the certificates selected by the SNI of the clients
+				for _, sniCert := range tls.Spec.TLS.SNICertificates {
+					secret, err := crMgr.getSecret(vsNamespace, sniCert.ClientSSL)
 					if err != nil {
-						log.Errorf("secret %s not found for Virtual '%s' using TLSProfile '%s'",
-							clientSSL, vsName, tlsName)
+						log.Errorf("secret %s not found for host '%s' of Virtual '%s' using TLSProfile '%s': %v",
+							sniCert.ClientSSL, sniCert.Host, vsName, tlsName, err)
 						return false
 					}
-					crMgr.SSLContext[clientSSL] = secret
-					err, _ = crMgr.createSecretClientSSLProfile(rsCfg, secret, CustomProfileClient)
+					err, _ = crMgr.createSecretClientSSLProfile(rsCfg, secret, sniCert.Host, false,
+						peerCertMode, caBundle, CustomProfileClient)
 					if err != nil {
-						log.Errorf("error %v encountered for '%s' using TLSProfile '%s'",
-							err, vsName, tlsName)
+						log.Errorf("error %v encountered for host '%s' of '%s' using TLSProfile '%s'",
+							err, sniCert.Host, vsName, tlsName)
 						return false
 					}
 				}
@@ -535,33 +605,17 @@ func (crMgr *CRManager) handleVirtualServerTLS(
 			// Process ServerSSL stored as kubernetes secret
 			serverSSL := tls.Spec.TLS.ServerSSL
 			if serverSSL != "" {
-				if secret, ok := crMgr.SSLContext[serverSSL]; ok {
-					log.Debugf("serverSSL secret %s for TLSProfile '%s' is already available with CIS in"+
-						"SSLContext", secret.ObjectMeta.Name, tlsName)
-					err, _ := crMgr.createSecretServerSSLProfile(rsCfg, secret, CustomProfileServer)
-					if err != nil {
-						log.Debugf("error %v encountered for '%s' using TLSProfile '%s'",
-							err, vsName, tlsName)
-						return false
-					}
-				} else {
-					// Check if profile is contained in a Secret
-					// Update the SSL Context if secret found, This is used to avoid api calls
-					log.Debugf("saving serverSSL secret for TLSProfile '%s' into SSLContext", tlsName)
-					secret, err := crMgr.kubeClient.CoreV1().Secrets(vsNamespace).
-						Get(context.TODO(), serverSSL, metav1.GetOptions{})
-					if err != nil {
-						log.Errorf("secret %s not found for Virtual '%s' using TLSProfile '%s'",
-							serverSSL, vsName, tlsName)
-						return false
-					}
-					crMgr.SSLContext[serverSSL] = secret
-					err, _ = crMgr.createSecretServerSSLProfile(rsCfg, secret, CustomProfileServer)
-					if err != nil {
-						log.Errorf("error %v encountered for '%s' using TLSProfile '%s'",
-							err, vsName, tlsName)
-						return false
-					}
+				secret, err := crMgr.getSecret(vsNamespace, serverSSL)
+				if err != nil {
+					log.Errorf("secret %s not found for Virtual '%s' using TLSProfile '%s': %v",
+						serverSSL, vsName, tlsName, err)
+					return false
+				}
+				err, _ = crMgr.createSecretServerSSLProfile(rsCfg, secret, CustomProfileServer)
+				if err != nil {
+					log.Errorf("error %v encountered for '%s' using TLSProfile '%s'",
+						err, vsName, tlsName)
+					return false
 				}
 			}
 		default:
@@ -580,7 +634,7 @@ func (crMgr *CRManager) handleVirtualServerTLS(
 					sslPath := hostName + path
 					sslPath = strings.TrimSuffix(sslPath, "/")
 					updateDataGroup(rsCfg.IntDgMap, getRSCfgResName(rsCfg.Virtual.Name, EdgeServerSslDgName),
-						DEFAULT_PARTITION, vs.ObjectMeta.Namespace, sslPath, serverSsl)
+						rsCfg.Virtual.Partition, vs.ObjectMeta.Namespace, sslPath, serverSsl)
 
 				case TLSReencrypt:
 					hostName := vs.Spec.Host
@@ -590,7 +644,7 @@ func (crMgr *CRManager) handleVirtualServerTLS(
 					serverSsl := AS3NameFormatter("crd_" + ip + "_tls_client")
 					if "" != tls.Spec.TLS.ServerSSL {
 						updateDataGroup(rsCfg.IntDgMap, getRSCfgResName(rsCfg.Virtual.Name, ReencryptServerSslDgName),
-							DEFAULT_PARTITION, vs.ObjectMeta.Namespace, sslPath, serverSsl)
+							rsCfg.Virtual.Partition, vs.ObjectMeta.Namespace, sslPath, serverSsl)
 					}
 				}
 			}
@@ -608,6 +662,7 @@ func (crMgr *CRManager) handleVirtualServerTLS(
 					rsCfg.IntDgMap,
 					vs,
 					rsCfg.Virtual.Name,
+					rsCfg.Virtual.Partition,
 					ReencryptHostsDgName,
 				)
 			case TLSEdge:
@@ -615,6 +670,7 @@ func (crMgr *CRManager) handleVirtualServerTLS(
 					rsCfg.IntDgMap,
 					vs,
 					rsCfg.Virtual.Name,
+					rsCfg.Virtual.Partition,
 					EdgeHostsDgName,
 				)
 			}
@@ -647,17 +703,18 @@ func (crMgr *CRManager) handleVirtualServerTLS(
 			var ruleName string
 			if vs.Spec.Host == "" {
 				ruleName = fmt.Sprintf("%s_%d", getRSCfgResName(rsCfg.Virtual.Name, HttpRedirectNoHostIRuleName), httpsPort)
-				rsCfg.addIRule(ruleName, DEFAULT_PARTITION, httpRedirectIRuleNoHost(httpsPort))
+				rsCfg.addIRule(ruleName, rsCfg.Virtual.Partition, httpRedirectIRuleNoHost(httpsPort))
 			} else {
 				ruleName = fmt.Sprintf("%s_%d", getRSCfgResName(rsCfg.Virtual.Name, HttpRedirectIRuleName), httpsPort)
-				rsCfg.addIRule(ruleName, DEFAULT_PARTITION, httpRedirectIRule(httpsPort, rsCfg.Virtual.Name))
+				rsCfg.addIRule(ruleName, rsCfg.Virtual.Partition, httpRedirectIRule(httpsPort, rsCfg.Virtual.Name))
 			}
-			ruleName = JoinBigipPath(DEFAULT_PARTITION, ruleName)
+			ruleName = JoinBigipPath(rsCfg.Virtual.Partition, ruleName)
 			rsCfg.Virtual.AddIRule(ruleName)
 			updateDataGroupOfDgName(
 				rsCfg.IntDgMap,
 				vs,
 				rsCfg.Virtual.Name,
+				rsCfg.Virtual.Partition,
 				HttpsRedirectDgName,
 			)
 		case TLSAllowInsecure:
@@ -705,9 +762,60 @@ func validateTLSProfile(tls *cisapiv1.TLSProfile) bool {
 			return false
 		}
 	}
+	// validation for SNI certificates
+	if len(tls.Spec.TLS.SNICertificates) > 0 && tls.Spec.TLS.ClientSSL == "" {
+		log.Errorf("TLSProfile %s with sniCertificates should contain ClientSSL as the default "+
+			"certificate", tls.ObjectMeta.Name)
+		return false
+	}
+	for _, sniCert := range tls.Spec.TLS.SNICertificates {
+		if sniCert.Host == "" || sniCert.ClientSSL == "" {
+			log.Errorf("TLSProfile %s should contain both host and clientSSL in sniCertificates",
+				tls.ObjectMeta.Name)
+			return false
+		}
+	}
+	// validation for client certificate authentication
+	switch tls.Spec.TLS.PeerCertMode {
+	case "", PeerCertIgnored:
+	case PeerCertRequired, PeerCertRequested:
+		// Client certificates are validated by the TLS_Server created from the secrets
+		if tls.Spec.TLS.Reference != Secret || tls.Spec.TLS.ClientCASecret == "" {
+			log.Errorf("TLSProfile %s with peerCertMode %s should reference secrets and "+
+				"contain clientCASecret", tls.ObjectMeta.Name, tls.Spec.TLS.PeerCertMode)
+			return false
+		}
+	default:
+		log.Errorf("TLSProfile %s has invalid peerCertMode %s", tls.ObjectMeta.Name,
+			tls.Spec.TLS.PeerCertMode)
+		return false
+	}
 	return true
 }
 
+// getClientCertAuth returns the peer cert mode and the CA bundle to validate
+// the client certificates for the TLSProfile
+func (crMgr *CRManager) getClientCertAuth(tls *cisapiv1.TLSProfile) (string, string, error) {
+	peerCertMode := tls.Spec.TLS.PeerCertMode
+	if peerCertMode != PeerCertRequired && peerCertMode != PeerCertRequested {
+		return "", "", nil
+	}
+	secret, err := crMgr.getSecret(tls.ObjectMeta.Namespace, tls.Spec.TLS.ClientCASecret)
+	if err != nil {
+		return "", "", err
+	}
+	// CA bundles are usually kept as ca.crt, fall back to tls.crt as for serverSSL
+	caBundle, ok := secret.Data["ca.crt"]
+	if !ok {
+		caBundle, ok = secret.Data["tls.crt"]
+	}
+	if !ok || len(caBundle) == 0 {
+		return "", "", fmt.Errorf("Invalid Secret '%v': 'ca.crt' field not specified",
+			secret.ObjectMeta.Name)
+	}
+	return peerCertMode, string(caBundle), nil
+}
+
 // ConvertStringToProfileRef converts strings to profile references
 func ConvertStringToProfileRef(profileName, context, ns string) ProfileRef {
 	profName := strings.TrimSpace(strings.TrimPrefix(profileName, "/"))
@@ -768,8 +876,9 @@ func (v *Virtual) SetVirtualAddress(bindAddr string, port int32) {
 			BindAddr: bindAddr,
 			Port:     port,
 		}
+		v.RouteDomain = getRouteDomain(0, bindAddr)
 		// Validate the IP address, and create the destination
-		ip, rd := split_ip_with_route_domain(bindAddr)
+		ip, rd := rsc.Split_ip_with_route_domain(bindAddr)
 		if len(rd) > 0 {
 			rd = "%" + rd
 		}
@@ -889,24 +998,33 @@ func (rc *ResourceConfig) copyConfig(cfg *ResourceConfig) {
 	}
 }
 
-// split_ip_with_route_domain splits ip into ip and route domain
-func split_ip_with_route_domain(address string) (ip string, rd string) {
-	// Split the address into the ip and routeDomain (optional) parts
-	//     address is of the form: <ipv4_or_ipv6>[%<routeDomainID>]
-	match := strings.Split(address, "%")
-	if len(match) == 2 {
-		_, err := strconv.Atoi(match[1])
-		//Matches only when RD contains number, Not allowing RD has 80f
-		if err == nil {
-			ip = match[0]
-			rd = match[1]
-		} else {
-			ip = address
-		}
-	} else {
-		ip = match[0]
+// getRouteDomain returns the route domain of a custom resource, set with routeDomain
+// or given as the %<routeDomainID> suffix of its virtual server address
+func getRouteDomain(routeDomain int32, address string) int32 {
+	if routeDomain != 0 {
+		return routeDomain
 	}
-	return
+	_, rd := rsc.Split_ip_with_route_domain(address)
+	if id, err := strconv.Atoi(rd); err == nil {
+		return int32(id)
+	}
+	return 0
+}
+
+// withRouteDomain returns the address in the route domain, addresses which already
+// carry a route domain and addresses of the default route domain are returned as is
+func withRouteDomain(address string, routeDomain int32) string {
+	if address == "" || routeDomain == 0 || strings.Contains(address, "%") {
+		return address
+	}
+	return fmt.Sprintf("%s%%%d", address, routeDomain)
+}
+
+// withResourceRouteDomain returns the IPv4 and IPv6 virtual addresses of a custom resource with the
+// %<routeDomainID> suffix of its route domain, given by routeDomain or by its virtualServerAddress
+func withResourceRouteDomain(routeDomain int32, virtualServerAddress, ip, ipv6 string) (string, string) {
+	routeDomain = getRouteDomain(routeDomain, virtualServerAddress)
+	return withRouteDomain(ip, routeDomain), withRouteDomain(ipv6, routeDomain)
 }
 
 func (pol *Policy) AddRules(rls *Rules) {
@@ -1076,19 +1194,20 @@ func (crMgr *CRManager) handleDataGroupIRules(
 	// For https
 	if nil != tls {
 		termination := tls.Spec.TLS.Termination
-		tlsIRuleName := JoinBigipPath(DEFAULT_PARTITION,
+		partition := rsCfg.Virtual.Partition
+		tlsIRuleName := JoinBigipPath(partition,
 			getRSCfgResName(rsCfg.Virtual.Name, TLSIRuleName))
 		switch termination {
 		case TLSEdge:
 			rsCfg.addIRule(
-				getRSCfgResName(rsCfg.Virtual.Name, TLSIRuleName), DEFAULT_PARTITION, crMgr.getTLSIRule(rsCfg.Virtual.Name))
-			rsCfg.addInternalDataGroup(getRSCfgResName(rsCfg.Virtual.Name, EdgeHostsDgName), DEFAULT_PARTITION)
-			rsCfg.addInternalDataGroup(getRSCfgResName(rsCfg.Virtual.Name, EdgeServerSslDgName), DEFAULT_PARTITION)
+				getRSCfgResName(rsCfg.Virtual.Name, TLSIRuleName), partition, crMgr.getTLSIRule(rsCfg.Virtual.Name, partition))
+			rsCfg.addInternalDataGroup(getRSCfgResName(rsCfg.Virtual.Name, EdgeHostsDgName), partition)
+			rsCfg.addInternalDataGroup(getRSCfgResName(rsCfg.Virtual.Name, EdgeServerSslDgName), partition)
 		case TLSReencrypt:
 			rsCfg.addIRule(
-				getRSCfgResName(rsCfg.Virtual.Name, TLSIRuleName), DEFAULT_PARTITION, crMgr.getTLSIRule(rsCfg.Virtual.Name))
-			rsCfg.addInternalDataGroup(getRSCfgResName(rsCfg.Virtual.Name, ReencryptHostsDgName), DEFAULT_PARTITION)
-			rsCfg.addInternalDataGroup(getRSCfgResName(rsCfg.Virtual.Name, ReencryptServerSslDgName), DEFAULT_PARTITION)
+				getRSCfgResName(rsCfg.Virtual.Name, TLSIRuleName), partition, crMgr.getTLSIRule(rsCfg.Virtual.Name, partition))
+			rsCfg.addInternalDataGroup(getRSCfgResName(rsCfg.Virtual.Name, ReencryptHostsDgName), partition)
+			rsCfg.addInternalDataGroup(getRSCfgResName(rsCfg.Virtual.Name, ReencryptServerSslDgName), partition)
 		}
 		if vsHost != "" {
 			rsCfg.Virtual.AddIRule(tlsIRuleName)
@@ -1100,6 +1219,74 @@ func (crMgr *CRManager) deleteVirtualServer(rsName string) {
 	crMgr.resources.deleteVirtualServer(rsName)
 }
 
+// setPoolLBSettings sets the load balancing method, slow ramp time, minimum
+// monitors and member limits of the pool spec on the pool.
+func setPoolLBSettings(pool *Pool, pl cisapiv1.Pool) {
+	pool.Balance = pl.LoadBalancingMethod
+	pool.SlowRampTime = pl.SlowRampTime
+	pool.MinimumMonitors = pl.MinimumMonitors
+	pool.ConnectionLimit = pl.ConnectionLimit
+	pool.RateLimit = pl.RateLimit
+}
+
+// getPoolSpecMonitors returns the monitor and monitors of the pool spec
+func getPoolSpecMonitors(pl cisapiv1.Pool) []cisapiv1.Monitor {
+	if pl.Monitor.Type != "" || pl.Monitor.Reference != "" {
+		return append([]cisapiv1.Monitor{pl.Monitor}, pl.Monitors...)
+	}
+	return pl.Monitors
+}
+
+// preparePoolMonitors attaches the monitor and monitors of the pool spec to
+// the pool and returns the monitors to be created. Monitors referencing
+// BIG-IP are only attached to the pool.
+func (crMgr *CRManager) preparePoolMonitors(pool *Pool, namespace string, pl cisapiv1.Pool) []Monitor {
+	var monitors []Monitor
+	for i, mon := range getPoolSpecMonitors(pl) {
+		if mon.Reference != "" {
+			pool.MonitorNames = append(pool.MonitorNames, mon.Reference)
+			continue
+		}
+		if ok, msg := checkValidMonitor(mon); !ok {
+			log.Errorf("Invalid monitor for pool %v: %v", pool.Name, msg)
+			continue
+		}
+		monitor := Monitor{
+			Name:      formatMonitorName(namespace, pool.ServiceName, mon.Type, pool.ServicePort),
+			Partition: pool.Partition,
+			Type:      mon.Type,
+			Interval:  mon.Interval,
+			Send:      mon.Send,
+			Recv:      mon.Recv,
+			Timeout:   mon.Timeout,
+			QueryName: mon.QueryName,
+			QueryType: mon.QueryType,
+		}
+		if mon.Type == "https" {
+			monitor.ServerName = mon.ServerName
+			if mon.ClientCertificate != "" {
+				secret, err := crMgr.getSecret(namespace, mon.ClientCertificate)
+				if err != nil {
+					log.Errorf("Client certificate of monitor for pool %v not found: %v", pool.Name, err)
+					continue
+				}
+				monitor.ClientCert = string(secret.Data["tls.crt"])
+				monitor.ClientKey = string(secret.Data["tls.key"])
+			}
+		}
+		// Monitors of the same type of a pool need a name of their own
+		for _, name := range pool.MonitorNames {
+			if name == JoinBigipPath(pool.Partition, monitor.Name) {
+				monitor.Name = fmt.Sprintf("%s_%d", monitor.Name, i)
+				break
+			}
+		}
+		pool.MonitorNames = append(pool.MonitorNames, JoinBigipPath(pool.Partition, monitor.Name))
+		monitors = append(monitors, monitor)
+	}
+	return monitors
+}
+
 // Prepares resource config based on VirtualServer resource config
 func (crMgr *CRManager) prepareRSConfigFromTransportServer(
 	rsCfg *ResourceConfig,
@@ -1122,21 +1309,8 @@ func (crMgr *CRManager) prepareRSConfigFromTransportServer(
 		ServicePort:     vs.Spec.Pool.ServicePort,
 		NodeMemberLabel: vs.Spec.Pool.NodeMemberLabel,
 	}
-
-	if vs.Spec.Pool.Monitor.Type != "" {
-		pool.MonitorNames = append(pool.MonitorNames, JoinBigipPath(DEFAULT_PARTITION,
-			formatMonitorName(vs.ObjectMeta.Namespace, vs.Spec.Pool.Service, vs.Spec.Pool.Monitor.Type, vs.Spec.Pool.ServicePort)))
-		monitor := Monitor{
-			Name:      formatMonitorName(vs.ObjectMeta.Namespace, vs.Spec.Pool.Service, vs.Spec.Pool.Monitor.Type, vs.Spec.Pool.ServicePort),
-			Partition: rsCfg.Virtual.Partition,
-			Type:      vs.Spec.Pool.Monitor.Type,
-			Interval:  vs.Spec.Pool.Monitor.Interval,
-			Send:      "",
-			Recv:      "",
-			Timeout:   vs.Spec.Pool.Monitor.Timeout,
-		}
-		monitors = append(monitors, monitor)
-	}
+	setPoolLBSettings(&pool, vs.Spec.Pool)
+	monitors = append(monitors, crMgr.preparePoolMonitors(&pool, vs.ObjectMeta.Namespace, vs.Spec.Pool)...)
 	pools = append(pools, pool)
 	rsCfg.Virtual.Mode = vs.Spec.Mode
 	rsCfg.Virtual.IpProtocol = vs.Spec.Type
@@ -1159,6 +1333,30 @@ func (crMgr *CRManager) prepareRSConfigFromTransportServer(
 	//set allowed VLAN's per TS config
 	rsCfg.Virtual.AllowVLANs = vs.Spec.AllowVLANs
 
+	// set the session persistence
+	if vs.Spec.Persistence != nil {
+		persistence := Persistence(*vs.Spec.Persistence)
+		rsCfg.Virtual.Persistence = &persistence
+	}
+
+	// set the BIG-IP profiles
+	if vs.Spec.Profiles != nil {
+		rsCfg.Virtual.ProfileSpec = newProfileSpec(vs.Spec.Profiles)
+	}
+
+	// set the source address ranges allowed and denied on the virtual
+	if len(vs.Spec.AllowSourceRange) != 0 {
+		rsCfg.Virtual.AllowSourceRange = parseSourceRanges(vs.Spec.AllowSourceRange)
+	}
+	if len(vs.Spec.DenySourceRange) != 0 {
+		rsCfg.Virtual.DenySourceRange = parseSourceRanges(vs.Spec.DenySourceRange)
+	}
+	if len(rsCfg.Virtual.AllowSourceRange) != 0 || len(rsCfg.Virtual.DenySourceRange) != 0 {
+		policyName := rsCfg.Virtual.Name + "_source_range_policy"
+		rsCfg.SetPolicy(*createSourceRangePolicy(rsCfg.Virtual.AllowSourceRange,
+			rsCfg.Virtual.DenySourceRange, policyName, vs.ObjectMeta.Namespace))
+	}
+
 	// Attach user specified iRules
 	if len(vs.Spec.IRules) > 0 {
 		rsCfg.Virtual.IRules = append(rsCfg.Virtual.IRules, vs.Spec.IRules...)
@@ -1197,28 +1395,89 @@ func (crMgr *CRManager) prepareRSConfigFromLBService(
 			msg := fmt.Sprintf(
 				"Unable to parse health monitor JSON array '%v': %v", hmStr, err)
 			log.Errorf("[CORE] %s", msg)
+		} else {
+			pool.MonitorNames = append(pool.MonitorNames, JoinBigipPath(rsCfg.Virtual.Partition,
+				formatMonitorName(svc.Namespace, svc.Name, monitorType, svcPort.Port)))
+			monitor = Monitor{
+				Name:      formatMonitorName(svc.Namespace, svc.Name, monitorType, svcPort.Port),
+				Partition: rsCfg.Virtual.Partition,
+				Type:      monitorType,
+				Interval:  mon.Interval,
+				Send:      "",
+				Recv:      "",
+				Timeout:   mon.Timeout,
+			}
+			rsCfg.Monitors = append(rsCfg.Monitors, monitor)
 		}
-		pool.MonitorNames = append(pool.MonitorNames, JoinBigipPath(DEFAULT_PARTITION,
-			formatMonitorName(svc.Namespace, svc.Name, monitorType, svcPort.Port)))
-		monitor = Monitor{
-			Name:      formatMonitorName(svc.Namespace, svc.Name, monitorType, svcPort.Port),
-			Partition: rsCfg.Virtual.Partition,
-			Type:      monitorType,
-			Interval:  mon.Interval,
-			Send:      "",
-			Recv:      "",
-			Timeout:   mon.Timeout,
-		}
-		rsCfg.Monitors = append(rsCfg.Monitors, monitor)
 	}
 	rsCfg.Pools = Pools{pool}
 	rsCfg.Virtual.PoolName = poolName
 	rsCfg.Virtual.SNAT = DEFAULT_SNAT
 	rsCfg.Virtual.Mode = "standard"
+	if svcPort.Protocol == v1.ProtocolUDP {
+		rsCfg.Virtual.IpProtocol = "udp"
+	}
+
+	if snat, ok := svc.Annotations[LBServiceSNATAnnotation]; ok && snat != "" {
+		rsCfg.Virtual.SNAT = snat
+	}
+
+	if iRules, ok := svc.Annotations[LBServiceIRulesAnnotation]; ok {
+		for _, iRule := range strings.Split(iRules, ",") {
+			iRule = strings.TrimSpace(iRule)
+			if iRule != "" {
+				rsCfg.Virtual.AddIRule(iRule)
+			}
+		}
+	}
+
+	if persistence, ok := svc.Annotations[LBServicePersistenceAnnotation]; ok && persistence != "" {
+		rsCfg.Virtual.PersistenceMethods = []string{persistence}
+	}
+
+	// Annotation takes precedence over loadBalancerSourceRanges in the spec
+	sourceRanges := svc.Spec.LoadBalancerSourceRanges
+	if ranges, ok := svc.Annotations[LBServiceAllowSourceRangeAnnotation]; ok {
+		sourceRanges = strings.Split(ranges, ",")
+	}
+	if allowed := parseSourceRanges(sourceRanges); len(allowed) != 0 {
+		iRuleName := getRSCfgResName(rsCfg.Virtual.Name, SourceRangeIRuleName)
+		if rsCfg.IRulesMap == nil {
+			rsCfg.IRulesMap = make(IRulesMap)
+		}
+		rsCfg.addIRule(iRuleName, rsCfg.Virtual.Partition, sourceRangeIRule(allowed))
+		rsCfg.Virtual.AddIRule(JoinBigipPath(rsCfg.Virtual.Partition, iRuleName))
+	}
 
 	return nil
 }
 
+// parseSourceRanges returns the valid CIDRs from the given list. Plain IP
+// addresses are converted to host CIDRs and invalid entries are skipped.
+func parseSourceRanges(ranges []string) []string {
+	var cidrs []string
+	for _, val := range ranges {
+		val = strings.TrimSpace(val)
+		if val == "" {
+			continue
+		}
+		if _, ipNet, err := net.ParseCIDR(val); err == nil {
+			cidrs = append(cidrs, ipNet.String())
+			continue
+		}
+		if ip := net.ParseIP(val); ip != nil {
+			if ip.To4() != nil {
+				cidrs = append(cidrs, ip.String()+"/32")
+			} else {
+				cidrs = append(cidrs, ip.String()+"/128")
+			}
+			continue
+		}
+		log.Errorf("Invalid source range %v, should be in CIDR format. Skipping.", val)
+	}
+	return cidrs
+}
+
 func getRSCfgResName(rsVSName, resName string) string {
 	return fmt.Sprintf("%s_%s", rsVSName, resName)
 }
@@ -87,6 +87,10 @@ var _ = Describe("Resource Config Tests", func() {
 			name := formatVirtualServerName("1.2.3.4", 80)
 			Expect(name).To(Equal("crd_1_2_3_4_80"), "Invalid VirtualServer Name")
 		})
+		It("VirtualServer Name in a route domain", func() {
+			name := formatVirtualServerName(withRouteDomain("1.2.3.4", 2), 80)
+			Expect(name).To(Equal("crd_1_2_3_4.2_80"), "Invalid VirtualServer Name")
+		})
 		It("VirtualServer Custom Name", func() {
 			name := formatCustomVirtualServerName("My_VS", 80)
 			Expect(name).To(Equal("My_VS_80"), "Invalid VirtualServer Name")
@@ -195,6 +199,7 @@ var _ = Describe("Resource Config Tests", func() {
 			_ = mockCRM.addNamespacedInformer(namespace)
 
 			rsCfg = &ResourceConfig{}
+			rsCfg.Virtual.Partition = DEFAULT_PARTITION
 			rsCfg.Virtual.SetVirtualAddress(
 				"1.2.3.4",
 				80,
@@ -245,6 +250,147 @@ var _ = Describe("Resource Config Tests", func() {
 			Expect(err).To(BeNil(), "Failed to Prepare Resource Config from VirtualServer")
 		})
 
+		It("Prepare Resource Config from a VirtualServer with multiple monitors", func() {
+			rsCfg.MetaData.ResourceType = VirtualServer
+			rsCfg.Virtual.Enabled = true
+			rsCfg.Virtual.Name = formatCustomVirtualServerName("My_VS", 80)
+			rsCfg.IntDgMap = make(InternalDataGroupMap)
+			rsCfg.IRulesMap = make(IRulesMap)
+
+			clSecret := test.NewSecret(
+				"monitorsecret",
+				namespace,
+				"### cert ###",
+				"#### key ####",
+			)
+			_ = mockCRM.crInformers[namespace].secretInformer.GetIndexer().Add(clSecret)
+
+			vs := test.NewVirtualServer(
+				"SampleVS",
+				namespace,
+				cisapiv1.VirtualServerSpec{
+					Host: "test.com",
+					Pools: []cisapiv1.Pool{
+						{
+							Path:        "/foo",
+							Service:     "svc1",
+							ServicePort: 80,
+							Monitor: cisapiv1.Monitor{
+								Type:     "http",
+								Send:     "GET /health",
+								Interval: 15,
+								Timeout:  10,
+							},
+							Monitors: []cisapiv1.Monitor{
+								{
+									Type:              "https",
+									Send:              "GET /health",
+									Interval:          15,
+									ServerName:        "test.com",
+									ClientCertificate: "monitorsecret",
+								},
+								{Type: "http", Send: "GET /ready", Interval: 5},
+								{Reference: "/Common/my_monitor"},
+								{Type: "icmp", Interval: 5},
+								{Type: "dns", Interval: 5},
+								{Type: "https", Send: "GET /", ClientCertificate: "unknownsecret"},
+							},
+						},
+					},
+				},
+			)
+			err := mockCRM.prepareRSConfigFromVirtualServer(rsCfg, vs)
+			Expect(err).To(BeNil(), "Failed to Prepare Resource Config from VirtualServer")
+			Expect(rsCfg.Pools[0].MonitorNames).To(Equal([]string{
+				JoinBigipPath(DEFAULT_PARTITION, "default_svc1_http_80"),
+				JoinBigipPath(DEFAULT_PARTITION, "default_svc1_https_80"),
+				JoinBigipPath(DEFAULT_PARTITION, "default_svc1_http_80_2"),
+				"/Common/my_monitor",
+				JoinBigipPath(DEFAULT_PARTITION, "default_svc1_icmp_80"),
+			}), "Monitors without the required options should be skipped")
+			Expect(len(rsCfg.Monitors)).To(Equal(4))
+			Expect(rsCfg.Monitors[1].ServerName).To(Equal("test.com"))
+			Expect(rsCfg.Monitors[1].ClientCert).To(Equal("### cert ###"))
+			Expect(rsCfg.Monitors[1].ClientKey).To(Equal("#### key ####"))
+		})
+
+		It("Prepare Resource Config from a VirtualServer with Alternate Backends", func() {
+			rsCfg.MetaData.ResourceType = VirtualServer
+			rsCfg.Virtual.Enabled = true
+			rsCfg.Virtual.Name = formatCustomVirtualServerName("My_VS", 80)
+			rsCfg.IntDgMap = make(InternalDataGroupMap)
+			rsCfg.IRulesMap = make(IRulesMap)
+
+			weight := int32(80)
+			abWeight := int32(20)
+			vs := test.NewVirtualServer(
+				"SampleVS",
+				namespace,
+				cisapiv1.VirtualServerSpec{
+					Host: "test.com",
+					Pools: []cisapiv1.Pool{
+						{
+							Path:        "/foo",
+							Service:     "svc1",
+							ServicePort: 80,
+							Weight:      &weight,
+							AlternateBackends: []cisapiv1.AlternateBackend{
+								{
+									Service: "svc1-canary",
+									Weight:  &abWeight,
+								},
+							},
+						},
+					},
+				},
+			)
+			err := mockCRM.prepareRSConfigFromVirtualServer(rsCfg, vs)
+			Expect(err).To(BeNil(), "Failed to Prepare Resource Config from VirtualServer")
+			Expect(len(rsCfg.Pools)).To(Equal(2), "Failed to create pool for alternate backend")
+			Expect(rsCfg.Pools[1].Name).To(Equal("default_svc1_canary_80"))
+
+			iRuleName := getRSCfgResName(rsCfg.Virtual.Name, AbDeploymentPathIRuleName)
+			Expect(rsCfg.Virtual.IRules).To(ContainElement(JoinBigipPath(DEFAULT_PARTITION, iRuleName)))
+			_, ok := rsCfg.IRulesMap[NameRef{Name: iRuleName, Partition: DEFAULT_PARTITION}]
+			Expect(ok).To(BeTrue(), "Failed to add A/B deployment iRule")
+
+			dgName := getRSCfgResName(rsCfg.Virtual.Name, AbDeploymentDgName)
+			dg := rsCfg.IntDgMap[NameRef{Name: dgName, Partition: DEFAULT_PARTITION}][namespace]
+			Expect(dg).NotTo(BeNil(), "Failed to add A/B deployment datagroup")
+			Expect(dg.Records).To(Equal(InternalDataGroupRecords{
+				{Name: "test.com/foo", Data: "default_svc1_80,0.800;default_svc1_canary_80,1.000"},
+			}))
+		})
+
+		It("Alternate Backends with zero weights", func() {
+			intDgMap := make(InternalDataGroupMap)
+			zero := int32(0)
+			vs := test.NewVirtualServer(
+				"SampleVS",
+				namespace,
+				cisapiv1.VirtualServerSpec{
+					Host: "test.com",
+					Pools: []cisapiv1.Pool{
+						{
+							Path:        "/",
+							Service:     "svc1",
+							ServicePort: 80,
+							Weight:      &zero,
+							AlternateBackends: []cisapiv1.AlternateBackend{
+								{Service: "svc2", ServicePort: 8080, Weight: &zero},
+							},
+						},
+					},
+				},
+			)
+			updateDataGroupForABVirtualServer(intDgMap, vs, "crd_1_2_3_4_80", DEFAULT_PARTITION)
+			dgName := getRSCfgResName("crd_1_2_3_4_80", AbDeploymentDgName)
+			dg := intDgMap[NameRef{Name: dgName, Partition: DEFAULT_PARTITION}][namespace]
+			Expect(dg.Records).To(Equal(InternalDataGroupRecords{
+				{Name: "test.com", Data: ""},
+			}), "All zero weights should result in empty record")
+		})
+
 		It("Prepare Resource Config from a TransportServer", func() {
 			ts := test.NewTransportServer(
 				"SampleTS",
@@ -265,6 +411,33 @@ var _ = Describe("Resource Config Tests", func() {
 			Expect(err).To(BeNil(), "Failed to Prepare Resource Config from TransportServer")
 		})
 
+		It("Prepare Resource Config from a TransportServer with pool load balancing settings", func() {
+			slowRampTime := int32(30)
+			ts := test.NewTransportServer(
+				"SampleTS",
+				namespace,
+				cisapiv1.TransportServerSpec{
+					Pool: cisapiv1.Pool{
+						Service:             "svc1",
+						ServicePort:         80,
+						LoadBalancingMethod: "fastest-app-response",
+						SlowRampTime:        &slowRampTime,
+						MinimumMonitors:     1,
+						ConnectionLimit:     100,
+						RateLimit:           10,
+					},
+				},
+			)
+			err := mockCRM.prepareRSConfigFromTransportServer(rsCfg, ts)
+			Expect(err).To(BeNil(), "Failed to Prepare Resource Config from TransportServer")
+			Expect(len(rsCfg.Pools)).To(Equal(1))
+			Expect(rsCfg.Pools[0].Balance).To(Equal("fastest-app-response"))
+			Expect(*rsCfg.Pools[0].SlowRampTime).To(Equal(int32(30)))
+			Expect(rsCfg.Pools[0].MinimumMonitors).To(Equal(int32(1)))
+			Expect(rsCfg.Pools[0].ConnectionLimit).To(Equal(int32(100)))
+			Expect(rsCfg.Pools[0].RateLimit).To(Equal(int32(10)))
+		})
+
 		It("Prepare Resource Config from a Service", func() {
 			svcPort := v1.ServicePort{
 				Name:     "port1",
@@ -287,6 +460,49 @@ var _ = Describe("Resource Config Tests", func() {
 			Expect(len(rsCfg.Monitors)).To(Equal(1), "Failed to Prepare Resource Config from Service")
 		})
 
+		It("Prepare Resource Config from a Service with annotations", func() {
+			svcPort := v1.ServicePort{
+				Name:     "port1",
+				Port:     53,
+				Protocol: v1.ProtocolUDP,
+			}
+			svc := test.NewService(
+				"svc1",
+				"1",
+				namespace,
+				v1.ServiceTypeLoadBalancer,
+				[]v1.ServicePort{svcPort},
+			)
+			svc.Spec.LoadBalancerSourceRanges = []string{"192.168.0.0/16"}
+			svc.Annotations = make(map[string]string)
+			svc.Annotations[HealthMonitorAnnotation] = `{"interval": 5, "timeout"}`
+			svc.Annotations[LBServiceSNATAnnotation] = "/Common/snatpool"
+			svc.Annotations[LBServiceIRulesAnnotation] = "/Common/rule1, /Common/rule2"
+			svc.Annotations[LBServicePersistenceAnnotation] = "source-address"
+			svc.Annotations[LBServiceAllowSourceRangeAnnotation] = "10.0.0.0/8,1.2.3.4,invalid"
+
+			rsCfg.Virtual.Name = "vs_lb_svc_default_svc1_1.2.3.4_53"
+			err := mockCRM.prepareRSConfigFromLBService(rsCfg, svc, svcPort)
+			Expect(err).To(BeNil(), "Failed to Prepare Resource Config from Service")
+			Expect(len(rsCfg.Monitors)).To(Equal(0), "Invalid health monitor should be ignored")
+			Expect(rsCfg.Virtual.IpProtocol).To(Equal("udp"))
+			Expect(rsCfg.Virtual.SNAT).To(Equal("/Common/snatpool"))
+			Expect(rsCfg.Virtual.PersistenceMethods).To(Equal([]string{"source-address"}))
+
+			iRuleName := getRSCfgResName(rsCfg.Virtual.Name, SourceRangeIRuleName)
+			Expect(rsCfg.Virtual.IRules).To(Equal([]string{
+				"/Common/rule1",
+				"/Common/rule2",
+				JoinBigipPath(DEFAULT_PARTITION, iRuleName),
+			}))
+			iRule, ok := rsCfg.IRulesMap[NameRef{Name: iRuleName, Partition: DEFAULT_PARTITION}]
+			Expect(ok).To(BeTrue(), "Failed to add source range iRule")
+			Expect(iRule.Code).To(ContainSubstring("equals 10.0.0.0/8]"))
+			Expect(iRule.Code).To(ContainSubstring("equals 1.2.3.4/32]"))
+			Expect(iRule.Code).NotTo(ContainSubstring("192.168.0.0/16"))
+			Expect(iRule.Code).NotTo(ContainSubstring("invalid"))
+		})
+
 		It("Get Pool Members from Resource Configs", func() {
 			mem1 := Member{
 				Address: "1.2.3.5",
@@ -511,6 +727,65 @@ var _ = Describe("Resource Config Tests", func() {
 		Expect(ok).To(BeFalse(), "TLS Edge Validation Failed")
 	})
 
+	It("Validate TLS Profiles with SNI certificates", func() {
+		tlsSNI := test.NewTLSProfile(
+			"sampleTLS",
+			namespace,
+			cisapiv1.TLSProfileSpec{
+				TLS: cisapiv1.TLS{
+					Termination: TLSEdge,
+					ClientSSL:   "clientssl",
+					Reference:   Secret,
+					SNICertificates: []cisapiv1.SNICertificate{
+						{Host: "*.example.com", ClientSSL: "snissl"},
+					},
+				},
+			},
+		)
+		Expect(validateTLSProfile(tlsSNI)).To(BeTrue(), "TLS SNI Validation Failed")
+
+		// Negative cases
+		tlsSNI.Spec.TLS.SNICertificates[0].ClientSSL = ""
+		Expect(validateTLSProfile(tlsSNI)).To(BeFalse(), "SNI certificate without clientSSL Validation Failed")
+
+		tlsSNI.Spec.TLS.SNICertificates[0].ClientSSL = "snissl"
+		tlsSNI.Spec.TLS.Termination = TLSPassthrough
+		tlsSNI.Spec.TLS.ClientSSL = ""
+		Expect(validateTLSProfile(tlsSNI)).To(BeFalse(), "TLS SNI without default certificate Validation Failed")
+	})
+
+	It("Validate TLS Profiles with client certificate authentication", func() {
+		tlsMTLS := test.NewTLSProfile(
+			"sampleTLS",
+			namespace,
+			cisapiv1.TLSProfileSpec{
+				TLS: cisapiv1.TLS{
+					Termination:    TLSEdge,
+					ClientSSL:      "clientssl",
+					Reference:      Secret,
+					ClientCASecret: "clientca",
+					PeerCertMode:   PeerCertRequired,
+				},
+			},
+		)
+		Expect(validateTLSProfile(tlsMTLS)).To(BeTrue(), "TLS Client Authentication Validation Failed")
+
+		tlsMTLS.Spec.TLS.PeerCertMode = PeerCertRequested
+		Expect(validateTLSProfile(tlsMTLS)).To(BeTrue(), "TLS Client Authentication Validation Failed")
+
+		// Negative cases
+		tlsMTLS.Spec.TLS.PeerCertMode = "always"
+		Expect(validateTLSProfile(tlsMTLS)).To(BeFalse(), "Invalid peerCertMode Validation Failed")
+
+		tlsMTLS.Spec.TLS.PeerCertMode = PeerCertRequired
+		tlsMTLS.Spec.TLS.ClientCASecret = ""
+		Expect(validateTLSProfile(tlsMTLS)).To(BeFalse(), "Missing clientCASecret Validation Failed")
+
+		tlsMTLS.Spec.TLS.ClientCASecret = "clientca"
+		tlsMTLS.Spec.TLS.Reference = BIGIP
+		Expect(validateTLSProfile(tlsMTLS)).To(BeFalse(), "BIGIP Reference Validation Failed")
+	})
+
 	Describe("Resource Configs", func() {
 		var res Resources
 		BeforeEach(func() {
@@ -555,7 +830,11 @@ var _ = Describe("Resource Config Tests", func() {
 
 		BeforeEach(func() {
 			mockCRM = newMockCRManager()
-			mockCRM.SSLContext = make(map[string]*v1.Secret)
+			mockCRM.kubeCRClient = crdfake.NewSimpleClientset()
+			mockCRM.kubeClient = k8sfake.NewSimpleClientset()
+			mockCRM.crInformers = make(map[string]*CRInformer)
+			mockCRM.resourceSelector, _ = createLabelSelector(DefaultCustomResourceLabel)
+			_ = mockCRM.addNamespacedInformer(namespace)
 
 			ip = "1.2.3.4"
 
@@ -620,6 +899,13 @@ var _ = Describe("Resource Config Tests", func() {
 			tlsProf.Spec.TLS.Termination = TLSPassthrough
 			ok := mockCRM.handleVirtualServerTLS(rsCfg, vs, tlsProf, ip)
 			Expect(ok).To(BeTrue(), "Failed to Process TLS Termination: Passthrough")
+			Expect(rsCfg.Virtual.PersistenceMethods).To(Equal([]string{"tls-session-id"}))
+
+			// HTTP profiles cannot be attached to passthrough virtuals
+			rsCfg.Virtual.ProfileSpec = &ProfileSpec{TCPClient: "/Common/f5-tcp-mobile", HTTP: "/Common/http"}
+			ok = mockCRM.handleVirtualServerTLS(rsCfg, vs, tlsProf, ip)
+			Expect(ok).To(BeTrue(), "Failed to Process TLS Termination: Passthrough")
+			Expect(rsCfg.Virtual.ProfileSpec).To(Equal(&ProfileSpec{TCPClient: "/Common/f5-tcp-mobile"}))
 		})
 
 		It("TLS Edge with BIGIP Reference", func() {
@@ -753,17 +1039,113 @@ var _ = Describe("Resource Config Tests", func() {
 				"### cert ###",
 				"#### key ####",
 			)
-			mockCRM.kubeClient = k8sfake.NewSimpleClientset(clSecret)
+			_ = mockCRM.crInformers[namespace].secretInformer.GetIndexer().Add(clSecret)
 
 			ok := mockCRM.handleVirtualServerTLS(rsCfg, vs, tlsProf, ip)
 			Expect(ok).To(BeTrue(), "Failed to Process TLS Termination: Edge")
 			Expect(len(rsCfg.customProfiles.Profs)).To(Equal(2), "Failed to Process TLS Termination: Edge")
-			Expect(len(mockCRM.SSLContext)).To(Equal(1), "Failed to Process TLS Termination: Edge")
 
 			ok = mockCRM.handleVirtualServerTLS(rsCfg, vs, tlsProf, ip)
 			Expect(ok).To(BeTrue(), "Failed to Process TLS Termination: Edge")
 			Expect(len(rsCfg.customProfiles.Profs)).To(Equal(2), "Failed to Process TLS Termination: Edge")
-			Expect(len(mockCRM.SSLContext)).To(Equal(1), "Failed to Process TLS Termination: Edge")
+		})
+
+		It("TLS Edge with client certificate authentication", func() {
+			vs.Spec.TLSProfileName = "SampleTLS"
+			tlsProf.Spec.TLS.Termination = TLSEdge
+			tlsProf.Spec.TLS.Reference = Secret
+			tlsProf.Spec.TLS.ClientSSL = "clientsecret"
+			tlsProf.Spec.TLS.ClientCASecret = "clientcasecret"
+			tlsProf.Spec.TLS.PeerCertMode = PeerCertRequired
+
+			rsCfg.customProfiles = *NewCustomProfiles()
+			secretIndexer := mockCRM.crInformers[namespace].secretInformer.GetIndexer()
+			_ = secretIndexer.Add(test.NewSecret("clientsecret", namespace, "### cert ###", "#### key ####"))
+
+			ok := mockCRM.handleVirtualServerTLS(rsCfg, vs, tlsProf, ip)
+			Expect(ok).To(BeFalse(), "TLS Termination without the client CA Secret should fail")
+
+			caSecret := test.NewSecret("clientcasecret", namespace, "", "")
+			caSecret.Data = map[string][]byte{"ca.crt": []byte("### ca ###")}
+			_ = secretIndexer.Add(caSecret)
+			ok = mockCRM.handleVirtualServerTLS(rsCfg, vs, tlsProf, ip)
+			Expect(ok).To(BeTrue(), "Failed to Process TLS Termination: Edge")
+			prof := rsCfg.customProfiles.Profs[SecretKey{Name: "clientsecret", ResourceName: rsCfg.GetName()}]
+			Expect(prof.PeerCertMode).To(Equal(PeerCertRequired), "Peer cert mode not set")
+			Expect(prof.CAFile).To(Equal("### ca ###"), "Client CA bundle not set")
+		})
+
+		It("TLS Edge with SNI certificates", func() {
+			vs.Spec.TLSProfileName = "SampleTLS"
+			tlsProf.Spec.TLS.Termination = TLSEdge
+			tlsProf.Spec.TLS.Reference = Secret
+			tlsProf.Spec.TLS.ClientSSL = "clientsecret"
+			tlsProf.Spec.TLS.SNICertificates = []cisapiv1.SNICertificate{
+				{Host: "*.example.com", ClientSSL: "snisecret"},
+				{Host: "foo.com", ClientSSL: "clientsecret"},
+			}
+
+			rsCfg.customProfiles = *NewCustomProfiles()
+			secretIndexer := mockCRM.crInformers[namespace].secretInformer.GetIndexer()
+			_ = secretIndexer.Add(test.NewSecret("clientsecret", namespace, "### cert ###", "#### key ####"))
+
+			ok := mockCRM.handleVirtualServerTLS(rsCfg, vs, tlsProf, ip)
+			Expect(ok).To(BeFalse(), "TLS Termination without the SNI Secret should fail")
+
+			_ = secretIndexer.Add(test.NewSecret("snisecret", namespace, "### sni cert ###", "#### sni key ####"))
+			ok = mockCRM.handleVirtualServerTLS(rsCfg, vs, tlsProf, ip)
+			Expect(ok).To(BeTrue(), "Failed to Process TLS Termination: Edge")
+			// default profile of the virtual and a certificate for the clientSSL and each host
+			Expect(len(rsCfg.customProfiles.Profs)).To(Equal(4), "Failed to Process TLS Termination: Edge")
+
+			prof := rsCfg.customProfiles.Profs[SecretKey{Name: "clientsecret", ResourceName: rsCfg.GetName()}]
+			Expect(prof.SNIDefault).To(BeTrue(), "clientSSL should be the default certificate")
+			Expect(prof.ServerName).To(BeEmpty())
+			prof = rsCfg.customProfiles.Profs[SecretKey{
+				Name: "snisecret-wildcard.example.com", ResourceName: rsCfg.GetName()}]
+			Expect(prof.ServerName).To(Equal("*.example.com"), "SNI certificate not found")
+			Expect(prof.Cert).To(Equal("### sni cert ###"), "SNI certificate not found")
+			prof = rsCfg.customProfiles.Profs[SecretKey{Name: "clientsecret-foo.com", ResourceName: rsCfg.GetName()}]
+			Expect(prof.ServerName).To(Equal("foo.com"), "SNI certificate not found")
+		})
+
+		It("TLS Edge with SNI BIG-IP profiles", func() {
+			vs.Spec.TLSProfileName = "SampleTLS"
+			tlsProf.Spec.TLS.Termination = TLSEdge
+			tlsProf.Spec.TLS.Reference = BIGIP
+			tlsProf.Spec.TLS.ClientSSL = "/Common/clientssl"
+			tlsProf.Spec.TLS.SNICertificates = []cisapiv1.SNICertificate{
+				{Host: "*.example.com", ClientSSL: "/Common/clientssl-example"},
+			}
+
+			ok := mockCRM.handleVirtualServerTLS(rsCfg, vs, tlsProf, ip)
+			Expect(ok).To(BeTrue(), "Failed to Process TLS Termination: Edge")
+			Expect(rsCfg.Virtual.Profiles).To(ContainElement(ProfileRef{
+				Name: "clientssl-example", Partition: "Common", Context: CustomProfileClient, Namespace: namespace,
+			}), "SNI BIG-IP profile not added")
+			Expect(len(rsCfg.Virtual.Profiles)).To(Equal(2), "Failed to Process TLS Termination: Edge")
+		})
+
+		It("TLS Edge with the rotated certificate of the Secret", func() {
+			vs.Spec.TLSProfileName = "SampleTLS"
+			tlsProf.Spec.TLS.Termination = TLSEdge
+			tlsProf.Spec.TLS.Reference = Secret
+			tlsProf.Spec.TLS.ClientSSL = "clientsecret"
+
+			rsCfg.customProfiles = *NewCustomProfiles()
+			secretIndexer := mockCRM.crInformers[namespace].secretInformer.GetIndexer()
+			_ = secretIndexer.Add(test.NewSecret("clientsecret", namespace, "### cert ###", "#### key ####"))
+
+			ok := mockCRM.handleVirtualServerTLS(rsCfg, vs, tlsProf, ip)
+			Expect(ok).To(BeTrue(), "Failed to Process TLS Termination: Edge")
+
+			_ = secretIndexer.Update(test.NewSecret("clientsecret", namespace, "### new cert ###", "#### new key ####"))
+			ok = mockCRM.handleVirtualServerTLS(rsCfg, vs, tlsProf, ip)
+			Expect(ok).To(BeTrue(), "Failed to Process TLS Termination: Edge")
+			Expect(len(rsCfg.customProfiles.Profs)).To(Equal(2), "Failed to Process TLS Termination: Edge")
+			prof := rsCfg.customProfiles.Profs[SecretKey{Name: "clientsecret", ResourceName: rsCfg.GetName()}]
+			Expect(prof.Cert).To(Equal("### new cert ###"), "Rotated certificate not used")
+			Expect(prof.Key).To(Equal("#### new key ####"), "Rotated key not used")
 		})
 
 		It("TLS Reencrypt with BIGIP Reference", func() {
@@ -788,17 +1170,16 @@ var _ = Describe("Resource Config Tests", func() {
 				"### cert ###",
 				"",
 			)
-			mockCRM.kubeClient = k8sfake.NewSimpleClientset(clSecret, svSecret)
+			_ = mockCRM.crInformers[namespace].secretInformer.GetIndexer().Add(clSecret)
+			_ = mockCRM.crInformers[namespace].secretInformer.GetIndexer().Add(svSecret)
 
 			ok := mockCRM.handleVirtualServerTLS(rsCfg, vs, tlsProf, ip)
 			Expect(ok).To(BeTrue(), "Failed to Process TLS Termination: Reencrypt")
 			Expect(len(rsCfg.customProfiles.Profs)).To(Equal(4), "Failed to Process TLS Termination: Reencrypt")
-			Expect(len(mockCRM.SSLContext)).To(Equal(2), "Failed to Process TLS Termination: Reencrypt")
 
 			ok = mockCRM.handleVirtualServerTLS(rsCfg, vs, tlsProf, ip)
 			Expect(ok).To(BeTrue(), "Failed to Process TLS Termination: Reencrypt")
 			Expect(len(rsCfg.customProfiles.Profs)).To(Equal(4), "Failed to Process TLS Termination: Reencrypt")
-			Expect(len(mockCRM.SSLContext)).To(Equal(2), "Failed to Process TLS Termination: Reencrypt")
 		})
 
 		It("Validate API failures", func() {
@@ -810,8 +1191,6 @@ var _ = Describe("Resource Config Tests", func() {
 
 			rsCfg.customProfiles = *NewCustomProfiles()
 
-			mockCRM.kubeClient = k8sfake.NewSimpleClientset()
-
 			ok := mockCRM.handleVirtualServerTLS(rsCfg, vs, tlsProf, ip)
 			Expect(ok).To(BeFalse(), "Failed to Process TLS Termination: Reencrypt")
 
@@ -821,7 +1200,7 @@ var _ = Describe("Resource Config Tests", func() {
 				"### cert ###",
 				"#### key ####",
 			)
-			mockCRM.kubeClient = k8sfake.NewSimpleClientset(clSecret)
+			_ = mockCRM.crInformers[namespace].secretInformer.GetIndexer().Add(clSecret)
 			ok = mockCRM.handleVirtualServerTLS(rsCfg, vs, tlsProf, ip)
 			Expect(ok).To(BeFalse(), "Failed to Process TLS Termination: Reencrypt")
 		})
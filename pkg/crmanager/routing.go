@@ -385,6 +385,13 @@ func (rules Rules) Len() int {
 func (rules Rules) Less(i, j int) bool {
 	ruleI := rules[i]
 	ruleJ := rules[j]
+	// Strategy 0: Rule dropping the connection of a source address
+	dropsI := isSourceRangeRule(ruleI)
+	dropsJ := isSourceRangeRule(ruleJ)
+	if dropsI != dropsJ {
+		return dropsI
+	}
+
 	// Strategy 1: Rule with Highest number of conditions
 	l1 := len(ruleI.Conditions)
 	l2 := len(ruleJ.Conditions)
@@ -446,6 +453,84 @@ func (rules Rules) Swap(i, j int) {
 	rules[i], rules[j] = rules[j], rules[i]
 }
 
+// sourceRangeRules drops the connections from clients within any of the denied
+// CIDRs and, when allowed CIDRs are given, from clients not within any of them.
+func sourceRangeRules(allowed, denied []string) Rules {
+	var rls Rules
+	if len(denied) != 0 {
+		rls = append(rls, sourceRangeRule("deny_source_range", 0, denied, false))
+	}
+	if len(allowed) != 0 {
+		rls = append(rls, sourceRangeRule("allow_source_range", 1, allowed, true))
+	}
+	return rls
+}
+
+func sourceRangeRule(ruleName string, ordinal int, cidrs []string, not bool) *Rule {
+	return &Rule{
+		Name:    ruleName,
+		Ordinal: ordinal,
+		Actions: []*action{{
+			Name:           "0",
+			ClientAccepted: true,
+			Reset:          true,
+		}},
+		Conditions: []*condition{{
+			Name:           "0",
+			Tcp:            true,
+			Address:        true,
+			Matches:        true,
+			Not:            not,
+			ClientAccepted: true,
+			Values:         cidrs,
+		}},
+	}
+}
+
+func isSourceRangeRule(rule *Rule) bool {
+	for _, cnd := range rule.Conditions {
+		if cnd.Tcp && cnd.Address {
+			return true
+		}
+	}
+	return false
+}
+
+// createSourceRangePolicy creates the policy dropping the connections of the
+// denied source addresses of a virtual without L7 forwarding policies.
+func createSourceRangePolicy(allowed, denied []string, policyName, partition string) *Policy {
+	plcy := Policy{
+		Controls:  []string{PolicyControlForward},
+		Legacy:    true,
+		Name:      policyName,
+		Partition: partition,
+		Rules:     Rules{},
+		Strategy:  "/Common/first-match",
+	}
+
+	rls := sourceRangeRules(allowed, denied)
+	plcy.AddRules(&rls)
+
+	log.Debugf("Configured policy: %v", plcy)
+	return &plcy
+}
+
+// sourceRangeIRule rejects connections from clients that are not
+// within any of the given CIDRs.
+func sourceRangeIRule(cidrs []string) string {
+	var conditions []string
+	for _, cidr := range cidrs {
+		conditions = append(conditions, fmt.Sprintf("[IP::addr [IP::client_addr] equals %s]", cidr))
+	}
+	iRuleCode := fmt.Sprintf(`
+		when CLIENT_ACCEPTED {
+			if { !(%s) } {
+				reject
+			}
+		}`, strings.Join(conditions, " || "))
+	return iRuleCode
+}
+
 // httpRedirectIRuleNoHost redirects traffic to BIG-IP https vs
 // for hostLess CRDs.
 func httpRedirectIRuleNoHost(port int32) string {
@@ -525,8 +610,13 @@ func httpRedirectIRule(port int32, rsVSName string) string {
 	return iRuleCode
 }
 
-func (crMgr *CRManager) getTLSIRule(rsVSName string) string {
-	dgPath := crMgr.dgPath
+// getDataGroupPath returns the path of the data groups referred in the iRules of the partition
+func getDataGroupPath(partition string) string {
+	return strings.Join([]string{partition, as3SharedApplication}, "/")
+}
+
+func (crMgr *CRManager) getTLSIRule(rsVSName string, partition string) string {
+	dgPath := getDataGroupPath(partition)
 
 	iRule := fmt.Sprintf(`
 		when CLIENT_ACCEPTED {
@@ -772,13 +862,13 @@ func (crMgr *CRManager) getTLSIRule(rsVSName string) string {
 			}
         }`, dgPath, rsVSName)
 
-	iRuleCode := fmt.Sprintf("%s\n\n%s", crMgr.selectPoolIRuleFunc(rsVSName), iRule)
+	iRuleCode := fmt.Sprintf("%s\n\n%s", crMgr.selectPoolIRuleFunc(rsVSName, partition), iRule)
 
 	return iRuleCode
 }
 
-func (crMgr *CRManager) selectPoolIRuleFunc(rsVSName string) string {
-	dgPath := crMgr.dgPath
+func (crMgr *CRManager) selectPoolIRuleFunc(rsVSName string, partition string) string {
+	dgPath := getDataGroupPath(partition)
 
 	iRuleFunc := fmt.Sprintf(`
 		proc select_ab_pool {path default_pool } {
@@ -817,10 +907,147 @@ func (crMgr *CRManager) selectPoolIRuleFunc(rsVSName string) string {
 	return iRuleFunc
 }
 
+func (crMgr *CRManager) abDeploymentPathIRule(rsVSName string, partition string) string {
+	// For all A/B deployments that include a path.
+	// The key in the data group is the specific host/path to examine.
+	// The data is a list of pool/weight pairs delimited by ';'. The pair values
+	// are delineated by ','. Finally, the weight value is normalized between
+	// 0.0 and 1.0 and the pairs should be listed in ascending order or weight
+	// values.
+	iRuleCode := fmt.Sprintf("%s\n\n%s", crMgr.selectPoolIRuleFunc(rsVSName, partition), `
+		when HTTP_REQUEST priority 200 {
+			set path [string tolower [getfield [HTTP::host] ":" 1]][HTTP::path]
+			set selected_pool [call select_ab_pool $path ""]
+			if {$selected_pool != ""} then {
+				pool $selected_pool
+				event disable
+			}
+		}`)
+
+	return iRuleCode
+}
+
+// handleVirtualServerABDeployment attaches the A/B deployment iRule and
+// datagroup for VirtualServer pools having alternate backends
+func (crMgr *CRManager) handleVirtualServerABDeployment(
+	rsCfg *ResourceConfig,
+	vs *cisapiv1.VirtualServer,
+) {
+	isABDeployment := false
+	for _, pl := range vs.Spec.Pools {
+		if len(pl.AlternateBackends) > 0 {
+			isABDeployment = true
+			break
+		}
+	}
+	if !isABDeployment {
+		return
+	}
+
+	if vs.Spec.TLSProfileName != "" {
+		tls := crMgr.getTLSProfileForVirtualServer(vs, vs.ObjectMeta.Namespace)
+		if tls != nil && tls.Spec.TLS.Termination == TLSPassthrough {
+			log.Warningf("Alternate backends are not supported with passthrough termination, "+
+				"VirtualServer %s/%s will use only the primary pools",
+				vs.ObjectMeta.Namespace, vs.ObjectMeta.Name)
+			return
+		}
+	}
+
+	iRuleName := getRSCfgResName(rsCfg.Virtual.Name, AbDeploymentPathIRuleName)
+	partition := rsCfg.Virtual.Partition
+	rsCfg.addIRule(iRuleName, partition, crMgr.abDeploymentPathIRule(rsCfg.Virtual.Name, partition))
+	rsCfg.addInternalDataGroup(getRSCfgResName(rsCfg.Virtual.Name, AbDeploymentDgName), partition)
+	rsCfg.Virtual.AddIRule(JoinBigipPath(partition, iRuleName))
+
+	updateDataGroupForABVirtualServer(rsCfg.IntDgMap, vs, rsCfg.Virtual.Name, partition)
+}
+
+// Update a data group map based on the alternate backends of VirtualServer pools.
+// (ignore a service with a 0 weight value)
+func updateDataGroupForABVirtualServer(
+	intDgMap InternalDataGroupMap,
+	vs *cisapiv1.VirtualServer,
+	rsVSName string,
+	partition string,
+) {
+	namespace := vs.ObjectMeta.Namespace
+	dgName := getRSCfgResName(rsVSName, AbDeploymentDgName)
+
+	for _, pl := range vs.Spec.Pools {
+		if len(pl.AlternateBackends) == 0 {
+			continue
+		}
+		key := strings.TrimSuffix(vs.Spec.Host+pl.Path, "/")
+
+		type weightedPool struct {
+			name   string
+			weight int32
+		}
+		backends := []weightedPool{{
+			name: formatVirtualServerPoolName(
+				namespace, pl.Service, pl.ServicePort, pl.NodeMemberLabel),
+			weight: getBackendWeight(pl.Weight),
+		}}
+		for _, ab := range pl.AlternateBackends {
+			backends = append(backends, weightedPool{
+				name: formatVirtualServerPoolName(
+					namespace, ab.Service, getAlternateBackendPort(ab, pl), pl.NodeMemberLabel),
+				weight: getBackendWeight(ab.Weight),
+			})
+		}
+
+		var weightTotal int32
+		for _, be := range backends {
+			weightTotal = weightTotal + be.weight
+		}
+		if weightTotal == 0 {
+			// All services have 0 weight, a 503 will be returned
+			updateDataGroup(intDgMap, dgName, partition, namespace, key, "")
+			continue
+		}
+
+		// Place each service in a segment between 0.0 and 1.0 that corresponds to
+		// it's ratio percentage. The list must be in ascending order.
+		var entries []string
+		var runningWeightTotal int32
+		for _, be := range backends {
+			if be.weight == 0 {
+				continue
+			}
+			runningWeightTotal = runningWeightTotal + be.weight
+			weightedSliceThreshold := float64(runningWeightTotal) / float64(weightTotal)
+			entries = append(entries, fmt.Sprintf("%s,%4.3f", be.name, weightedSliceThreshold))
+		}
+		updateDataGroup(intDgMap, dgName, partition, namespace, key, strings.Join(entries, ";"))
+	}
+}
+
+// getBackendWeight returns the weight of a backend, defaults to DEFAULT_AB_WEIGHT
+func getBackendWeight(weight *int32) int32 {
+	if weight == nil {
+		return DEFAULT_AB_WEIGHT
+	}
+	if *weight < 0 {
+		return 0
+	}
+	return *weight
+}
+
+// getAlternateBackendPort returns the service port of an alternate backend,
+// defaults to the service port of the pool
+func getAlternateBackendPort(ab cisapiv1.AlternateBackend, pl cisapiv1.Pool) int32 {
+	if ab.ServicePort == 0 {
+		return pl.ServicePort
+	}
+	return ab.ServicePort
+}
+
 func updateDataGroupOfDgName(
 	intDgMap InternalDataGroupMap,
 	virtual *cisapiv1.VirtualServer,
 	rsVSName string,
+	partition string,
 	dgName string,
 ) {
 	hostName := virtual.Spec.Host
@@ -838,7 +1065,7 @@ func updateDataGroupOfDgName(
 			routePath = strings.TrimSuffix(routePath, "/")
 			poolName := formatVirtualServerPoolName(namespace, pl.Service, pl.ServicePort, pl.NodeMemberLabel)
 			updateDataGroup(intDgMap, rsDGName,
-				DEFAULT_PARTITION, namespace, routePath, poolName)
+				partition, namespace, routePath, poolName)
 		}
 	case HttpsRedirectDgName:
 		for _, pl := range virtual.Spec.Pools {
@@ -848,7 +1075,7 @@ func updateDataGroupOfDgName(
 			}
 			routePath := hostName + path
 			updateDataGroup(intDgMap, rsDGName,
-				DEFAULT_PARTITION, namespace, routePath, path)
+				partition, namespace, routePath, path)
 		}
 	}
 }
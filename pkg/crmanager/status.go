@@ -0,0 +1,327 @@
+/*-
+* Copyright (c) 2016-2021, F5 Networks, Inc.
+*
+* Licensed under the Apache License, Version 2.0 (the "License");
+* you may not use this file except in compliance with the License.
+* You may obtain a copy of the License at
+*
+*    http://www.apache.org/licenses/LICENSE-2.0
+*
+* Unless required by applicable law or agreed to in writing, software
+* distributed under the License is distributed on an "AS IS" BASIS,
+* WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+* See the License for the specific language governing permissions and
+* limitations under the License.
+ */
+
+package crmanager
+
+import (
+	"context"
+	"reflect"
+
+	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/config/apis/cis/v1"
+	log "github.com/F5Networks/k8s-bigip-ctlr/pkg/vlogger"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Reasons for the conditions reported in the status of the custom resources
+const (
+	ReasonValidated        = "Validated"
+	ReasonMissingAddress   = "MissingVirtualServerAddress"
+	ReasonMissingIPAMLabel = "MissingIPAMLabel"
+	ReasonInvalidSpec      = "InvalidSpec"
+	ReasonStaticAddress    = "StaticAddress"
+	ReasonIPAMAllocated    = "IPAMAllocated"
+	ReasonIPAMPending      = "IPAMPending"
+	ReasonIPPoolExhausted  = "IPPoolExhausted"
+	ReasonConfigGenerated  = "ConfigGenerated"
+	ReasonProcessingFailed = "ProcessingFailed"
+	ReasonNoVirtualServer  = "NoMatchingVirtualServer"
+	ReasonAS3Accepted      = "AS3Accepted"
+	ReasonAS3Rejected      = "AS3Rejected"
+)
+
+// newCondition returns a condition for the status of the custom resources
+func newCondition(condType string, valid bool, reason, message string) metav1.Condition {
+	status := metav1.ConditionFalse
+	if valid {
+		status = metav1.ConditionTrue
+	}
+	return metav1.Condition{
+		Type:    condType,
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+}
+
+// statusUpdateRetries is the number of attempts to update the status on conflicts
+const statusUpdateRetries = 5
+
+// retryOnConflict runs the update until it succeeds or fails with an error other than a conflict
+func retryOnConflict(update func() error) error {
+	var err error
+	for i := 0; i < statusUpdateRetries; i++ {
+		if err = update(); !errors.IsConflict(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// setConditions adds or updates the conditions observed for the given generation
+func setConditions(existing *[]metav1.Condition, generation int64, conditions []metav1.Condition) {
+	for _, cond := range conditions {
+		if cond.Type == "" {
+			continue
+		}
+		cond.ObservedGeneration = generation
+		meta.SetStatusCondition(existing, cond)
+	}
+}
+
+// updateStatus runs the status update of a custom resource until it succeeds or fails with
+// an error other than a conflict, on conflicts the resource is refreshed with get before retrying
+// Status is not updated in dry-run mode and left to the leader, standby replicas skip the update
+func (crMgr *CRManager) updateStatus(kind, key string, get func() error, update func() error) {
+	if crMgr.dryRun || crMgr.isStandby() {
+		return
+	}
+	err := retryOnConflict(func() error {
+		err := update()
+		if errors.IsConflict(err) {
+			if getErr := get(); getErr != nil {
+				return getErr
+			}
+		}
+		return err
+	})
+	if nil != err {
+		log.Warningf("Error while updating %s %s status: %v", kind, key, err)
+	}
+}
+
+// updateVirtualServerStatus updates the status of VirtualServer with the virtual address and conditions
+// An empty ip retains the existing virtual address
+func (crMgr *CRManager) updateVirtualServerStatus(
+	vs *cisapiv1.VirtualServer,
+	ip string,
+	conditions ...metav1.Condition,
+) {
+	if crMgr.kubeCRClient == nil {
+		return
+	}
+	client := crMgr.kubeCRClient.CisV1().VirtualServers(vs.ObjectMeta.Namespace)
+	crMgr.updateStatus(VirtualServer, vs.ObjectMeta.Namespace+"/"+vs.ObjectMeta.Name,
+		func() (err error) {
+			vs, err = client.Get(context.TODO(), vs.ObjectMeta.Name, metav1.GetOptions{})
+			return err
+		},
+		func() error {
+			newVS := vs.DeepCopy()
+			if ip != "" {
+				newVS.Status.VSAddress = ip
+			}
+			setConditions(&newVS.Status.Conditions, newVS.Generation, conditions)
+			if reflect.DeepEqual(vs.Status, newVS.Status) {
+				return nil
+			}
+			_, err := client.UpdateStatus(context.TODO(), newVS, metav1.UpdateOptions{})
+			return err
+		},
+	)
+}
+
+// updateTransportServerStatus updates the status of TransportServer with the virtual address and conditions
+// An empty ip retains the existing virtual address
+func (crMgr *CRManager) updateTransportServerStatus(
+	ts *cisapiv1.TransportServer,
+	ip string,
+	conditions ...metav1.Condition,
+) {
+	if crMgr.kubeCRClient == nil {
+		return
+	}
+	client := crMgr.kubeCRClient.CisV1().TransportServers(ts.ObjectMeta.Namespace)
+	crMgr.updateStatus(TransportServer, ts.ObjectMeta.Namespace+"/"+ts.ObjectMeta.Name,
+		func() (err error) {
+			ts, err = client.Get(context.TODO(), ts.ObjectMeta.Name, metav1.GetOptions{})
+			return err
+		},
+		func() error {
+			newTS := ts.DeepCopy()
+			if ip != "" {
+				newTS.Status.VSAddress = ip
+			}
+			setConditions(&newTS.Status.Conditions, newTS.Generation, conditions)
+			if reflect.DeepEqual(ts.Status, newTS.Status) {
+				return nil
+			}
+			_, err := client.UpdateStatus(context.TODO(), newTS, metav1.UpdateOptions{})
+			return err
+		},
+	)
+}
+
+// updateIngressLinkStatus updates the status of IngressLink with the virtual address and conditions
+// An empty ip retains the existing virtual address
+func (crMgr *CRManager) updateIngressLinkStatus(
+	il *cisapiv1.IngressLink,
+	ip string,
+	conditions ...metav1.Condition,
+) {
+	if crMgr.kubeCRClient == nil {
+		return
+	}
+	client := crMgr.kubeCRClient.CisV1().IngressLinks(il.ObjectMeta.Namespace)
+	crMgr.updateStatus(IngressLink, il.ObjectMeta.Namespace+"/"+il.ObjectMeta.Name,
+		func() (err error) {
+			il, err = client.Get(context.TODO(), il.ObjectMeta.Name, metav1.GetOptions{})
+			return err
+		},
+		func() error {
+			newIL := il.DeepCopy()
+			if ip != "" {
+				newIL.Status.VSAddress = ip
+			}
+			setConditions(&newIL.Status.Conditions, newIL.Generation, conditions)
+			if reflect.DeepEqual(il.Status, newIL.Status) {
+				return nil
+			}
+			_, err := client.UpdateStatus(context.TODO(), newIL, metav1.UpdateOptions{})
+			return err
+		},
+	)
+}
+
+// updateTLSProfileStatus updates the status of TLSProfile with the conditions
+func (crMgr *CRManager) updateTLSProfileStatus(
+	tls *cisapiv1.TLSProfile,
+	conditions ...metav1.Condition,
+) {
+	if crMgr.kubeCRClient == nil {
+		return
+	}
+	client := crMgr.kubeCRClient.CisV1().TLSProfiles(tls.ObjectMeta.Namespace)
+	crMgr.updateStatus(TLSProfile, tls.ObjectMeta.Namespace+"/"+tls.ObjectMeta.Name,
+		func() (err error) {
+			tls, err = client.Get(context.TODO(), tls.ObjectMeta.Name, metav1.GetOptions{})
+			return err
+		},
+		func() error {
+			newTLS := tls.DeepCopy()
+			setConditions(&newTLS.Status.Conditions, newTLS.Generation, conditions)
+			if reflect.DeepEqual(tls.Status, newTLS.Status) {
+				return nil
+			}
+			_, err := client.UpdateStatus(context.TODO(), newTLS, metav1.UpdateOptions{})
+			return err
+		},
+	)
+}
+
+// updateExternalDNSStatus updates the status of ExternalDNS with the conditions
+func (crMgr *CRManager) updateExternalDNSStatus(
+	edns *cisapiv1.ExternalDNS,
+	conditions ...metav1.Condition,
+) {
+	if crMgr.kubeCRClient == nil {
+		return
+	}
+	client := crMgr.kubeCRClient.CisV1().ExternalDNSs(edns.ObjectMeta.Namespace)
+	crMgr.updateStatus(ExternalDNS, edns.ObjectMeta.Namespace+"/"+edns.ObjectMeta.Name,
+		func() (err error) {
+			edns, err = client.Get(context.TODO(), edns.ObjectMeta.Name, metav1.GetOptions{})
+			return err
+		},
+		func() error {
+			newEDNS := edns.DeepCopy()
+			setConditions(&newEDNS.Status.Conditions, newEDNS.Generation, conditions)
+			if reflect.DeepEqual(edns.Status, newEDNS.Status) {
+				return nil
+			}
+			_, err := client.UpdateStatus(context.TODO(), newEDNS, metav1.UpdateOptions{})
+			return err
+		},
+	)
+}
+
+// getResource returns the custom resource of the kind with namespace/name key
+func (crMgr *CRManager) getResource(kind, key string) (interface{}, bool) {
+	namespace, _, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return nil, false
+	}
+	crInf, ok := crMgr.getNamespacedInformer(namespace)
+	if !ok {
+		return nil, false
+	}
+	var obj interface{}
+	var found bool
+	switch kind {
+	case VirtualServer:
+		obj, found, _ = crInf.vsInformer.GetIndexer().GetByKey(key)
+	case TransportServer:
+		obj, found, _ = crInf.tsInformer.GetIndexer().GetByKey(key)
+	case IngressLink:
+		obj, found, _ = crInf.ilInformer.GetIndexer().GetByKey(key)
+	}
+	return obj, found
+}
+
+// setResourceCondition sets the condition in the status of the custom resource
+func (crMgr *CRManager) setResourceCondition(obj interface{}, condition metav1.Condition) {
+	switch rsc := obj.(type) {
+	case *cisapiv1.VirtualServer:
+		crMgr.updateVirtualServerStatus(rsc, "", condition)
+	case *cisapiv1.TransportServer:
+		crMgr.updateTransportServerStatus(rsc, "", condition)
+	case *cisapiv1.IngressLink:
+		crMgr.updateIngressLinkStatus(rsc, "", condition)
+	}
+}
+
+// recordResourceEvent records an event on the custom resource
+func (crMgr *CRManager) recordResourceEvent(obj runtime.Object, eventType, reason, message string) {
+	if crMgr.eventNotifier == nil || crMgr.kubeClient == nil || crMgr.dryRun || crMgr.isStandby() {
+		return
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	evNotifier := crMgr.eventNotifier.CreateNotifierForNamespace(
+		accessor.GetNamespace(), crMgr.kubeClient.CoreV1())
+	evNotifier.RecordEvent(obj, eventType, reason, message)
+}
+
+// responseHandler updates the BIGIPAccepted condition of the custom resources
+// based on the response of BIG-IP for the posted declaration
+// AS3 errors are also recorded as events on the custom resources producing the failed objects
+func (crMgr *CRManager) responseHandler(respChan chan resourceStatusMeta) {
+	for rscStatus := range respChan {
+		reason := ReasonAS3Accepted
+		if !rscStatus.accepted {
+			reason = ReasonAS3Rejected
+		}
+		for key, kind := range rscStatus.resources {
+			obj, found := crMgr.getResource(kind, key)
+			if !found {
+				continue
+			}
+			cond := newCondition(cisapiv1.ConditionBIGIPAccepted, rscStatus.accepted, reason, rscStatus.message)
+			if msg, failed := rscStatus.failures[key]; failed {
+				cond.Message = msg
+				crMgr.recordResourceEvent(obj.(runtime.Object), v1.EventTypeWarning, ReasonAS3Rejected, msg)
+			}
+			crMgr.setResourceCondition(obj, cond)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+package crmanager
+
+import (
+	"context"
+	"net/http"
+
+	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/config/apis/cis/v1"
+	crdfake "github.com/F5Networks/k8s-bigip-ctlr/config/client/clientset/versioned/fake"
+	apm "github.com/F5Networks/k8s-bigip-ctlr/pkg/appmanager"
+	rsc "github.com/F5Networks/k8s-bigip-ctlr/pkg/resource"
+	"github.com/F5Networks/k8s-bigip-ctlr/pkg/teem"
+	"github.com/F5Networks/k8s-bigip-ctlr/pkg/test"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+)
+
+// fakeEventBroadcaster hands out a shared FakeRecorder to capture the recorded events
+type fakeEventBroadcaster struct {
+	record.EventBroadcaster
+	recorder *record.FakeRecorder
+}
+
+func (feb *fakeEventBroadcaster) NewRecorder(scheme *runtime.Scheme, source v1.EventSource) record.EventRecorder {
+	return feb.recorder
+}
+
+var _ = Describe("Status Tests", func() {
+	var mockCRM *mockCRManager
+	var vs *cisapiv1.VirtualServer
+	var ts *cisapiv1.TransportServer
+	var tlsProf *cisapiv1.TLSProfile
+	var edns *cisapiv1.ExternalDNS
+	namespace := "default"
+
+	BeforeEach(func() {
+		mockCRM = newMockCRManager()
+		vs = test.NewVirtualServer(
+			"SampleVS",
+			namespace,
+			cisapiv1.VirtualServerSpec{
+				Host: "test.com",
+				Pools: []cisapiv1.Pool{
+					{
+						Path:    "/path",
+						Service: "svc1",
+					},
+				},
+			},
+		)
+		ts = test.NewTransportServer(
+			"SampleTS",
+			namespace,
+			cisapiv1.TransportServerSpec{
+				VirtualServerAddress: "1.2.3.5",
+				VirtualServerPort:    1600,
+			},
+		)
+		tlsProf = test.NewTLSProfile(
+			"SampleTLS",
+			namespace,
+			cisapiv1.TLSProfileSpec{
+				TLS: cisapiv1.TLS{
+					Termination: "edge",
+				},
+			},
+		)
+		edns = test.NewExternalDNS(
+			"SampleEDNS",
+			namespace,
+			cisapiv1.ExternalDNSSpec{},
+		)
+		mockCRM.kubeCRClient = crdfake.NewSimpleClientset(vs, ts, tlsProf, edns)
+		mockCRM.kubeClient = k8sfake.NewSimpleClientset()
+		mockCRM.crInformers = make(map[string]*CRInformer)
+		mockCRM.resourceSelector, _ = createLabelSelector(DefaultCustomResourceLabel)
+		_ = mockCRM.addNamespacedInformer(namespace)
+		mockCRM.resources = NewResources()
+	})
+
+	It("Update VirtualServer Status with Conditions", func() {
+		mockCRM.updateVirtualServerStatus(
+			vs,
+			"1.2.3.4",
+			newCondition(cisapiv1.ConditionValid, true, ReasonValidated, ""),
+			newCondition(cisapiv1.ConditionIPAllocated, true, ReasonIPAMAllocated, ""),
+		)
+		updatedVS, err := mockCRM.kubeCRClient.CisV1().VirtualServers(namespace).Get(
+			context.TODO(), vs.Name, metav1.GetOptions{})
+		Expect(err).To(BeNil())
+		Expect(updatedVS.Status.VSAddress).To(Equal("1.2.3.4"), "Incorrect VirtualServer Address")
+		Expect(meta.IsStatusConditionTrue(updatedVS.Status.Conditions, cisapiv1.ConditionValid)).To(BeTrue())
+		Expect(meta.IsStatusConditionTrue(updatedVS.Status.Conditions, cisapiv1.ConditionIPAllocated)).To(BeTrue())
+
+		// Same conditions should not update the status again
+		actions := len(mockCRM.kubeCRClient.(*crdfake.Clientset).Actions())
+		mockCRM.updateVirtualServerStatus(
+			updatedVS,
+			"",
+			newCondition(cisapiv1.ConditionValid, true, ReasonValidated, ""),
+		)
+		Expect(mockCRM.kubeCRClient.(*crdfake.Clientset).Actions()).To(HaveLen(actions),
+			"Status should not be updated without changes")
+	})
+
+	It("Update TransportServer, TLSProfile and ExternalDNS Status", func() {
+		mockCRM.updateTransportServerStatus(ts, "",
+			newCondition(cisapiv1.ConditionProgrammed, false, ReasonProcessingFailed, "failed"))
+		updatedTS, err := mockCRM.kubeCRClient.CisV1().TransportServers(namespace).Get(
+			context.TODO(), ts.Name, metav1.GetOptions{})
+		Expect(err).To(BeNil())
+		cond := meta.FindStatusCondition(updatedTS.Status.Conditions, cisapiv1.ConditionProgrammed)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+		Expect(cond.Reason).To(Equal(ReasonProcessingFailed))
+		Expect(cond.Message).To(Equal("failed"))
+
+		mockCRM.updateTLSProfileStatus(tlsProf,
+			newCondition(cisapiv1.ConditionValid, true, ReasonValidated, ""))
+		updatedTLS, err := mockCRM.kubeCRClient.CisV1().TLSProfiles(namespace).Get(
+			context.TODO(), tlsProf.Name, metav1.GetOptions{})
+		Expect(err).To(BeNil())
+		Expect(meta.IsStatusConditionTrue(updatedTLS.Status.Conditions, cisapiv1.ConditionValid)).To(BeTrue())
+
+		mockCRM.TeemData = &teem.TeemsData{
+			ResourceType: teem.ResourceTypes{
+				ExternalDNS: make(map[string]int),
+			},
+		}
+		mockCRM.processExternalDNS(edns, false)
+		// Fake tracker does not register the externaldnss resource, verify the status update action
+		actions := mockCRM.kubeCRClient.(*crdfake.Clientset).Actions()
+		updateAction, ok := actions[len(actions)-1].(k8stesting.UpdateAction)
+		Expect(ok).To(BeTrue())
+		Expect(updateAction.GetSubresource()).To(Equal("status"))
+		updatedEDNS := updateAction.GetObject().(*cisapiv1.ExternalDNS)
+		Expect(meta.IsStatusConditionFalse(updatedEDNS.Status.Conditions, cisapiv1.ConditionValid)).To(BeTrue(),
+			"ExternalDNS without domainName should be invalid")
+	})
+
+	It("Standby replica skips status updates and IPAM allocations", func() {
+		mockCRM.standby = true
+		actions := len(mockCRM.kubeCRClient.(*crdfake.Clientset).Actions())
+		mockCRM.updateVirtualServerStatus(
+			vs,
+			"1.2.3.4",
+			newCondition(cisapiv1.ConditionValid, true, ReasonValidated, ""),
+		)
+		Expect(mockCRM.kubeCRClient.(*crdfake.Clientset).Actions()).To(HaveLen(actions),
+			"Status should not be updated in standby")
+
+		_, _ = mockCRM.kubeClient.CoreV1().ConfigMaps("kube-system").Create(context.TODO(), &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cis-ipam",
+				Namespace: "kube-system",
+			},
+			Data: map[string]string{
+				"test": "10.8.0.0/30",
+			},
+		}, metav1.CreateOptions{})
+		mockCRM.ipamProvider = newStaticIPAMProvider(mockCRM.kubeClient, "kube-system", "cis-ipam")
+		key := namespace + "/SampleTS_ts"
+		Expect(mockCRM.requestIP("test", "", key)).To(BeEmpty(), "Address should not be allocated in standby")
+
+		mockCRM.standby = false
+		Expect(mockCRM.requestIP("test", "", key)).To(Equal("10.8.0.1"), "Address not allocated by the leader")
+
+		mockCRM.standby = true
+		Expect(mockCRM.requestIP("test", "", key)).To(Equal("10.8.0.1"),
+			"Standby replica should get the address allocated by the leader")
+		Expect(mockCRM.releaseIP("test", "", key)).To(Equal("10.8.0.1"))
+		Expect(mockCRM.ipamProvider.LookupIP("test", "", key)).To(Equal("10.8.0.1"),
+			"Address should not be released in standby")
+		Expect(mockCRM.requestIP("test", "", key)).To(Equal("10.8.0.1"))
+		Expect(mockCRM.skippedReleases).To(BeEmpty(), "Address of the resource created again should be kept")
+
+		Expect(mockCRM.releaseIP("test", "", key)).To(Equal("10.8.0.1"))
+		mockCRM.standby = false
+		mockCRM.replaySkippedReleases()
+		Expect(mockCRM.ipamProvider.LookupIP("test", "", key)).To(BeEmpty(),
+			"Address of the resource deleted in standby should be released by the leader")
+	})
+
+	It("Invalid VirtualServer reports Valid Condition", func() {
+		_ = mockCRM.crInformers[namespace].vsInformer.GetIndexer().Add(vs)
+		mockCRM.TeemData = &teem.TeemsData{
+			ResourceType: teem.ResourceTypes{
+				VirtualServer: make(map[string]int),
+			},
+		}
+		err := mockCRM.processVirtualServers(vs, false)
+		Expect(err).To(BeNil())
+		updatedVS, err := mockCRM.kubeCRClient.CisV1().VirtualServers(namespace).Get(
+			context.TODO(), vs.Name, metav1.GetOptions{})
+		Expect(err).To(BeNil())
+		cond := meta.FindStatusCondition(updatedVS.Status.Conditions, cisapiv1.ConditionValid)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+		Expect(cond.Reason).To(Equal(ReasonMissingAddress))
+	})
+
+	It("BIG-IP Response updates BIGIPAccepted Condition", func() {
+		_ = mockCRM.crInformers[namespace].vsInformer.GetIndexer().Add(vs)
+		_ = mockCRM.crInformers[namespace].tsInformer.GetIndexer().Add(ts)
+		respChan := make(chan resourceStatusMeta, 1)
+		respChan <- resourceStatusMeta{
+			accepted: false,
+			message:  "code: 422 tenant: test message: declaration failed",
+			resources: map[string]string{
+				namespace + "/" + vs.Name: VirtualServer,
+				namespace + "/" + ts.Name: TransportServer,
+				namespace + "/svc1":       Service,
+			},
+		}
+		close(respChan)
+		mockCRM.responseHandler(respChan)
+
+		updatedVS, err := mockCRM.kubeCRClient.CisV1().VirtualServers(namespace).Get(
+			context.TODO(), vs.Name, metav1.GetOptions{})
+		Expect(err).To(BeNil())
+		cond := meta.FindStatusCondition(updatedVS.Status.Conditions, cisapiv1.ConditionBIGIPAccepted)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+		Expect(cond.Reason).To(Equal(ReasonAS3Rejected))
+
+		updatedTS, err := mockCRM.kubeCRClient.CisV1().TransportServers(namespace).Get(
+			context.TODO(), ts.Name, metav1.GetOptions{})
+		Expect(err).To(BeNil())
+		Expect(meta.IsStatusConditionFalse(updatedTS.Status.Conditions, cisapiv1.ConditionBIGIPAccepted)).To(BeTrue())
+	})
+
+	It("AS3 Failures are recorded as Events on the Custom Resources", func() {
+		_ = mockCRM.crInformers[namespace].vsInformer.GetIndexer().Add(vs)
+		_ = mockCRM.crInformers[namespace].tsInformer.GetIndexer().Add(ts)
+		recorder := record.NewFakeRecorder(10)
+		mockCRM.eventNotifier = apm.NewEventNotifier(func() record.EventBroadcaster {
+			return &fakeEventBroadcaster{
+				EventBroadcaster: record.NewBroadcaster(),
+				recorder:         recorder,
+			}
+		})
+		vsKey := namespace + "/" + vs.Name
+		tsKey := namespace + "/" + ts.Name
+		respChan := make(chan resourceStatusMeta, 1)
+		respChan <- resourceStatusMeta{
+			accepted:  false,
+			message:   "code: 422",
+			resources: map[string]string{vsKey: VirtualServer, tsKey: TransportServer},
+			failures:  map[string]string{vsKey: "/test/app/pool1: invalid monitor"},
+		}
+		close(respChan)
+		mockCRM.responseHandler(respChan)
+
+		Expect(recorder.Events).To(HaveLen(1), "Event should be recorded only for the failed resource")
+		Expect(<-recorder.Events).To(Equal("Warning " + ReasonAS3Rejected + " /test/app/pool1: invalid monitor"))
+		updatedVS, err := mockCRM.kubeCRClient.CisV1().VirtualServers(namespace).Get(
+			context.TODO(), vs.Name, metav1.GetOptions{})
+		Expect(err).To(BeNil())
+		cond := meta.FindStatusCondition(updatedVS.Status.Conditions, cisapiv1.ConditionBIGIPAccepted)
+		Expect(cond).NotTo(BeNil())
+		Expect(cond.Message).To(Equal("/test/app/pool1: invalid monitor"))
+	})
+
+	It("Maps AS3 Failures to Custom Resources", func() {
+		vsKey := namespace + "/" + vs.Name
+		tsKey := namespace + "/" + ts.Name
+		reqMeta := requestMeta{
+			resources: map[string]string{vsKey: VirtualServer, tsKey: TransportServer},
+			objects: map[string][]string{
+				"vs_pool": {vsKey},
+				"ts_pool": {tsKey},
+			},
+		}
+		Expect(getResourceFailures(reqMeta, nil)).To(BeNil())
+
+		failures := getResourceFailures(reqMeta, []rsc.AS3Failure{
+			{Tenant: "test", Objects: []string{"vs_pool"}, Message: "invalid pool"},
+		})
+		Expect(failures).To(Equal(map[string]string{vsKey: "invalid pool"}))
+
+		// Failure without a known object is reported on all resources
+		failures = getResourceFailures(reqMeta, []rsc.AS3Failure{
+			{Tenant: "test", Objects: []string{"vs_pool"}, Message: "invalid pool"},
+			{Tenant: "test", Message: "declaration failed"},
+		})
+		Expect(failures).To(Equal(map[string]string{
+			vsKey: "invalid pool; declaration failed",
+			tsKey: "declaration failed",
+		}))
+	})
+
+	It("Dry-run mode skips status updates, events and IPAM allocations", func() {
+		mockCRM.dryRun = true
+		recorder := record.NewFakeRecorder(10)
+		mockCRM.eventNotifier = apm.NewEventNotifier(func() record.EventBroadcaster {
+			return &fakeEventBroadcaster{
+				EventBroadcaster: record.NewBroadcaster(),
+				recorder:         recorder,
+			}
+		})
+		actions := len(mockCRM.kubeCRClient.(*crdfake.Clientset).Actions())
+		mockCRM.updateVirtualServerStatus(
+			vs,
+			"1.2.3.4",
+			newCondition(cisapiv1.ConditionValid, true, ReasonValidated, ""),
+		)
+		Expect(mockCRM.kubeCRClient.(*crdfake.Clientset).Actions()).To(HaveLen(actions),
+			"Status updated in dry-run mode")
+		mockCRM.recordResourceEvent(vs, v1.EventTypeWarning, ReasonAS3Rejected, "declaration failed")
+		Expect(recorder.Events).To(BeEmpty(), "Event recorded in dry-run mode")
+
+		_, _ = mockCRM.kubeClient.CoreV1().ConfigMaps("kube-system").Create(context.TODO(), &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "cis-ipam",
+				Namespace: "kube-system",
+			},
+			Data: map[string]string{
+				"test": "10.8.0.0/30",
+			},
+		}, metav1.CreateOptions{})
+		prov := newStaticIPAMProvider(mockCRM.kubeClient, "kube-system", "cis-ipam")
+		mockCRM.ipamProvider = prov
+		key := namespace + "/SampleTS_ts"
+		Expect(mockCRM.requestIP("test", "", key)).To(BeEmpty(), "Address allocated in dry-run mode")
+		Expect(prov.RequestIP("test", "", key)).To(Equal("10.8.0.1"))
+		Expect(mockCRM.requestIP("test", "", key)).To(Equal("10.8.0.1"), "Allocated address not found")
+		Expect(mockCRM.releaseIP("test", "", key)).To(Equal("10.8.0.1"))
+		Expect(prov.LookupIP("test", "", key)).To(Equal("10.8.0.1"), "Address released in dry-run mode")
+	})
+
+	Describe("PostManager Response Notification", func() {
+		var mockPM *mockPostManager
+		var cfg config
+
+		BeforeEach(func() {
+			mockPM = newMockPostManger()
+			mockPM.respChan = make(chan resourceStatusMeta, 1)
+			cfg = config{
+				reqMeta: requestMeta{
+					resources: map[string]string{namespace + "/" + vs.Name: VirtualServer},
+				},
+			}
+		})
+
+		It("Notifies accepted declaration", func() {
+			mockPM.setResponses([]int{http.StatusOK}, "", http.MethodPost)
+			Expect(mockPM.postConfig(cfg)).To(BeTrue())
+			rscStatus := <-mockPM.respChan
+			Expect(rscStatus.accepted).To(BeTrue())
+			Expect(rscStatus.resources).To(Equal(cfg.reqMeta.resources))
+		})
+
+		It("Notifies rejected declaration", func() {
+			mockPM.setResponses([]int{http.StatusNotFound}, "", http.MethodPost)
+			Expect(mockPM.postConfig(cfg)).To(BeTrue())
+			rscStatus := <-mockPM.respChan
+			Expect(rscStatus.accepted).To(BeFalse())
+			Expect(rscStatus.message).To(ContainSubstring("code: 404"))
+		})
+
+		It("Does not block on a response not yet handled", func() {
+			mockPM.setResponses([]int{http.StatusNotFound, http.StatusOK}, "", http.MethodPost)
+			Expect(mockPM.postConfig(cfg)).To(BeTrue())
+			cfg.data = "{}"
+			Expect(mockPM.postConfig(cfg)).To(BeTrue())
+			Expect(mockPM.respChan).To(HaveLen(1))
+			rscStatus := <-mockPM.respChan
+			Expect(rscStatus.accepted).To(BeTrue(), "Latest response should be retained")
+		})
+	})
+})
@@ -21,12 +21,10 @@ import (
 
 	"github.com/F5Networks/k8s-bigip-ctlr/pkg/teem"
 
-	"github.com/F5Networks/f5-ipam-controller/pkg/ipammachinery"
 	"github.com/F5Networks/k8s-bigip-ctlr/config/client/clientset/versioned"
 	apm "github.com/F5Networks/k8s-bigip-ctlr/pkg/appmanager"
 	"github.com/F5Networks/k8s-bigip-ctlr/pkg/pollers"
 	"github.com/F5Networks/k8s-bigip-ctlr/pkg/writer"
-	v1 "k8s.io/api/core/v1"
 	extClient "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
@@ -56,14 +54,26 @@ type (
 		oldNodes           []Node
 		UseNodeInternal    bool
 		initState          bool
-		SSLContext         map[string]*v1.Secret
-		dgPath             string
 		shareNodes         bool
-		ipamCli            *ipammachinery.IPAMClient
-		ipamCR             string
+		ipamProvider       IPAMProvider
+		ipamPending        map[string]*ipamPendingRequest
 		defaultRouteDomain int
 		TeemData           *teem.TeemsData
+		// Set in dry-run mode, the resources are processed without side effects on the cluster
+		dryRun bool
+		// Followers of the leader election process the resources without posting them
+		standbyMutex sync.Mutex
+		standby      bool
+		// IPAM releases skipped in standby, replayed once elected as the leader
+		skippedReleases []ipamRelease
 	}
+	// ipamRelease identifies the address of a resource to release from IPAM
+	ipamRelease struct {
+		ipamLabel string
+		host      string
+		key       string
+	}
+
 	// Params defines parameters
 	Params struct {
 		Config             *rest.Config
@@ -79,19 +89,23 @@ type (
 		NodeLabelSelector  string
 		ShareNodes         bool
 		IPAM               bool
+		IPAMConfigMap      string
 		DefaultRouteDomain int
+		DryRun             bool
+		LeaderElection     bool
 	}
 	// CRInformer defines the structure of Custom Resource Informer
 	CRInformer struct {
-		namespace    string
-		stopCh       chan struct{}
-		svcInformer  cache.SharedIndexInformer
-		epsInformer  cache.SharedIndexInformer
-		vsInformer   cache.SharedIndexInformer
-		tlsInformer  cache.SharedIndexInformer
-		tsInformer   cache.SharedIndexInformer
-		ilInformer   cache.SharedIndexInformer
-		ednsInformer cache.SharedIndexInformer
+		namespace      string
+		stopCh         chan struct{}
+		svcInformer    cache.SharedIndexInformer
+		epsInformer    cache.SharedIndexInformer
+		secretInformer cache.SharedIndexInformer
+		vsInformer     cache.SharedIndexInformer
+		tlsInformer    cache.SharedIndexInformer
+		tsInformer     cache.SharedIndexInformer
+		ilInformer     cache.SharedIndexInformer
+		ednsInformer   cache.SharedIndexInformer
 	}
 
 	NSInformer struct {
@@ -111,6 +125,9 @@ type (
 		ResourceType string
 		rscName      string
 		hosts        []string
+		// baseResources holds the custom resources contributing to the config
+		// as namespace/name to kind
+		baseResources map[string]string
 	}
 
 	// Virtual Server Key - unique server is Name + Port
@@ -142,10 +159,38 @@ type (
 		Source                 string                `json:"source,omitempty"`
 		AllowVLANs             []string              `json:"allowVlans,omitempty"`
 		PersistenceMethods     []string              `json:"-"`
+		Persistence            *Persistence          `json:"-"`
+		ProfileSpec            *ProfileSpec          `json:"-"`
+		AllowSourceRange       []string              `json:"-"`
+		DenySourceRange        []string              `json:"-"`
+		RouteDomain            int32                 `json:"-"`
 	}
 	// Virtuals is slice of virtuals
 	Virtuals []Virtual
 
+	// Persistence defines the session persistence of a virtual
+	Persistence struct {
+		Method       string
+		CookieMethod string
+		CookieName   string
+		Duration     int32
+		IRule        string
+		Reference    string
+		Fallback     string
+	}
+
+	// ProfileSpec defines the BIG-IP profiles referenced by a virtual
+	ProfileSpec struct {
+		TCPClient       string
+		TCPServer       string
+		HTTP            string
+		HTTP2           string
+		HTTPCompression string
+		WebAcceleration string
+		OneConnect      string
+		RequestLogging  string
+	}
+
 	// ServiceAddress Service IP address definition (BIG-IP virtual-address).
 	ServiceAddress struct {
 		ArpEnabled         bool   `json:"arpEnabled,omitempty"`
@@ -214,6 +259,8 @@ type (
 		shareNodes         bool
 		dnsConfig          DNSConfig
 		defaultRouteDomain int
+		// Partitions to be declared even without resources
+		partitions map[string]struct{}
 	}
 
 	// Pool config
@@ -225,6 +272,11 @@ type (
 		Members         []Member `json:"members"`
 		NodeMemberLabel string   `json:"-"`
 		MonitorNames    []string `json:"monitors,omitempty"`
+		Balance         string   `json:"loadBalancingMode,omitempty"`
+		SlowRampTime    *int32   `json:"slowRampTime,omitempty"`
+		MinimumMonitors int32    `json:"minimumMonitors,omitempty"`
+		ConnectionLimit int32    `json:"connectionLimit,omitempty"`
+		RateLimit       int32    `json:"rateLimit,omitempty"`
 	}
 	// Pools is slice of pool
 	Pools []Pool
@@ -239,6 +291,11 @@ type (
 		Recv       string `json:"recv"`
 		Timeout    int    `json:"timeout,omitempty"`
 		TargetPort int32  `json:"targetPort,omitempty"`
+		ServerName string `json:"serverName,omitempty"`
+		QueryName  string `json:"queryName,omitempty"`
+		QueryType  string `json:"queryType,omitempty"`
+		ClientCert string `json:"-"`
+		ClientKey  string `json:"-"`
 	}
 	// Monitors  is slice of monitor
 	Monitors []Monitor
@@ -261,20 +318,21 @@ type (
 
 	// action config for a Rule
 	action struct {
-		Name      string `json:"name"`
-		Pool      string `json:"pool,omitempty"`
-		HTTPHost  bool   `json:"httpHost,omitempty"`
-		HttpReply bool   `json:"httpReply,omitempty"`
-		HTTPURI   bool   `json:"httpUri,omitempty"`
-		Forward   bool   `json:"forward,omitempty"`
-		Location  string `json:"location,omitempty"`
-		Path      string `json:"path,omitempty"`
-		Redirect  bool   `json:"redirect,omitempty"`
-		Replace   bool   `json:"replace,omitempty"`
-		Request   bool   `json:"request,omitempty"`
-		Reset     bool   `json:"reset,omitempty"`
-		Select    bool   `json:"select,omitempty"`
-		Value     string `json:"value,omitempty"`
+		Name           string `json:"name"`
+		Pool           string `json:"pool,omitempty"`
+		ClientAccepted bool   `json:"clientAccepted,omitempty"`
+		HTTPHost       bool   `json:"httpHost,omitempty"`
+		HttpReply      bool   `json:"httpReply,omitempty"`
+		HTTPURI        bool   `json:"httpUri,omitempty"`
+		Forward        bool   `json:"forward,omitempty"`
+		Location       string `json:"location,omitempty"`
+		Path           string `json:"path,omitempty"`
+		Redirect       bool   `json:"redirect,omitempty"`
+		Replace        bool   `json:"replace,omitempty"`
+		Request        bool   `json:"request,omitempty"`
+		Reset          bool   `json:"reset,omitempty"`
+		Select         bool   `json:"select,omitempty"`
+		Value          string `json:"value,omitempty"`
 	}
 
 	// condition config for a Rule
@@ -282,6 +340,7 @@ type (
 		Name            string   `json:"name"`
 		Address         bool     `json:"address,omitempty"`
 		CaseInsensitive bool     `json:"caseInsensitive,omitempty"`
+		ClientAccepted  bool     `json:"clientAccepted,omitempty"`
 		Equals          bool     `json:"equals,omitempty"`
 		EndsWith        bool     `json:"endsWith,omitempty"`
 		External        bool     `json:"external,omitempty"`
@@ -290,6 +349,7 @@ type (
 		HTTPURI         bool     `json:"httpUri,omitempty"`
 		Index           int      `json:"index,omitempty"`
 		Matches         bool     `json:"matches,omitempty"`
+		Not             bool     `json:"not,omitempty"`
 		Path            bool     `json:"path,omitempty"`
 		PathSegment     bool     `json:"pathSegment,omitempty"`
 		Present         bool     `json:"present,omitempty"`
@@ -391,6 +451,11 @@ type (
 		PythonDriverPID int
 		activeDecl      as3Declaration
 		userAgent       string
+		// Partitions declared as AS3 Tenants, partitions without resources
+		// are declared empty to remove their configuration from BIG-IP
+		partitions map[string]struct{}
+		// Writes the declarations instead of posting them to BIG-IP in dry-run mode
+		declWriter *writer.DeclarationWriter
 	}
 
 	AgentParams struct {
@@ -403,6 +468,8 @@ type (
 		VXLANName      string
 		PythonBaseDir  string
 		UserAgent      string
+		// Set in dry-run mode, the declarations are not posted to BIG-IP
+		DeclarationWriter *writer.DeclarationWriter
 	}
 
 	globalSection struct {
@@ -475,6 +542,7 @@ type (
 		Type        string                  `json:"type,omitempty"`
 		Name        string                  `json:"name,omitempty"`
 		Event       string                  `json:"event,omitempty"`
+		Address     *as3PolicyCompareString `json:"address,omitempty"`
 		All         *as3PolicyCompareString `json:"all,omitempty"`
 		Index       int                     `json:"index,omitempty"`
 		Host        *as3PolicyCompareString `json:"host,omitempty"`
@@ -504,6 +572,8 @@ type (
 	as3Pool struct {
 		Class             string               `json:"class,omitempty"`
 		LoadBalancingMode string               `json:"loadBalancingMode,omitempty"`
+		SlowRampTime      *int32               `json:"slowRampTime,omitempty"`
+		MinimumMonitors   as3MultiTypeParam    `json:"minimumMonitors,omitempty"`
 		Members           []as3PoolMember      `json:"members,omitempty"`
 		Monitors          []as3ResourcePointer `json:"monitors,omitempty"`
 	}
@@ -514,6 +584,8 @@ type (
 		ServerAddresses  []string `json:"serverAddresses,omitempty"`
 		ServicePort      int32    `json:"servicePort,omitempty"`
 		ShareNodes       bool     `json:"shareNodes,omitempty"`
+		ConnectionLimit  int32    `json:"connectionLimit,omitempty"`
+		RateLimit        int32    `json:"rateLimit,omitempty"`
 	}
 
 	// as3ResourcePointer maps to following in AS3 Resources
@@ -550,7 +622,25 @@ type (
 		WAF                    as3MultiTypeParam    `json:"policyWAF,omitempty"`
 		ProfileL4              string               `json:"profileL4,omitempty"`
 		AllowVLANs             []as3ResourcePointer `json:"allowVlans,omitempty"`
-		PersistenceMethods     []string             `json:"persistenceMethods,omitempty"`
+		PersistenceMethods     []as3MultiTypeParam  `json:"persistenceMethods,omitempty"`
+		FallbackPersistence    as3MultiTypeParam    `json:"fallbackPersistenceMethod,omitempty"`
+		ProfileTCP             as3MultiTypeParam    `json:"profileTCP,omitempty"`
+		ProfileHTTP            as3MultiTypeParam    `json:"profileHTTP,omitempty"`
+		ProfileHTTP2           as3MultiTypeParam    `json:"profileHTTP2,omitempty"`
+		ProfileHTTPCompression as3MultiTypeParam    `json:"profileHTTPCompression,omitempty"`
+		ProfileAcceleration    as3MultiTypeParam    `json:"profileHTTPAcceleration,omitempty"`
+		ProfileMultiplex       as3MultiTypeParam    `json:"profileMultiplex,omitempty"`
+		ProfileTrafficLog      as3MultiTypeParam    `json:"profileTrafficLog,omitempty"`
+	}
+
+	// as3Persist maps to Persist in AS3 Resources
+	as3Persist struct {
+		Class             string              `json:"class,omitempty"`
+		PersistenceMethod string              `json:"persistenceMethod,omitempty"`
+		CookieMethod      string              `json:"cookieMethod,omitempty"`
+		CookieName        string              `json:"cookieName,omitempty"`
+		Duration          int32               `json:"duration,omitempty"`
+		IRule             *as3ResourcePointer `json:"iRule,omitempty"`
 	}
 
 	// as3ServiceAddress maps to VirtualAddress in AS3 Resources
@@ -569,19 +659,22 @@ type (
 	// - Monitor_HTTP
 	// - Monitor_HTTPS
 	as3Monitor struct {
-		Class             string  `json:"class,omitempty"`
-		Interval          int     `json:"interval,omitempty"`
-		MonitorType       string  `json:"monitorType,omitempty"`
-		TargetAddress     *string `json:"targetAddress,omitempty"`
-		Timeout           int     `json:"timeout,omitempty"`
-		TimeUnitilUp      *int    `json:"timeUntilUp,omitempty"`
-		Adaptive          *bool   `json:"adaptive,omitempty"`
-		Dscp              *int    `json:"dscp,omitempty"`
-		Receive           string  `json:"receive"`
-		Send              string  `json:"send"`
-		TargetPort        *int32  `json:"targetPort,omitempty"`
-		ClientCertificate string  `json:"clientCertificate,omitempty"`
-		Ciphers           string  `json:"ciphers,omitempty"`
+		Class             string              `json:"class,omitempty"`
+		Interval          int                 `json:"interval,omitempty"`
+		MonitorType       string              `json:"monitorType,omitempty"`
+		TargetAddress     *string             `json:"targetAddress,omitempty"`
+		Timeout           int                 `json:"timeout,omitempty"`
+		TimeUnitilUp      *int                `json:"timeUntilUp,omitempty"`
+		Adaptive          *bool               `json:"adaptive,omitempty"`
+		Dscp              *int                `json:"dscp,omitempty"`
+		Receive           string              `json:"receive,omitempty"`
+		Send              string              `json:"send,omitempty"`
+		TargetPort        *int32              `json:"targetPort,omitempty"`
+		ClientCertificate string              `json:"clientCertificate,omitempty"`
+		ClientTLS         *as3ResourcePointer `json:"clientTLS,omitempty"`
+		Ciphers           string              `json:"ciphers,omitempty"`
+		QueryName         string              `json:"queryName,omitempty"`
+		QueryType         string              `json:"queryType,omitempty"`
 	}
 
 	// as3CABundle maps to CA_Bundle in AS3 Resources
@@ -600,16 +693,19 @@ type (
 
 	// as3TLSServer maps to TLS_Server in AS3 Resources
 	as3TLSServer struct {
-		Class         string                     `json:"class,omitempty"`
-		Certificates  []as3TLSServerCertificates `json:"certificates,omitempty"`
-		Ciphers       string                     `json:"ciphers,omitempty"`
-		CipherGroup   *as3ResourcePointer        `json:"cipherGroup,omitempty"`
-		Tls1_3Enabled bool                       `json:"tls1_3Enabled,omitempty"`
+		Class                 string                     `json:"class,omitempty"`
+		Certificates          []as3TLSServerCertificates `json:"certificates,omitempty"`
+		Ciphers               string                     `json:"ciphers,omitempty"`
+		CipherGroup           *as3ResourcePointer        `json:"cipherGroup,omitempty"`
+		Tls1_3Enabled         bool                       `json:"tls1_3Enabled,omitempty"`
+		AuthenticationMode    string                     `json:"authenticationMode,omitempty"`
+		AuthenticationTrustCA string                     `json:"authenticationTrustCA,omitempty"`
 	}
 
 	// as3TLSServerCertificates maps to TLS_Server_certificates in AS3 Resources
 	as3TLSServerCertificates struct {
 		Certificate string `json:"certificate,omitempty"`
+		MatchToSNI  string `json:"matchToSNI,omitempty"`
 	}
 
 	// as3TLSClient maps to TLS_Client in AS3 Resources
@@ -617,6 +713,8 @@ type (
 		Class               string              `json:"class,omitempty"`
 		TrustCA             *as3ResourcePointer `json:"trustCA,omitempty"`
 		ValidateCertificate bool                `json:"validateCertificate,omitempty"`
+		ServerName          string              `json:"serverName,omitempty"`
+		SendSNI             string              `json:"sendSNI,omitempty"`
 	}
 
 	// as3DataGroup maps to Data_Group in AS3 Resources
@@ -18,14 +18,20 @@ package crmanager
 
 import (
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
 
 	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/config/apis/cis/v1"
+	rsc "github.com/F5Networks/k8s-bigip-ctlr/pkg/resource"
 	log "github.com/F5Networks/k8s-bigip-ctlr/pkg/vlogger"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func (crMgr *CRManager) checkValidVirtualServer(
 	vsResource *cisapiv1.VirtualServer,
-) bool {
+) (bool, metav1.Condition) {
 
 	vsNamespace := vsResource.ObjectMeta.Namespace
 	vsName := vsResource.ObjectMeta.Name
@@ -34,37 +40,91 @@ func (crMgr *CRManager) checkValidVirtualServer(
 	crInf, ok := crMgr.getNamespacedInformer(vsNamespace)
 	if !ok {
 		log.Errorf("Informer not found for namespace: %v", vsNamespace)
-		return false
+		return false, metav1.Condition{}
 	}
 	// Check if the virtual exists and valid for us.
 	_, virtualFound, _ := crInf.vsInformer.GetIndexer().GetByKey(vkey)
 	if !virtualFound {
 		log.Infof("VirtualServer %s is invalid", vsName)
-		return false
+		return false, metav1.Condition{}
 	}
 	bindAddr := vsResource.Spec.VirtualServerAddress
-	if crMgr.ipamCli == nil {
+	if crMgr.ipamProvider == nil {
 
 		// This ensures that pool-only mode only logs the message below the first
 		// time we see a config.
 		if bindAddr == "" {
-			log.Infof("No IP was specified for the virtual server %s", vsName)
-			return false
+			msg := fmt.Sprintf("No IP was specified for the virtual server %s", vsName)
+			log.Infof("%s", msg)
+			return false, newCondition(cisapiv1.ConditionValid, false, ReasonMissingAddress, msg)
 		}
 	} else {
 		ipamLabel := vsResource.Spec.IPAMLabel
 		if ipamLabel == "" && bindAddr == "" {
-			log.Infof("No ipamLabel was specified for the virtual server %s", vsName)
-			return false
+			msg := fmt.Sprintf("No ipamLabel was specified for the virtual server %s", vsName)
+			log.Infof("%s", msg)
+			return false, newCondition(cisapiv1.ConditionValid, false, ReasonMissingIPAMLabel, msg)
+		}
+	}
+
+	// Alternate backends are selected by host and path
+	if vsResource.Spec.Host == "" {
+		for _, pl := range vsResource.Spec.Pools {
+			if len(pl.AlternateBackends) > 0 {
+				msg := fmt.Sprintf("Alternate backends require a host for the virtual server %s", vsName)
+				log.Errorf("%s", msg)
+				return false, newCondition(cisapiv1.ConditionValid, false, ReasonInvalidSpec, msg)
+			}
 		}
 	}
 
-	return true
+	if ok, msg := checkValidPartition(vsResource.Spec.Partition); !ok {
+		log.Errorf("%s for virtual server %s", msg, vsName)
+		return false, newCondition(cisapiv1.ConditionValid, false, ReasonInvalidSpec, msg)
+	}
+
+	if ok, msg := crMgr.checkValidDualStack(bindAddr, vsResource.Spec.IPv6VirtualServerAddress,
+		vsResource.Spec.IPv6IPAMLabel); !ok {
+		log.Errorf("%s for virtual server %s", msg, vsName)
+		return false, newCondition(cisapiv1.ConditionValid, false, ReasonInvalidSpec, msg)
+	}
+
+	if ok, msg := checkValidRouteDomain(vsResource.Spec.RouteDomain, bindAddr,
+		vsResource.Spec.IPv6VirtualServerAddress); !ok {
+		log.Errorf("%s for virtual server %s", msg, vsName)
+		return false, newCondition(cisapiv1.ConditionValid, false, ReasonInvalidSpec, msg)
+	}
+
+	for _, pl := range vsResource.Spec.Pools {
+		if ok, msg := checkValidPoolMonitors(pl); !ok {
+			log.Errorf("%s for virtual server %s", msg, vsName)
+			return false, newCondition(cisapiv1.ConditionValid, false, ReasonInvalidSpec, msg)
+		}
+	}
+
+	if ok, msg := checkValidPersistence(vsResource.Spec.Persistence, true); !ok {
+		log.Errorf("%s for virtual server %s", msg, vsName)
+		return false, newCondition(cisapiv1.ConditionValid, false, ReasonInvalidSpec, msg)
+	}
+
+	if ok, msg := checkValidProfiles(vsResource.Spec.Profiles, true, true,
+		vsResource.Spec.TLSProfileName != ""); !ok {
+		log.Errorf("%s for virtual server %s", msg, vsName)
+		return false, newCondition(cisapiv1.ConditionValid, false, ReasonInvalidSpec, msg)
+	}
+
+	if ok, msg := checkValidSourceRanges(vsResource.Spec.AllowSourceRange,
+		vsResource.Spec.DenySourceRange); !ok {
+		log.Errorf("%s for virtual server %s", msg, vsName)
+		return false, newCondition(cisapiv1.ConditionValid, false, ReasonInvalidSpec, msg)
+	}
+
+	return true, newCondition(cisapiv1.ConditionValid, true, ReasonValidated, "")
 }
 
 func (crMgr *CRManager) checkValidTransportServer(
 	tsResource *cisapiv1.TransportServer,
-) bool {
+) (bool, metav1.Condition) {
 
 	vsNamespace := tsResource.ObjectMeta.Namespace
 	vsName := tsResource.ObjectMeta.Name
@@ -73,45 +133,87 @@ func (crMgr *CRManager) checkValidTransportServer(
 	crInf, ok := crMgr.getNamespacedInformer(vsNamespace)
 	if !ok {
 		log.Errorf("Informer not found for namespace: %v", vsNamespace)
-		return false
+		return false, metav1.Condition{}
 	}
 	// Check if the virtual exists and valid for us.
 	_, virtualFound, _ := crInf.tsInformer.GetIndexer().GetByKey(vkey)
 	if !virtualFound {
 		log.Infof("TransportServer %s is invalid", vsName)
-		return false
+		return false, metav1.Condition{}
 	}
 
 	bindAddr := tsResource.Spec.VirtualServerAddress
 
-	if crMgr.ipamCli == nil {
+	if crMgr.ipamProvider == nil {
 		// This ensures that pool-only mode only logs the message below the first
 		// time we see a config.
 		if bindAddr == "" {
-			log.Infof("No IP was specified for the transport server %s", vsName)
-			return false
+			msg := fmt.Sprintf("No IP was specified for the transport server %s", vsName)
+			log.Infof("%s", msg)
+			return false, newCondition(cisapiv1.ConditionValid, false, ReasonMissingAddress, msg)
 		}
 	} else {
 		ipamLabel := tsResource.Spec.IPAMLabel
 		if ipamLabel == "" && bindAddr == "" {
-			log.Infof("No ipamLabel was specified for the transport server %s", vsName)
-			return false
+			msg := fmt.Sprintf("No ipamLabel was specified for the transport server %s", vsName)
+			log.Infof("%s", msg)
+			return false, newCondition(cisapiv1.ConditionValid, false, ReasonMissingIPAMLabel, msg)
 		}
 	}
 
 	if tsResource.Spec.Type == "" {
 		tsResource.Spec.Type = "tcp"
 	} else if !(tsResource.Spec.Type == "udp" || tsResource.Spec.Type == "tcp") {
-		log.Errorf("Invalid type value for transport server %s. Supported values are tcp and udp only", vsName)
-		return false
+		msg := fmt.Sprintf("Invalid type value for transport server %s. Supported values are tcp and udp only", vsName)
+		log.Errorf("%s", msg)
+		return false, newCondition(cisapiv1.ConditionValid, false, ReasonInvalidSpec, msg)
+	}
+
+	if ok, msg := checkValidPartition(tsResource.Spec.Partition); !ok {
+		log.Errorf("%s for transport server %s", msg, vsName)
+		return false, newCondition(cisapiv1.ConditionValid, false, ReasonInvalidSpec, msg)
 	}
 
-	return true
+	if ok, msg := crMgr.checkValidDualStack(bindAddr, tsResource.Spec.IPv6VirtualServerAddress,
+		tsResource.Spec.IPv6IPAMLabel); !ok {
+		log.Errorf("%s for transport server %s", msg, vsName)
+		return false, newCondition(cisapiv1.ConditionValid, false, ReasonInvalidSpec, msg)
+	}
+
+	if ok, msg := checkValidRouteDomain(tsResource.Spec.RouteDomain, bindAddr,
+		tsResource.Spec.IPv6VirtualServerAddress); !ok {
+		log.Errorf("%s for transport server %s", msg, vsName)
+		return false, newCondition(cisapiv1.ConditionValid, false, ReasonInvalidSpec, msg)
+	}
+
+	if ok, msg := checkValidPoolMonitors(tsResource.Spec.Pool); !ok {
+		log.Errorf("%s for transport server %s", msg, vsName)
+		return false, newCondition(cisapiv1.ConditionValid, false, ReasonInvalidSpec, msg)
+	}
+
+	if ok, msg := checkValidPersistence(tsResource.Spec.Persistence, false); !ok {
+		log.Errorf("%s for transport server %s", msg, vsName)
+		return false, newCondition(cisapiv1.ConditionValid, false, ReasonInvalidSpec, msg)
+	}
+
+	if ok, msg := checkValidProfiles(tsResource.Spec.Profiles, false,
+		tsResource.Spec.Type == "tcp" && tsResource.Spec.Mode == "standard", false); !ok {
+		log.Errorf("%s for transport server %s", msg, vsName)
+		return false, newCondition(cisapiv1.ConditionValid, false, ReasonInvalidSpec, msg)
+	}
+
+	if ok, msg := checkValidSourceRanges(tsResource.Spec.AllowSourceRange,
+		tsResource.Spec.DenySourceRange); !ok {
+		log.Errorf("%s for transport server %s", msg, vsName)
+		return false, newCondition(cisapiv1.ConditionValid, false, ReasonInvalidSpec, msg)
+	}
+
+	return true, newCondition(cisapiv1.ConditionValid, true, ReasonValidated, "")
 }
 
 func (crMgr *CRManager) checkValidIngressLink(
 	il *cisapiv1.IngressLink,
-) bool {
+) (bool, metav1.Condition) {
 
 	ilNamespace := il.ObjectMeta.Namespace
 	ilName := il.ObjectMeta.Name
@@ -120,28 +222,206 @@ func (crMgr *CRManager) checkValidIngressLink(
 	crInf, ok := crMgr.getNamespacedInformer(ilNamespace)
 	if !ok {
 		log.Errorf("Informer not found for namespace: %v", ilNamespace)
-		return false
+		return false, metav1.Condition{}
 	}
 	// Check if the virtual exists and valid for us.
 	_, virtualFound, _ := crInf.ilInformer.GetIndexer().GetByKey(ilkey)
 	if !virtualFound {
 		log.Infof("IngressLink %s is invalid", ilName)
-		return false
+		return false, metav1.Condition{}
 	}
 
 	bindAddr := il.Spec.VirtualServerAddress
 
-	if crMgr.ipamCli == nil {
+	if crMgr.ipamProvider == nil {
 		if bindAddr == "" {
-			log.Infof("No IP was specified for ingresslink %s", ilName)
-			return false
+			msg := fmt.Sprintf("No IP was specified for ingresslink %s", ilName)
+			log.Infof("%s", msg)
+			return false, newCondition(cisapiv1.ConditionValid, false, ReasonMissingAddress, msg)
 		}
 	} else {
 		ipamLabel := il.Spec.IPAMLabel
 		if ipamLabel == "" && bindAddr == "" {
-			log.Infof("No ipamLabel was specified for the il server %s", ilName)
-			return false
+			msg := fmt.Sprintf("No ipamLabel was specified for the il server %s", ilName)
+			log.Infof("%s", msg)
+			return false, newCondition(cisapiv1.ConditionValid, false, ReasonMissingIPAMLabel, msg)
+		}
+	}
+
+	if ok, msg := checkValidRouteDomain(il.Spec.RouteDomain, bindAddr); !ok {
+		log.Errorf("%s for ingresslink %s", msg, ilName)
+		return false, newCondition(cisapiv1.ConditionValid, false, ReasonInvalidSpec, msg)
+	}
+	return true, newCondition(cisapiv1.ConditionValid, true, ReasonValidated, "")
+}
+
+// checkValidPartition verifies the partition of a custom resource, the Common
+// partition is shared with the BIG-IP and cannot be managed by CIS
+func checkValidPartition(partition string) (bool, string) {
+	if partition == "Common" {
+		return false, "Partition Common is not supported"
+	}
+	return true, ""
+}
+
+// checkValidDualStack verifies the IPv6 address or IPv6 IPAM label of a dual-stack
+// custom resource, the primary address of a dual-stack resource is an IPv4 address
+func (crMgr *CRManager) checkValidDualStack(bindAddr, ipv6Addr, ipv6IPAMLabel string) (bool, string) {
+	if ipv6Addr == "" && ipv6IPAMLabel == "" {
+		return true, ""
+	}
+	if ipv6Addr != "" {
+		ip, _ := rsc.Split_ip_with_route_domain(ipv6Addr)
+		if addr := net.ParseIP(ip); addr == nil || addr.To4() != nil {
+			return false, fmt.Sprintf("Invalid IPv6 address %s", ipv6Addr)
+		}
+	} else if crMgr.ipamProvider == nil {
+		return false, "ipv6IpamLabel requires IPAM to be enabled"
+	}
+	if bindAddr != "" {
+		ip, _ := rsc.Split_ip_with_route_domain(bindAddr)
+		if addr := net.ParseIP(ip); addr == nil || addr.To4() == nil {
+			return false, fmt.Sprintf("Address %s of a dual-stack resource is not an IPv4 address", bindAddr)
+		}
+	}
+	return true, ""
+}
+
+// checkValidRouteDomain verifies the route domain of a custom resource, the
+// %<routeDomainID> suffix of its addresses must match the routeDomain if both are given
+func checkValidRouteDomain(routeDomain int32, addresses ...string) (bool, string) {
+	if routeDomain < 0 || routeDomain > maxRouteDomain {
+		return false, fmt.Sprintf("Invalid route domain %d", routeDomain)
+	}
+	expected, found := routeDomain, routeDomain != 0
+	for _, address := range addresses {
+		if !strings.Contains(address, "%") {
+			continue
+		}
+		_, rd := rsc.Split_ip_with_route_domain(address)
+		id, err := strconv.Atoi(rd)
+		if err != nil || id < 0 || int32(id) > maxRouteDomain {
+			return false, fmt.Sprintf("Invalid route domain of address %s", address)
+		}
+		if found && int32(id) != expected {
+			return false, fmt.Sprintf("Route domain of address %s does not match route domain %d", address, expected)
+		}
+		expected, found = int32(id), true
+	}
+	return true, ""
+}
+
+// checkValidMonitor verifies the options required by the type of a monitor
+// created by CIS, monitors referencing BIG-IP are not verified
+func checkValidMonitor(mon cisapiv1.Monitor) (bool, string) {
+	switch mon.Type {
+	case "http", "https":
+		if mon.Send == "" {
+			return false, fmt.Sprintf("send is required for %s monitor", mon.Type)
+		}
+	case "dns":
+		if mon.QueryName == "" {
+			return false, "queryName is required for dns monitor"
+		}
+	case "tcp", "udp", "icmp", "gateway-icmp":
+	default:
+		return false, fmt.Sprintf("Unsupported monitor type %q", mon.Type)
+	}
+	if mon.Type != "https" && (mon.ServerName != "" || mon.ClientCertificate != "") {
+		return false, fmt.Sprintf("serverName and clientCertificate are not supported for %s monitor", mon.Type)
+	}
+	return true, ""
+}
+
+// checkValidPoolMonitors verifies the monitors created by CIS for the pool
+func checkValidPoolMonitors(pl cisapiv1.Pool) (bool, string) {
+	for _, mon := range getPoolSpecMonitors(pl) {
+		if mon.Reference != "" {
+			continue
+		}
+		if ok, msg := checkValidMonitor(mon); !ok {
+			return false, fmt.Sprintf("Invalid monitor for pool of service %s: %s", pl.Service, msg)
+		}
+	}
+	return true, ""
+}
+
+// checkValidPersistence verifies the session persistence of a virtual, cookie
+// persistence is only supported for HTTP traffic
+func checkValidPersistence(persistence *cisapiv1.Persistence, http bool) (bool, string) {
+	if persistence == nil {
+		return true, ""
+	}
+	if persistence.Reference != "" {
+		if persistence.Method != "" {
+			return false, "Persistence method and reference are mutually exclusive"
+		}
+	} else {
+		switch persistence.Method {
+		case "cookie":
+			if !http {
+				return false, "Cookie persistence is only supported for HTTP traffic"
+			}
+			switch persistence.CookieMethod {
+			case "", "insert", "rewrite", "passive":
+			case "hash":
+				if persistence.CookieName == "" {
+					return false, "cookieName is required for hash cookie persistence"
+				}
+			default:
+				return false, fmt.Sprintf("Unsupported cookie method %q", persistence.CookieMethod)
+			}
+		case "universal":
+			if persistence.IRule == "" {
+				return false, "iRule is required for universal persistence"
+			}
+		case "source-address", "destination-address":
+		case "":
+			return false, "Persistence method or reference is required"
+		default:
+			return false, fmt.Sprintf("Unsupported persistence method %q", persistence.Method)
+		}
+	}
+	switch {
+	case persistence.Fallback == "", persistence.Fallback == "source-address",
+		persistence.Fallback == "destination-address", strings.HasPrefix(persistence.Fallback, "/"):
+	default:
+		return false, fmt.Sprintf("Unsupported fallback persistence %q", persistence.Fallback)
+	}
+	return true, ""
+}
+
+// checkValidProfiles verifies the BIG-IP profiles referenced by a virtual, HTTP
+// profiles require HTTP traffic, TCP profiles a full proxy TCP virtual and the
+// HTTP/2 profile a virtual terminating TLS
+func checkValidProfiles(profiles *cisapiv1.ProfileSpec, http, tcp, tls bool) (bool, string) {
+	if profiles == nil {
+		return true, ""
+	}
+	if !tcp && (profiles.TCP.Client != "" || profiles.TCP.Server != "") {
+		return false, "TCP profiles are only supported for standard TCP virtuals"
+	}
+	if !http && newProfileSpec(profiles).hasHTTPProfiles() {
+		return false, "HTTP profiles are only supported for HTTP virtuals"
+	}
+	if !tls && profiles.HTTP2 != "" {
+		return false, "HTTP/2 profile is only supported for virtuals with a TLSProfile"
+	}
+	return true, ""
+}
+
+// checkValidSourceRanges verifies the allowed and denied source address ranges
+// of a virtual, which are CIDRs or IP addresses
+func checkValidSourceRanges(sourceRanges ...[]string) (bool, string) {
+	for _, ranges := range sourceRanges {
+		for _, sourceRange := range ranges {
+			if _, _, err := net.ParseCIDR(sourceRange); err == nil {
+				continue
+			}
+			if net.ParseIP(sourceRange) == nil {
+				return false, fmt.Sprintf("Invalid source range %s", sourceRange)
+			}
 		}
 	}
-	return true
+	return true, ""
 }
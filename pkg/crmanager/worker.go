@@ -21,6 +21,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"net"
 	"reflect"
 	"sort"
 	"strings"
@@ -28,6 +29,8 @@ import (
 
 	ficV1 "github.com/F5Networks/f5-ipam-controller/pkg/ipamapis/apis/fic/v1"
 	cisapiv1 "github.com/F5Networks/k8s-bigip-ctlr/config/apis/cis/v1"
+	bigIPPrometheus "github.com/F5Networks/k8s-bigip-ctlr/pkg/prometheus"
+	rsc "github.com/F5Networks/k8s-bigip-ctlr/pkg/resource"
 	log "github.com/F5Networks/k8s-bigip-ctlr/pkg/vlogger"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -58,6 +61,10 @@ func (crMgr *CRManager) processResource() bool {
 	defer crMgr.rscQueue.Done(key)
 	rKey := key.(*rqKey)
 	log.Debugf("Processing Key: %v", rKey)
+	if !crMgr.refreshIPAMPending(rKey) {
+		return true
+	}
+	crMgr.resetIPAMPending(rKey)
 
 	// Check the type of resource and process accordingly.
 	switch rKey.kind {
@@ -87,6 +94,25 @@ func (crMgr *CRManager) processResource() bool {
 				isError = true
 			}
 		}
+	case K8sSecret:
+		if crMgr.initState {
+			break
+		}
+		// Only the VirtualServers using the Secret through TLSProfiles or monitors are processed
+		for _, virtual := range crMgr.getVirtualsForSecret(rKey.namespace, rKey.rscName) {
+			err := crMgr.processVirtualServers(virtual, false)
+			if err != nil {
+				utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+				isError = true
+			}
+		}
+		for _, virtual := range crMgr.getTransportServersForMonitorSecret(rKey.namespace, rKey.rscName) {
+			err := crMgr.processTransportServers(virtual, false)
+			if err != nil {
+				utilruntime.HandleError(fmt.Errorf("Sync %v failed with %v", key, err))
+				isError = true
+			}
+		}
 	case TransportServer:
 		virtual := rKey.rsc.(*cisapiv1.TransportServer)
 		err := crMgr.processTransportServers(virtual, rKey.rscDelete)
@@ -293,40 +319,54 @@ func (crMgr *CRManager) processResource() bool {
 			crMgr.crInformers[nsName].start()
 			log.Debugf("Added Namespace: '%v' to CIS scope", nsName)
 		}
+	case Leader:
+		// The configuration processed in standby is posted below
+		log.Info("Posting the configuration as the leader")
+		crMgr.replaySkippedReleases()
 	default:
 		log.Errorf("Unknown resource Kind: %v", rKey.kind)
 	}
 
+	// Resources waiting for IPAM are retried with backoff, in case the IPAM event is missed
+	ipamPending := crMgr.requeueIPAMPending(rKey)
 	if isError {
 		crMgr.rscQueue.AddRateLimited(key)
-	} else {
+	} else if !ipamPending {
 		crMgr.rscQueue.Forget(key)
 	}
 
 	if crMgr.rscQueue.Len() == 0 &&
 		(!reflect.DeepEqual(crMgr.resources.rsMap, crMgr.resources.oldRsMap) ||
 			!reflect.DeepEqual(crMgr.resources.dnsConfig, crMgr.resources.oldDNSConfig)) {
-		customProfileStore := NewCustomProfiles()
-		for _, rsCfg := range crMgr.resources.rsMap {
-			for skey, prof := range rsCfg.customProfiles.Profs {
-				customProfileStore.Profs[skey] = prof
-			}
+		// Only the leader posts the configuration, which is kept up to date in standby
+		if !crMgr.isStandby() {
+			config := crMgr.getResourceConfigWrapper()
+			go crMgr.TeemData.PostTeemsData()
+			crMgr.Agent.PostConfig(config)
+			crMgr.resources.updateOldConfig()
 		}
-		config := ResourceConfigWrapper{
-			rsCfgs:             crMgr.resources.GetAllResources(),
-			customProfiles:     customProfileStore,
-			shareNodes:         crMgr.shareNodes,
-			dnsConfig:          crMgr.resources.dnsConfig,
-			defaultRouteDomain: crMgr.defaultRouteDomain,
-		}
-		go crMgr.TeemData.PostTeemsData()
-		crMgr.Agent.PostConfig(config)
 		crMgr.initState = false
-		crMgr.resources.updateOldConfig()
 	}
 	return true
 }
 
+// getResourceConfigWrapper returns the current resource configs to be posted to BIG-IP
+func (crMgr *CRManager) getResourceConfigWrapper() ResourceConfigWrapper {
+	customProfileStore := NewCustomProfiles()
+	for _, rsCfg := range crMgr.resources.rsMap {
+		for skey, prof := range rsCfg.customProfiles.Profs {
+			customProfileStore.Profs[skey] = prof
+		}
+	}
+	return ResourceConfigWrapper{
+		rsCfgs:             crMgr.resources.GetAllResources(),
+		customProfiles:     customProfileStore,
+		shareNodes:         crMgr.shareNodes,
+		dnsConfig:          crMgr.resources.dnsConfig,
+		defaultRouteDomain: crMgr.defaultRouteDomain,
+	}
+}
+
 // getServiceForEndpoints returns the service associated with endpoints.
 func (crMgr *CRManager) getServiceForEndpoints(ep *v1.Endpoints) *v1.Service {
 
@@ -417,6 +457,100 @@ func (crMgr *CRManager) getVirtualsForTLSProfile(tls *cisapiv1.TLSProfile) []*ci
 	return virtualsForTLSProfile
 }
 
+// getTLSProfilesForSecret returns the TLSProfiles referencing the Secret
+func (crMgr *CRManager) getTLSProfilesForSecret(namespace, name string) []*cisapiv1.TLSProfile {
+	crInf, ok := crMgr.getNamespacedInformer(namespace)
+	if !ok {
+		return nil
+	}
+	objs, err := crInf.tlsInformer.GetIndexer().ByIndex(TLSSecretIndex, namespace+"/"+name)
+	if err != nil {
+		log.Errorf("Unable to get the TLSProfiles for Secret %v/%v: %v", namespace, name, err)
+		return nil
+	}
+	var tlsProfiles []*cisapiv1.TLSProfile
+	for _, obj := range objs {
+		tlsProfiles = append(tlsProfiles, obj.(*cisapiv1.TLSProfile))
+	}
+	return tlsProfiles
+}
+
+// getVirtualsForSecret returns the VirtualServers using the Secret through TLSProfiles
+// or as the client certificate of a pool monitor
+func (crMgr *CRManager) getVirtualsForSecret(namespace, name string) []*cisapiv1.VirtualServer {
+	var virtuals []*cisapiv1.VirtualServer
+	found := make(map[string]bool)
+	for _, tls := range crMgr.getTLSProfilesForSecret(namespace, name) {
+		for _, vs := range crMgr.getVirtualsForTLSProfile(tls) {
+			if found[vs.ObjectMeta.Name] {
+				continue
+			}
+			found[vs.ObjectMeta.Name] = true
+			virtuals = append(virtuals, vs)
+		}
+	}
+	for _, vs := range crMgr.getVirtualsForMonitorSecret(namespace, name) {
+		if !found[vs.ObjectMeta.Name] {
+			found[vs.ObjectMeta.Name] = true
+			virtuals = append(virtuals, vs)
+		}
+	}
+	return virtuals
+}
+
+// getVirtualsForMonitorSecret returns the VirtualServers with a pool monitor
+// using the Secret as its client certificate
+func (crMgr *CRManager) getVirtualsForMonitorSecret(namespace, name string) []*cisapiv1.VirtualServer {
+	var virtuals []*cisapiv1.VirtualServer
+	for _, vs := range crMgr.getAllVirtualServers(namespace) {
+		for _, pl := range vs.Spec.Pools {
+			if usesMonitorSecret(pl, name) {
+				virtuals = append(virtuals, vs)
+				break
+			}
+		}
+	}
+	return virtuals
+}
+
+// getTransportServersForMonitorSecret returns the TransportServers with a pool
+// monitor using the Secret as its client certificate
+func (crMgr *CRManager) getTransportServersForMonitorSecret(namespace, name string) []*cisapiv1.TransportServer {
+	var virtuals []*cisapiv1.TransportServer
+	for _, ts := range crMgr.getAllTransportServers(namespace) {
+		if usesMonitorSecret(ts.Spec.Pool, name) {
+			virtuals = append(virtuals, ts)
+		}
+	}
+	return virtuals
+}
+
+// usesMonitorSecret reports whether a monitor of the pool uses the Secret as its client certificate
+func usesMonitorSecret(pl cisapiv1.Pool, name string) bool {
+	for _, mon := range getPoolSpecMonitors(pl) {
+		if mon.ClientCertificate == name {
+			return true
+		}
+	}
+	return false
+}
+
+// getSecret returns the Secret from the store of the Secret informer
+func (crMgr *CRManager) getSecret(namespace, name string) (*v1.Secret, error) {
+	crInf, ok := crMgr.getNamespacedInformer(namespace)
+	if !ok {
+		return nil, fmt.Errorf("informer not found for namespace %v", namespace)
+	}
+	obj, found, err := crInf.secretInformer.GetIndexer().GetByKey(namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("secret %v/%v not found", namespace, name)
+	}
+	return obj.(*v1.Secret), nil
+}
+
 // getAllVirtualServers returns list of all valid VirtualServers in rkey namespace.
 func (crMgr *CRManager) getAllVirtualServers(namespace string) []*cisapiv1.VirtualServer {
 	var allVirtuals []*cisapiv1.VirtualServer
@@ -483,6 +617,12 @@ func filterVirtualServersForService(allVirtuals []*cisapiv1.VirtualServer,
 				isValidVirtual = true
 				break
 			}
+			for _, ab := range pool.AlternateBackends {
+				if ab.Service == svcName {
+					isValidVirtual = true
+					break
+				}
+			}
 		}
 		if !isValidVirtual {
 			continue
@@ -543,16 +683,23 @@ func (crMgr *CRManager) getTLSProfileForVirtualServer(
 		return nil
 	}
 
+	tlsProfile := obj.(*cisapiv1.TLSProfile)
+
 	// validate TLSProfile
-	validation := validateTLSProfile(obj.(*cisapiv1.TLSProfile))
+	validation := validateTLSProfile(tlsProfile)
 	if validation == false {
+		crMgr.updateTLSProfileStatus(tlsProfile, newCondition(cisapiv1.ConditionValid, false, ReasonInvalidSpec,
+			fmt.Sprintf("Invalid SSL profiles for %s termination", tlsProfile.Spec.TLS.Termination)))
 		return nil
 	}
-
-	tlsProfile := obj.(*cisapiv1.TLSProfile)
+	crMgr.updateTLSProfileStatus(tlsProfile, newCondition(cisapiv1.ConditionValid, true, ReasonValidated, ""))
 
 	if tlsProfile.Spec.TLS.Reference == "secret" {
-		clientSecret, _ := crMgr.kubeClient.CoreV1().Secrets(namespace).Get(context.TODO(), tlsProfile.Spec.TLS.ClientSSL, metav1.GetOptions{})
+		clientSecret, err := crMgr.getSecret(namespace, tlsProfile.Spec.TLS.ClientSSL)
+		if err != nil {
+			log.Errorf("Unable to validate TLSProfile %s: %v", tlsName, err)
+			return nil
+		}
 		//validate clientSSL certificates and hostname
 		match := checkCertificateHost(clientSecret, vs.Spec.Host)
 		if match == false {
@@ -605,13 +752,25 @@ func (crMgr *CRManager) processVirtualServers(
 		endTime := time.Now()
 		log.Debugf("Finished syncing virtual servers %+v (%v)",
 			virtual, endTime.Sub(startTime))
+		bigIPPrometheus.ProcessingDuration.WithLabelValues("processVirtualServers").
+			Observe(endTime.Sub(startTime).Seconds())
 	}()
 
+	// Conditions observed while processing are reported in the status of the Virtual Server
+	var conditions []metav1.Condition
+	var statusIP string
+	if !isVSDeleted {
+		defer func() {
+			crMgr.updateVirtualServerStatus(virtual, statusIP, conditions...)
+		}()
+	}
+
 	// Skip validation for a deleted Virtual Server
 	if !isVSDeleted {
 		// check if the virutal server matches all the requirements.
 		vkey := virtual.ObjectMeta.Namespace + "/" + virtual.ObjectMeta.Name
-		valid := crMgr.checkValidVirtualServer(virtual)
+		valid, cond := crMgr.checkValidVirtualServer(virtual)
+		conditions = append(conditions, cond)
 		if false == valid {
 			log.Errorf("VirtualServer %s, is not valid",
 				vkey)
@@ -629,121 +788,171 @@ func (crMgr *CRManager) processVirtualServers(
 	log.Debugf("Process all the Virtual Servers which share same VirtualServerAddress")
 
 	virtuals := crMgr.getAssociatedVirtualServers(virtual, allVirtuals, isVSDeleted)
+	if !isVSDeleted && len(virtuals) == 0 {
+		conditions = append(conditions, newCondition(cisapiv1.ConditionProgrammed, false, ReasonInvalidSpec,
+			fmt.Sprintf("VirtualServer conflicts with other VirtualServers of host %s", virtual.Spec.Host)))
+	}
 
 	var ip string
-	if crMgr.ipamCli != nil {
+	if crMgr.ipamProvider != nil {
 		if isVSDeleted && len(virtuals) == 0 && virtual.Spec.VirtualServerAddress == "" {
 			ip = crMgr.releaseIP(virtual.Spec.IPAMLabel, virtual.Spec.Host, "")
 		} else if virtual.Spec.VirtualServerAddress != "" {
 			ip = virtual.Spec.VirtualServerAddress
+			conditions = append(conditions, newCondition(cisapiv1.ConditionIPAllocated, true, ReasonStaticAddress, ""))
+		} else if ipamLabel := getIPAMLabel(virtuals); ipamLabel == "" || virtual.Spec.Host == "" {
+			// Addresses are allocated for the host, nothing is allocated without an ipamLabel
+			// or a host, e.g. for a VirtualServer conflicting with the others of its host
+			msg := fmt.Sprintf("IPAM requires both host and ipamLabel, found host %q and ipamLabel %q",
+				virtual.Spec.Host, ipamLabel)
+			log.Errorf("[ipam] %s for virtual server %s", msg, virtual.Namespace+"/"+virtual.Name)
+			conditions = append(conditions, newCondition(cisapiv1.ConditionIPAllocated, false, ReasonInvalidSpec, msg))
+			return nil
 		} else {
-			ipamLabel := getIPAMLabel(virtuals)
-			ip = crMgr.requestIP(ipamLabel, virtual.Spec.Host, "")
+			var err error
+			ip, err = crMgr.requestIP(ipamLabel, virtual.Spec.Host, "")
 			if ip == "" {
 				log.Debugf("[ipam] requested IP for host %v is empty.", virtual.Spec.Host)
+				conditions = append(conditions, crMgr.setIPAMPending(VirtualServer, virtual, virtual.Namespace,
+					virtual.Name, "host "+virtual.Spec.Host, err))
 				return nil
 			}
 			log.Debugf("[ipam] requested IP for host %v is: %v", virtual.Spec.Host, ip)
-			crMgr.updateVirtualServerStatus(virtual, ip)
+			statusIP = ip
+			conditions = append(conditions, newCondition(cisapiv1.ConditionIPAllocated, true, ReasonIPAMAllocated, ""))
 		}
 	} else {
 		if virtual.Spec.VirtualServerAddress == "" {
 			return fmt.Errorf("No VirtualServer address or IPAM found.")
 		}
 		ip = virtual.Spec.VirtualServerAddress
+		conditions = append(conditions, newCondition(cisapiv1.ConditionIPAllocated, true, ReasonStaticAddress, ""))
+	}
+
+	// Dual-stack VirtualServers also listen on an IPv6 address, sharing the pools of the primary address
+	ipv6IPAMLabel := virtual.Spec.IPv6IPAMLabel
+	if len(virtuals) > 0 {
+		ipv6IPAMLabel = getIPv6IPAMLabel(virtuals)
 	}
+	ipv6, ipv6Pending, err := crMgr.getIPv6VirtualAddress(virtual.Spec.IPv6VirtualServerAddress, ipv6IPAMLabel,
+		virtual.Spec.Host+ipv6Suffix, isVSDeleted && len(virtuals) == 0)
+	if ipv6Pending {
+		log.Debugf("[ipam] requested IPv6 address for host %v is empty.", virtual.Spec.Host)
+		conditions = append(conditions, crMgr.setIPAMPending(VirtualServer, virtual, virtual.Namespace,
+			virtual.Name, "host "+virtual.Spec.Host+" IPv6", err))
+		return nil
+	}
+	ip, ipv6 = withResourceRouteDomain(virtual.Spec.RouteDomain, virtual.Spec.VirtualServerAddress, ip, ipv6)
+	addresses := getVirtualAddresses(ip, ipv6)
+
 	// Depending on the ports defined, TLS type or Unsecured we will populate the resource config.
 	portStructs := crMgr.virtualPorts(virtual)
 
 	// vsMap holds Resource Configs of current virtuals temporarily
 	vsMap := make(ResourceConfigMap)
 	processingError := false
+	var processingErrMsg string
 	for _, portStruct := range portStructs {
-		// TODO: Add Route Domain
-		var rsName string
-		if virtual.Spec.VirtualServerName != "" {
-			rsName = formatCustomVirtualServerName(
-				virtual.Spec.VirtualServerName,
-				portStruct.port,
-			)
-		} else {
-			rsName = formatVirtualServerName(
-				ip,
-				portStruct.port,
-			)
-		}
-
-		// Delete rsCfg if no corresponding virtuals exist
-		// Delete rsCfg if it is HTTP rsCfg and the CR VirtualServer does not handle HTTPTraffic
-		if (len(virtuals) == 0) ||
-			(portStruct.protocol == "http" && !doesVSHandleHTTP(virtual)) {
-			crMgr.deleteVirtualServer(rsName)
-			continue
-		}
-
-		rsCfg := &ResourceConfig{}
-		rsCfg.Virtual.Partition = crMgr.Partition
-		rsCfg.MetaData.ResourceType = VirtualServer
-		rsCfg.Virtual.Enabled = true
-		rsCfg.Virtual.Name = rsName
-		rsCfg.MetaData.hosts = append(rsCfg.MetaData.hosts, virtual.Spec.Host)
-		rsCfg.Virtual.SetVirtualAddress(
-			ip,
-			portStruct.port,
-		)
-		rsCfg.IntDgMap = make(InternalDataGroupMap)
-		rsCfg.IRulesMap = make(IRulesMap)
-		rsCfg.customProfiles.Profs = make(map[SecretKey]CustomProfile)
-
-		for _, vrt := range virtuals {
-			log.Debugf("Processing Virtual Server %s for port %v",
-				vrt.ObjectMeta.Name, portStruct.port)
-			err := crMgr.prepareRSConfigFromVirtualServer(
-				rsCfg,
-				vrt,
-			)
-			if err != nil {
-				processingError = true
-				break
+		for _, addr := range addresses {
+			var rsName string
+			if virtual.Spec.VirtualServerName != "" {
+				rsName = getDualStackVirtualServerName(
+					virtual.Spec.VirtualServerName,
+					portStruct.port,
+					addr,
+					ip,
+				)
+			} else {
+				rsName = formatVirtualServerName(
+					addr,
+					portStruct.port,
+				)
 			}
 
-			if isTLSVirtualServer(vrt) {
-				// Handle TLS configuration for VirtualServer Custom Resource
+			// Delete rsCfg if no corresponding virtuals exist
+			// Delete rsCfg if it is HTTP rsCfg and the CR VirtualServer does not handle HTTPTraffic
+			if (len(virtuals) == 0) ||
+				(portStruct.protocol == "http" && !doesVSHandleHTTP(virtual)) {
+				crMgr.deleteVirtualServer(rsName)
+				continue
+			}
 
-				tlsProf := crMgr.getTLSProfileForVirtualServer(vrt, vrt.Namespace)
-				if tlsProf == nil {
-					// Processing failed
-					// Stop processing further virtuals
+			rsCfg := &ResourceConfig{}
+			rsCfg.Virtual.Partition = crMgr.getPartition(virtual.Spec.Partition)
+			rsCfg.MetaData.ResourceType = VirtualServer
+			rsCfg.Virtual.Enabled = true
+			rsCfg.Virtual.Name = rsName
+			rsCfg.MetaData.hosts = append(rsCfg.MetaData.hosts, virtual.Spec.Host)
+			rsCfg.MetaData.baseResources = make(map[string]string)
+			rsCfg.Virtual.SetVirtualAddress(
+				addr,
+				portStruct.port,
+			)
+			rsCfg.IntDgMap = make(InternalDataGroupMap)
+			rsCfg.IRulesMap = make(IRulesMap)
+			rsCfg.customProfiles.Profs = make(map[SecretKey]CustomProfile)
+
+			for _, vrt := range virtuals {
+				log.Debugf("Processing Virtual Server %s for port %v",
+					vrt.ObjectMeta.Name, portStruct.port)
+				err := crMgr.prepareRSConfigFromVirtualServer(
+					rsCfg,
+					vrt,
+				)
+				if err != nil {
 					processingError = true
+					processingErrMsg = err.Error()
 					break
 				}
+				rsCfg.MetaData.baseResources[vrt.ObjectMeta.Namespace+"/"+vrt.ObjectMeta.Name] = VirtualServer
+
+				if isTLSVirtualServer(vrt) {
+					// Handle TLS configuration for VirtualServer Custom Resource
+
+					tlsProf := crMgr.getTLSProfileForVirtualServer(vrt, vrt.Namespace)
+					if tlsProf == nil {
+						// Processing failed
+						// Stop processing further virtuals
+						processingError = true
+						processingErrMsg = fmt.Sprintf("TLSProfile %s is not valid for VirtualServer %s",
+							vrt.Spec.TLSProfileName, vrt.ObjectMeta.Name)
+						break
+					}
 
-				processed := crMgr.handleVirtualServerTLS(rsCfg, vrt, tlsProf, ip)
-				if !processed {
-					// Processing failed
-					// Stop processing further virtuals
-					processingError = true
-					break
+					processed := crMgr.handleVirtualServerTLS(rsCfg, vrt, tlsProf, addr)
+					if !processed {
+						// Processing failed
+						// Stop processing further virtuals
+						processingError = true
+						processingErrMsg = fmt.Sprintf("Failed to apply TLSProfile %s to VirtualServer %s",
+							vrt.Spec.TLSProfileName, vrt.ObjectMeta.Name)
+						break
+					}
+
+					log.Debugf("Updated Virtual %s with TLSProfile %s",
+						vrt.ObjectMeta.Name, vrt.Spec.TLSProfileName)
 				}
+			}
 
-				log.Debugf("Updated Virtual %s with TLSProfile %s",
-					vrt.ObjectMeta.Name, vrt.Spec.TLSProfileName)
+			if processingError {
+				log.Errorf("Cannot Publish VirtualServer %s", virtual.ObjectMeta.Name)
+				conditions = append(conditions, newCondition(cisapiv1.ConditionProgrammed, false,
+					ReasonProcessingFailed, processingErrMsg))
+				break
 			}
-		}
 
+			// Save ResourceConfig in temporary Map
+			vsMap[rsName] = rsCfg
+
+			if crMgr.ControllerMode == NodePortMode {
+				crMgr.updatePoolMembersForNodePort(rsCfg, virtual.ObjectMeta.Namespace)
+			} else {
+				crMgr.updatePoolMembersForCluster(rsCfg, virtual.ObjectMeta.Namespace)
+			}
+		}
 		if processingError {
-			log.Errorf("Cannot Publish VirtualServer %s", virtual.ObjectMeta.Name)
 			break
 		}
-
-		// Save ResourceConfig in temporary Map
-		vsMap[rsName] = rsCfg
-
-		if crMgr.ControllerMode == NodePortMode {
-			crMgr.updatePoolMembersForNodePort(rsCfg, virtual.ObjectMeta.Namespace)
-		} else {
-			crMgr.updatePoolMembersForCluster(rsCfg, virtual.ObjectMeta.Namespace)
-		}
 	}
 
 	if !processingError {
@@ -760,6 +969,9 @@ func (crMgr *CRManager) processVirtualServers(
 		if newVSCreated {
 			crMgr.ProcessAssociatedExternalDNS(hostnames)
 		}
+		if len(vsMap) > 0 {
+			conditions = append(conditions, newCondition(cisapiv1.ConditionProgrammed, true, ReasonConfigGenerated, ""))
+		}
 	}
 
 	return nil
@@ -777,30 +989,62 @@ func (crMgr *CRManager) getAssociatedVirtualServers(
 	for _, vrt := range allVirtuals {
 		if vrt.Spec.Host == virtual.Spec.Host &&
 			!(isVSDeleted && vrt.ObjectMeta.Name == virtual.ObjectMeta.Name) {
-			if crMgr.ipamCli != nil {
+			if crMgr.ipamProvider != nil {
 				if vrt.Spec.IPAMLabel != virtual.Spec.IPAMLabel {
 					log.Debugf("Same host is configured with different IPAM label : , %v ", vrt.Spec.Host)
 					return nil
 				}
+				if vrt.Spec.IPv6IPAMLabel != virtual.Spec.IPv6IPAMLabel {
+					log.Debugf("Same host is configured with different IPv6 IPAM label : , %v ", vrt.Spec.Host)
+					return nil
+				}
 				// Empty host with IPAM label is invalid
 				if virtual.Spec.IPAMLabel != "" && virtual.Spec.Host == "" {
 					log.Debugf("Hostless VS is configured with IPAM label : , %v ", vrt.Spec.Host)
 					return nil
 				}
 			}
-			// Same host with different VirtualServerAddress is invalid
-			if vrt.Spec.VirtualServerAddress != virtual.Spec.VirtualServerAddress {
+			// Same host with different VirtualServerAddress or IPv6VirtualServerAddress is invalid
+			if vrt.Spec.VirtualServerAddress != virtual.Spec.VirtualServerAddress ||
+				vrt.Spec.IPv6VirtualServerAddress != virtual.Spec.IPv6VirtualServerAddress {
 				if virtual.Spec.Host != "" {
 					log.Debugf("Same host is configured with different VirtualServerAddress : %v ", vrt.Spec.VirtualServerName)
 					return nil
 				}
 				continue
 			}
+			// Virtuals sharing the same VirtualServerAddress in different partitions is invalid
+			if crMgr.getPartition(vrt.Spec.Partition) != crMgr.getPartition(virtual.Spec.Partition) {
+				log.Debugf("Same VirtualServerAddress is configured in different partitions : %v ",
+					vrt.Spec.VirtualServerAddress)
+				return nil
+			}
+			// Virtuals sharing the same VirtualServerAddress in different route domains is invalid
+			if getRouteDomain(vrt.Spec.RouteDomain, vrt.Spec.VirtualServerAddress) !=
+				getRouteDomain(virtual.Spec.RouteDomain, virtual.Spec.VirtualServerAddress) {
+				log.Debugf("Same VirtualServerAddress is configured in different route domains : %v ",
+					vrt.Spec.VirtualServerAddress)
+				return nil
+			}
 			// Hosts sharing same VirtualServerAddress but different ports are supported
 			if vrt.Spec.VirtualServerHTTPPort != virtual.Spec.VirtualServerHTTPPort ||
 				vrt.Spec.VirtualServerHTTPSPort != virtual.Spec.VirtualServerHTTPSPort {
 				continue
 			}
+			// Virtuals sharing the same VirtualServerAddress and ports with different session persistence is invalid
+			if vrt.Spec.Persistence != nil && virtual.Spec.Persistence != nil &&
+				!reflect.DeepEqual(vrt.Spec.Persistence, virtual.Spec.Persistence) {
+				log.Debugf("Same VirtualServerAddress is configured with different session persistence : %v ",
+					vrt.Spec.VirtualServerAddress)
+				return nil
+			}
+			// Virtuals sharing the same VirtualServerAddress and ports with different source ranges is invalid
+			if !sameSourceRanges(vrt.Spec.AllowSourceRange, virtual.Spec.AllowSourceRange) ||
+				!sameSourceRanges(vrt.Spec.DenySourceRange, virtual.Spec.DenySourceRange) {
+				log.Debugf("Same VirtualServerAddress is configured with different source ranges : %v ",
+					vrt.Spec.VirtualServerAddress)
+				return nil
+			}
 			isUnique := true
 		op:
 			// Check for duplicate path entries among virtuals
@@ -830,6 +1074,18 @@ func (crMgr *CRManager) getAssociatedVirtualServers(
 	return virtuals
 }
 
+// sameSourceRanges reports whether both lists hold the same source ranges, in any order
+func sameSourceRanges(ranges1, ranges2 []string) bool {
+	parsed1 := parseSourceRanges(ranges1)
+	parsed2 := parseSourceRanges(ranges2)
+	if len(parsed1) != len(parsed2) {
+		return false
+	}
+	sort.Strings(parsed1)
+	sort.Strings(parsed2)
+	return reflect.DeepEqual(parsed1, parsed2)
+}
+
 func getIPAMLabel(virtuals []*cisapiv1.VirtualServer) string {
 	for _, vrt := range virtuals {
 		if vrt.Spec.IPAMLabel != "" {
@@ -839,156 +1095,55 @@ func getIPAMLabel(virtuals []*cisapiv1.VirtualServer) string {
 	return ""
 }
 
-func (crMgr *CRManager) getIPAMCR() *ficV1.IPAM {
-	cr := strings.Split(crMgr.ipamCR, "/")
-	if len(cr) != 2 {
-		log.Errorf("[ipam] error while retrieving IPAM namespace and name.")
-		return nil
-	}
-	ipamCR, err := crMgr.ipamCli.Get(cr[0], cr[1])
-	if err != nil {
-		log.Errorf("[ipam] error while retrieving IPAM custom resource.")
-		return nil
-	}
-	return ipamCR
-}
-
-//Request IPAM for virtual IP address
-func (crMgr *CRManager) requestIP(ipamLabel string, host string, key string) string {
-	ipamCR := crMgr.getIPAMCR()
-	if ipamCR == nil || ipamLabel == "" {
-		return ""
-	}
-
-	if host != "" {
-		//For VS server
-		for _, ipst := range ipamCR.Status.IPStatus {
-			if ipst.IPAMLabel == ipamLabel && ipst.Host == host {
-				return ipst.IP
-			}
-		}
-
-		for _, hst := range ipamCR.Spec.HostSpecs {
-			if hst.Host == host {
-				if hst.IPAMLabel == ipamLabel {
-					//Check if HostSpec is already updated with IPAMLabel and Host
-					return ""
-				} else {
-					//Check this for key and host both
-					crMgr.releaseIP(hst.IPAMLabel, hst.Host, "")
-					break
-				}
-			}
-		}
-
-		ipamCR.SetResourceVersion(ipamCR.ResourceVersion)
-		ipamCR.Spec.HostSpecs = append(ipamCR.Spec.HostSpecs, &ficV1.HostSpec{
-			Host:      host,
-			IPAMLabel: ipamLabel,
-		})
-	} else if key != "" {
-		//For Transport Server
-		for _, ipst := range ipamCR.Status.IPStatus {
-			if ipst.IPAMLabel == ipamLabel && ipst.Key == key {
-				return ipst.IP
-			}
-		}
-
-		for _, hst := range ipamCR.Spec.HostSpecs {
-			if hst.Key == key {
-				if hst.IPAMLabel == ipamLabel {
-					//Check if HostSpec is already updated with IPAMLabel and Key
-					return ""
-				} else {
-					//Check this for key and host both
-					crMgr.releaseIP(hst.IPAMLabel, "", hst.Key)
-					break
-				}
-			}
+func getIPv6IPAMLabel(virtuals []*cisapiv1.VirtualServer) string {
+	for _, vrt := range virtuals {
+		if vrt.Spec.IPv6IPAMLabel != "" {
+			return vrt.Spec.IPv6IPAMLabel
 		}
-
-		ipamCR.SetResourceVersion(ipamCR.ResourceVersion)
-		ipamCR.Spec.HostSpecs = append(ipamCR.Spec.HostSpecs, &ficV1.HostSpec{
-			Key:       key,
-			IPAMLabel: ipamLabel,
-		})
-
-	} else {
-		log.Debugf("[IPAM] Invalid host and key.")
-		return ""
-	}
-
-	_, err := crMgr.ipamCli.Update(ipamCR)
-	if err != nil {
-		log.Errorf("[ipam] Error updating IPAM CR : %v", err)
-	} else {
-		log.Debugf("[ipam] Updated IPAM CR.")
 	}
 	return ""
+}
 
+// getIPv6VirtualAddress returns the IPv6 address of a dual-stack custom resource, either the
+// ipv6VirtualServerAddress or the address allocated by IPAM for the ipv6IpamLabel under the key.
+// An empty address is returned for resources that are not dual-stack, pending is set
+// while IPAM has not allocated the IPv6 address yet.
+func (crMgr *CRManager) getIPv6VirtualAddress(
+	address string,
+	ipamLabel string,
+	key string,
+	release bool,
+) (ip string, pending bool, err error) {
+	if address != "" {
+		return address, false, nil
+	}
+	if ipamLabel == "" || crMgr.ipamProvider == nil {
+		return "", false, nil
+	}
+	if release {
+		return crMgr.releaseIP(ipamLabel, "", key), false, nil
+	}
+	ip, err = crMgr.requestIP(ipamLabel, "", key)
+	return ip, ip == "", err
 }
 
-func (crMgr *CRManager) releaseIP(ipamLabel string, host string, key string) string {
-	ipamCR := crMgr.getIPAMCR()
-	var ip string
-	if ipamCR == nil || ipamLabel == "" {
-		return ip
+// getVirtualAddresses returns the addresses a custom resource listens on,
+// the IPv6 address of a dual-stack resource follows the primary address
+func getVirtualAddresses(ip, ipv6 string) []string {
+	if ipv6 == "" || ipv6 == ip {
+		return []string{ip}
 	}
-	index := -1
-	if host != "" {
-		//Find index for deleted host
-		for i, hostSpec := range ipamCR.Spec.HostSpecs {
-			if hostSpec.IPAMLabel == ipamLabel && hostSpec.Host == host {
-				index = i
-				break
-			}
-		}
-		//Find IP address for deleted host
-		for _, ipst := range ipamCR.Status.IPStatus {
-			if ipst.IPAMLabel == ipamLabel && ipst.Host == host {
-				ip = ipst.IP
-			}
-		}
-		if index != -1 {
-			ipamCR.Spec.HostSpecs = append(ipamCR.Spec.HostSpecs[:index], ipamCR.Spec.HostSpecs[index+1:]...)
-			ipamCR.SetResourceVersion(ipamCR.ResourceVersion)
-			_, err := crMgr.ipamCli.Update(ipamCR)
-			if err != nil {
-				log.Errorf("[ipam] ipam hostspec update error: %v", err)
-				return ""
-			}
-			log.Debug("[ipam] Updated IPAM CR hostspec while releasing IP.")
-		}
-	} else if key != "" {
-		//Find index for deleted key
-		for i, hostSpec := range ipamCR.Spec.HostSpecs {
-			if hostSpec.IPAMLabel == ipamLabel && hostSpec.Key == key {
-				index = i
-				break
-			}
-		}
-		//Find IP address for deleted host
-		for _, ipst := range ipamCR.Status.IPStatus {
-			if ipst.IPAMLabel == ipamLabel && ipst.Key == key {
-				ip = ipst.IP
-			}
-		}
-		if index != -1 {
-			ipamCR.Spec.HostSpecs = append(ipamCR.Spec.HostSpecs[:index], ipamCR.Spec.HostSpecs[index+1:]...)
-			ipamCR.SetResourceVersion(ipamCR.ResourceVersion)
-			_, err := crMgr.ipamCli.Update(ipamCR)
-			if err != nil {
-				log.Errorf("[ipam] ipam hostspec update error: %v", err)
-				return ""
-			}
-			log.Debug("[ipam] Updated IPAM CR hostspec while releasing IP.")
-		}
+	return []string{ip, ipv6}
+}
 
-	} else {
-		log.Debugf("[IPAM] Invalid host and key.")
+// getDualStackVirtualServerName returns the name of the virtual created for the
+// address of a custom resource with a custom virtual server name, the IPv6 virtual
+// of a dual-stack resource gets a suffix to keep both names unique
+func getDualStackVirtualServerName(name string, port int32, addr, ip string) string {
+	if addr != ip {
+		name += ipv6Suffix
 	}
-
-	return ip
+	return formatCustomVirtualServerName(name, port)
 }
 
 // updatePoolMembersForNodePort updates the pool with pool members for a
@@ -1127,9 +1282,16 @@ func (crMgr *CRManager) getEndpointsForCluster(
 			if portName == p.Name && servicePort == p.Port {
 				for _, addr := range subset.Addresses {
 					// Checking for headless services
-					if containsNode(nodes, *addr.NodeName) || clusterIP == "None" {
+					if (addr.NodeName != nil && containsNode(nodes, *addr.NodeName)) || clusterIP == "None" {
+						// Endpoints of IPv6 and dual-stack clusters may hold IPv6 addresses,
+						// which are used in their canonical form
+						ip := net.ParseIP(addr.IP)
+						if ip == nil {
+							log.Warningf("Invalid endpoint address %v of service port %v", addr.IP, p.Name)
+							continue
+						}
 						member := Member{
-							Address: addr.IP,
+							Address: ip.String(),
 							Port:    p.Port,
 							Session: "user-enabled",
 						}
@@ -1166,11 +1328,21 @@ func (crMgr *CRManager) processTransportServers(
 			virtual, endTime.Sub(startTime))
 	}()
 
+	// Conditions observed while processing are reported in the status of the Transport Server
+	var conditions []metav1.Condition
+	var statusIP string
+	if !isTSDeleted {
+		defer func() {
+			crMgr.updateTransportServerStatus(virtual, statusIP, conditions...)
+		}()
+	}
+
 	// Skip validation for a deleted Virtual Server
 	if !isTSDeleted {
 		// check if the virutal server matches all the requirements.
 		vkey := virtual.ObjectMeta.Namespace + "/" + virtual.ObjectMeta.Name
-		valid := crMgr.checkValidTransportServer(virtual)
+		valid, cond := crMgr.checkValidTransportServer(virtual)
+		conditions = append(conditions, cond)
 		if false == valid {
 			log.Errorf("TransportServer %s, is not valid",
 				vkey)
@@ -1203,71 +1375,97 @@ func (crMgr *CRManager) processTransportServers(
 	var ip string
 	var key string
 	key = virtual.ObjectMeta.Namespace + "/" + virtual.ObjectMeta.Name + "_ts"
-	if crMgr.ipamCli != nil {
+	if crMgr.ipamProvider != nil {
 		if isTSDeleted && len(virtuals) == 0 && virtual.Spec.VirtualServerAddress == "" {
 			ip = crMgr.releaseIP(virtual.Spec.IPAMLabel, "", key)
 		} else if virtual.Spec.VirtualServerAddress != "" {
 			ip = virtual.Spec.VirtualServerAddress
+			conditions = append(conditions, newCondition(cisapiv1.ConditionIPAllocated, true, ReasonStaticAddress, ""))
 		} else {
-			ip = crMgr.requestIP(virtual.Spec.IPAMLabel, "", key)
+			var err error
+			ip, err = crMgr.requestIP(virtual.Spec.IPAMLabel, "", key)
 			log.Debugf("[ipam] requested IP for TS %v is: %v", virtual.ObjectMeta.Name, ip)
 			if ip == "" {
 				log.Debugf("[ipam] requested IP for TS %v is empty.", virtual.ObjectMeta.Name)
+				conditions = append(conditions, crMgr.setIPAMPending(TransportServer, virtual, virtual.Namespace,
+					virtual.Name, "label "+virtual.Spec.IPAMLabel, err))
 				return nil
 			}
+			statusIP = ip
+			conditions = append(conditions, newCondition(cisapiv1.ConditionIPAllocated, true, ReasonIPAMAllocated, ""))
 		}
 	} else {
 		if virtual.Spec.VirtualServerAddress == "" {
 			return fmt.Errorf("No VirtualServer address in TS or IPAM found.")
 		}
 		ip = virtual.Spec.VirtualServerAddress
+		conditions = append(conditions, newCondition(cisapiv1.ConditionIPAllocated, true, ReasonStaticAddress, ""))
 	}
 
+	// Dual-stack TransportServers also listen on an IPv6 address, sharing the pool of the primary address
+	ipv6, ipv6Pending, err := crMgr.getIPv6VirtualAddress(virtual.Spec.IPv6VirtualServerAddress,
+		virtual.Spec.IPv6IPAMLabel, key+ipv6Suffix, isTSDeleted && len(virtuals) == 0)
+	if ipv6Pending {
+		log.Debugf("[ipam] requested IPv6 address for TS %v is empty.", virtual.ObjectMeta.Name)
+		conditions = append(conditions, crMgr.setIPAMPending(TransportServer, virtual, virtual.Namespace,
+			virtual.Name, "label "+virtual.Spec.IPv6IPAMLabel, err))
+		return nil
+	}
+
+	ip, ipv6 = withResourceRouteDomain(virtual.Spec.RouteDomain, virtual.Spec.VirtualServerAddress, ip, ipv6)
+
 	// vsMap holds Resource Configs of current virtuals temporarily
 	vsMap := make(ResourceConfigMap)
 	processingError := false
-	var rsName string
-	if virtual.Spec.VirtualServerName != "" {
-		rsName = formatCustomVirtualServerName(
-			virtual.Spec.VirtualServerName,
-			virtual.Spec.VirtualServerPort,
-		)
-	} else {
-		rsName = formatVirtualServerName(
-			ip,
+	for _, addr := range getVirtualAddresses(ip, ipv6) {
+		var rsName string
+		if virtual.Spec.VirtualServerName != "" {
+			rsName = getDualStackVirtualServerName(
+				virtual.Spec.VirtualServerName,
+				virtual.Spec.VirtualServerPort,
+				addr,
+				ip,
+			)
+		} else {
+			rsName = formatVirtualServerName(
+				addr,
+				virtual.Spec.VirtualServerPort,
+			)
+		}
+		if len(virtuals) == 0 {
+			crMgr.resources.deleteVirtualServer(rsName)
+			continue
+		}
+
+		rsCfg := &ResourceConfig{}
+		rsCfg.Virtual.Partition = crMgr.getPartition(virtual.Spec.Partition)
+		rsCfg.MetaData.ResourceType = TransportServer
+		rsCfg.Virtual.Enabled = true
+		rsCfg.Virtual.Name = rsName
+		rsCfg.Virtual.IpProtocol = virtual.Spec.Type
+		rsCfg.MetaData.baseResources = make(map[string]string)
+		rsCfg.Virtual.SetVirtualAddress(
+			addr,
 			virtual.Spec.VirtualServerPort,
 		)
-	}
-	if len(virtuals) == 0 {
-		crMgr.resources.deleteVirtualServer(rsName)
-		return nil
-	}
-
-	rsCfg := &ResourceConfig{}
-	rsCfg.Virtual.Partition = crMgr.Partition
-	rsCfg.MetaData.ResourceType = TransportServer
-	rsCfg.Virtual.Enabled = true
-	rsCfg.Virtual.Name = rsName
-	rsCfg.Virtual.IpProtocol = virtual.Spec.Type
-	rsCfg.Virtual.SetVirtualAddress(
-		ip,
-		virtual.Spec.VirtualServerPort,
-	)
 
-	for _, vrt := range virtuals {
-		log.Debugf("Processing Transport Server %s for port %v",
-			vrt.ObjectMeta.Name, vrt.Spec.VirtualServerPort)
-		err := crMgr.prepareRSConfigFromTransportServer(
-			rsCfg,
-			vrt,
-		)
-		if err != nil {
-			processingError = true
-			break
+		for _, vrt := range virtuals {
+			log.Debugf("Processing Transport Server %s for port %v",
+				vrt.ObjectMeta.Name, vrt.Spec.VirtualServerPort)
+			err := crMgr.prepareRSConfigFromTransportServer(
+				rsCfg,
+				vrt,
+			)
+			if err != nil {
+				processingError = true
+				log.Errorf("Cannot Publish TransportServer %s", virtual.ObjectMeta.Name)
+				conditions = append(conditions, newCondition(cisapiv1.ConditionProgrammed, false,
+					ReasonProcessingFailed, err.Error()))
+				break
+			}
+			rsCfg.MetaData.baseResources[vrt.ObjectMeta.Namespace+"/"+vrt.ObjectMeta.Name] = TransportServer
 		}
-
 		if processingError {
-			log.Errorf("Cannot Publish TransportServer %s", virtual.ObjectMeta.Name)
 			break
 		}
 
@@ -1285,6 +1483,9 @@ func (crMgr *CRManager) processTransportServers(
 		for rsName, rsCfg := range vsMap {
 			crMgr.resources.rsMap[rsName] = rsCfg
 		}
+		if len(vsMap) > 0 {
+			conditions = append(conditions, newCondition(cisapiv1.ConditionProgrammed, true, ReasonConfigGenerated, ""))
+		}
 	}
 	return nil
 
@@ -1496,40 +1697,67 @@ func (crMgr *CRManager) processLBServices(
 	svc *v1.Service,
 	isSVCDeleted bool,
 ) error {
-	if crMgr.ipamCli == nil {
-		log.Error("IPAM is not enabled, Unable to process Services of Type LoadBalancer")
-		return nil
-	}
-
-	ipamLabel, ok := svc.Annotations[LBServiceIPAMLabelAnnotation]
+	// A statically configured address takes precedence over IPAM
+	ip, ok := getLBServiceStaticIP(svc)
 	if !ok {
-		log.Errorf("Not found %v in %v/%v. Unable to process.",
-			LBServiceIPAMLabelAnnotation,
-			svc.Namespace,
-			svc.Name,
-		)
 		return nil
 	}
 
+	var ipamLabel string
 	svcKey := svc.Namespace + "/" + svc.Name + "_svc"
+	if ip == "" {
+		if crMgr.ipamProvider == nil {
+			log.Errorf("No IP address configured for Service %v/%v and IPAM is not enabled. "+
+				"Set spec.loadBalancerIP or the %v annotation. Unable to process.",
+				svc.Namespace,
+				svc.Name,
+				LBServiceIPAnnotation,
+			)
+			return nil
+		}
 
-	ip := crMgr.requestIP(ipamLabel, "", svcKey)
+		ipamLabel, ok = svc.Annotations[LBServiceIPAMLabelAnnotation]
+		if !ok {
+			log.Errorf("Not found %v or %v in %v/%v. Unable to process.",
+				LBServiceIPAnnotation,
+				LBServiceIPAMLabelAnnotation,
+				svc.Namespace,
+				svc.Name,
+			)
+			return nil
+		}
 
-	if ip == "" {
-		log.Debugf("IP address not available, yet, for service service: %s/%s", svc.Namespace, svc.Name)
-		return nil
+		var err error
+		ip, err = crMgr.requestIP(ipamLabel, "", svcKey)
+
+		if ip == "" {
+			log.Debugf("IP address not available, yet, for service service: %s/%s", svc.Namespace, svc.Name)
+			cond := crMgr.setIPAMPending(Service, svc, svc.Namespace, svc.Name, "label "+ipamLabel, err)
+			if cond.Reason == ReasonIPPoolExhausted {
+				crMgr.recordResourceEvent(svc, v1.EventTypeWarning, cond.Reason, cond.Message)
+			}
+			return nil
+		}
 	}
 
+	statusIP, _ := rsc.Split_ip_with_route_domain(ip)
 	if !isSVCDeleted {
-		crMgr.setLBServiceIngressStatus(svc, ip)
+		crMgr.setLBServiceIngressStatus(svc, statusIP)
 	} else {
-		crMgr.releaseIP(ipamLabel, "", svcKey)
-		crMgr.unSetLBServiceIngressStatus(svc, ip)
+		if ipamLabel != "" {
+			crMgr.releaseIP(ipamLabel, "", svcKey)
+		}
+		crMgr.unSetLBServiceIngressStatus(svc, statusIP)
 	}
 
+	// The virtual name of IPv4 addresses is unchanged, IPv6 and route domain addresses are formatted for AS3
+	vsIP := ip
+	if strings.ContainsAny(vsIP, ":%") {
+		vsIP = AS3NameFormatter(vsIP)
+	}
 	for _, portSpec := range svc.Spec.Ports {
 
-		rsName := fmt.Sprintf("vs_lb_svc_%s_%s_%s_%v", svc.Namespace, svc.Name, ip, portSpec.Port)
+		rsName := fmt.Sprintf("vs_lb_svc_%s_%s_%s_%v", svc.Namespace, svc.Name, vsIP, portSpec.Port)
 		if isSVCDeleted {
 			delete(crMgr.resources.rsMap, rsName)
 			continue
@@ -1540,6 +1768,7 @@ func (crMgr *CRManager) processLBServices(
 		rsCfg.MetaData.ResourceType = TransportServer
 		rsCfg.Virtual.Enabled = true
 		rsCfg.Virtual.Name = rsName
+		rsCfg.IRulesMap = make(IRulesMap)
 		rsCfg.Virtual.SetVirtualAddress(
 			ip,
 			portSpec.Port,
@@ -1560,6 +1789,30 @@ func (crMgr *CRManager) processLBServices(
 	return nil
 }
 
+// getLBServiceStaticIP returns the address configured for a Service of type
+// LoadBalancer through the ip annotation or spec.loadBalancerIP. An empty
+// address means one has to be allocated from IPAM. Returns false when the
+// configured address is invalid.
+func getLBServiceStaticIP(svc *v1.Service) (string, bool) {
+	ip, ok := svc.Annotations[LBServiceIPAnnotation]
+	if !ok || ip == "" {
+		ip = svc.Spec.LoadBalancerIP
+	}
+	if ip == "" {
+		return "", true
+	}
+	addr, _ := rsc.Split_ip_with_route_domain(ip)
+	if net.ParseIP(addr) == nil {
+		log.Errorf("Invalid IP address %v configured for Service %v/%v. Unable to process.",
+			ip,
+			svc.Namespace,
+			svc.Name,
+		)
+		return "", false
+	}
+	return ip, true
+}
+
 func (crMgr *CRManager) processExternalDNS(edns *cisapiv1.ExternalDNS, isDelete bool) {
 
 	if isDelete {
@@ -1572,6 +1825,12 @@ func (crMgr *CRManager) processExternalDNS(edns *cisapiv1.ExternalDNS, isDelete
 	crMgr.TeemData.Lock()
 	crMgr.TeemData.ResourceType.ExternalDNS[edns.Namespace] = len(crMgr.getAllExternalDNS(edns.Namespace))
 	crMgr.TeemData.Unlock()
+	if edns.Spec.DomainName == "" {
+		log.Errorf("No domainName was specified for the ExternalDNS %s", edns.ObjectMeta.Name)
+		crMgr.updateExternalDNSStatus(edns, newCondition(cisapiv1.ConditionValid, false, ReasonInvalidSpec,
+			"No domainName was specified"))
+		return
+	}
 	wip := WideIP{
 		DomainName: edns.Spec.DomainName,
 		RecordType: edns.Spec.DNSRecordType,
@@ -1586,6 +1845,7 @@ func (crMgr *CRManager) processExternalDNS(edns *cisapiv1.ExternalDNS, isDelete
 
 	log.Debugf("Processing WideIP: %v", edns.Spec.DomainName)
 
+	var memberCount int
 	for _, pl := range edns.Spec.Pools {
 		log.Debugf("Processing WideIP Pool: %v", pl.Name)
 		pool := GSLBPool{
@@ -1611,11 +1871,11 @@ func (crMgr *CRManager) processExternalDNS(edns *cisapiv1.ExternalDNS, isDelete
 			}
 			if found {
 				log.Debugf("Adding WideIP Pool Member: %v", fmt.Sprintf("%v:/%v/Shared/%v",
-					pl.DataServerName, DEFAULT_PARTITION, vsName))
+					pl.DataServerName, vs.Virtual.Partition, vsName))
 				pool.Members = append(
 					pool.Members,
 					fmt.Sprintf("%v:/%v/Shared/%v",
-						pl.DataServerName, DEFAULT_PARTITION, vsName),
+						pl.DataServerName, vs.Virtual.Partition, vsName),
 				)
 			}
 		}
@@ -1631,10 +1891,22 @@ func (crMgr *CRManager) processExternalDNS(edns *cisapiv1.ExternalDNS, isDelete
 				Timeout:   pl.Monitor.Timeout,
 			}
 		}
+		memberCount += len(pool.Members)
 		wip.Pools = append(wip.Pools, pool)
 	}
 
 	crMgr.resources.dnsConfig[wip.DomainName] = wip
+
+	programmed := newCondition(cisapiv1.ConditionProgrammed, true, ReasonConfigGenerated, "")
+	if memberCount == 0 {
+		programmed = newCondition(cisapiv1.ConditionProgrammed, false, ReasonNoVirtualServer,
+			fmt.Sprintf("No virtual server found for domain %s", edns.Spec.DomainName))
+	}
+	crMgr.updateExternalDNSStatus(
+		edns,
+		newCondition(cisapiv1.ConditionValid, true, ReasonValidated, ""),
+		programmed,
+	)
 	return
 }
 
@@ -1716,11 +1988,20 @@ func (crMgr *CRManager) processIngressLink(
 		log.Debugf("Finished syncing Ingress Links %+v (%v)",
 			ingLink, endTime.Sub(startTime))
 	}()
+	// Conditions observed while processing are reported in the status of the ingressLink
+	var conditions []metav1.Condition
+	var statusIP string
+	if !isILDeleted {
+		defer func() {
+			crMgr.updateIngressLinkStatus(ingLink, statusIP, conditions...)
+		}()
+	}
 	// Skip validation for a deleted ingressLink
 	if !isILDeleted {
 		// check if the virutal server matches all the requirements.
 		vkey := ingLink.ObjectMeta.Namespace + "/" + ingLink.ObjectMeta.Name
-		valid := crMgr.checkValidIngressLink(ingLink)
+		valid, cond := crMgr.checkValidIngressLink(ingLink)
+		conditions = append(conditions, cond)
 		if false == valid {
 			log.Errorf("ingressLink %s, is not valid",
 				vkey)
@@ -1730,26 +2011,33 @@ func (crMgr *CRManager) processIngressLink(
 	var ip string
 	var key string
 	key = ingLink.ObjectMeta.Namespace + "/" + ingLink.ObjectMeta.Name + "_il"
-	if crMgr.ipamCli != nil {
+	if crMgr.ipamProvider != nil {
 		if isILDeleted && ingLink.Spec.VirtualServerAddress == "" {
 			ip = crMgr.releaseIP(ingLink.Spec.IPAMLabel, "", key)
 		} else if ingLink.Spec.VirtualServerAddress != "" {
 			ip = ingLink.Spec.VirtualServerAddress
+			conditions = append(conditions, newCondition(cisapiv1.ConditionIPAllocated, true, ReasonStaticAddress, ""))
 		} else {
-			ip = crMgr.requestIP(ingLink.Spec.IPAMLabel, "", key)
+			var err error
+			ip, err = crMgr.requestIP(ingLink.Spec.IPAMLabel, "", key)
 			log.Debugf("[ipam] requested IP for ingLink %v is: %v", ingLink.ObjectMeta.Name, ip)
 			if ip == "" {
 				log.Debugf("[ipam] requested IP for ingLink %v is empty.", ingLink.ObjectMeta.Name)
+				conditions = append(conditions, crMgr.setIPAMPending(IngressLink, ingLink, ingLink.Namespace,
+					ingLink.Name, "label "+ingLink.Spec.IPAMLabel, err))
 				return nil
 			}
-			crMgr.updateIngressLinkStatus(ingLink, ip)
+			statusIP = ip
+			conditions = append(conditions, newCondition(cisapiv1.ConditionIPAllocated, true, ReasonIPAMAllocated, ""))
 		}
 	} else {
 		if ingLink.Spec.VirtualServerAddress == "" {
 			return fmt.Errorf("No VirtualServer address in ingLink or IPAM found.")
 		}
 		ip = ingLink.Spec.VirtualServerAddress
+		conditions = append(conditions, newCondition(cisapiv1.ConditionIPAllocated, true, ReasonStaticAddress, ""))
 	}
+	ip, _ = withResourceRouteDomain(ingLink.Spec.RouteDomain, ingLink.Spec.VirtualServerAddress, ip, "")
 	if isILDeleted {
 		var delRes []string
 		for k := range crMgr.resources.rsMap {
@@ -1774,10 +2062,14 @@ func (crMgr *CRManager) processIngressLink(
 	crMgr.TeemData.Unlock()
 	svc, err := crMgr.getKICServiceOfIngressLink(ingLink)
 	if err != nil {
+		conditions = append(conditions, newCondition(cisapiv1.ConditionProgrammed, false,
+			ReasonProcessingFailed, err.Error()))
 		return err
 	}
 
 	if svc == nil {
+		conditions = append(conditions, newCondition(cisapiv1.ConditionProgrammed, false,
+			ReasonProcessingFailed, "No service found matching the selector"))
 		return nil
 	}
 	targetPort := nginxMonitorPort
@@ -1807,6 +2099,9 @@ func (crMgr *CRManager) processIngressLink(
 		rsCfg.Virtual.Enabled = true
 		rsCfg.Virtual.Name = rsName
 		rsCfg.Virtual.SNAT = DEFAULT_SNAT
+		rsCfg.MetaData.baseResources = map[string]string{
+			ingLink.ObjectMeta.Namespace + "/" + ingLink.ObjectMeta.Name: IngressLink,
+		}
 		if len(ingLink.Spec.IRules) > 0 {
 			rsCfg.Virtual.IRules = ingLink.Spec.IRules
 		}
@@ -1842,6 +2137,7 @@ func (crMgr *CRManager) processIngressLink(
 			crMgr.updatePoolMembersForCluster(rsCfg, ingLink.ObjectMeta.Namespace)
 		}
 	}
+	conditions = append(conditions, newCondition(cisapiv1.ConditionProgrammed, true, ReasonConfigGenerated, ""))
 
 	return nil
 }
@@ -2112,27 +2408,3 @@ func getNodeport(svc *v1.Service, servicePort int32) int32 {
 	}
 	return 0
 }
-
-//Update virtual server status with virtual server address
-func (crMgr *CRManager) updateVirtualServerStatus(vs *cisapiv1.VirtualServer, ip string) {
-	// Set the vs status to include the virtual IP address
-	vsStatus := cisapiv1.VirtualServerStatus{VSAddress: ip}
-	vs.Status = vsStatus
-	_, updateErr := crMgr.kubeCRClient.CisV1().VirtualServers(vs.ObjectMeta.Namespace).UpdateStatus(context.TODO(), vs, metav1.UpdateOptions{})
-	if nil != updateErr {
-		log.Debugf("Error while updating virtual server status:%v", updateErr)
-		return
-	}
-}
-
-//Update ingresslink status with virtual server address
-func (crMgr *CRManager) updateIngressLinkStatus(il *cisapiv1.IngressLink, ip string) {
-	// Set the vs status to include the virtual IP address
-	ilStatus := cisapiv1.IngressLinkStatus{VSAddress: ip}
-	il.Status = ilStatus
-	_, updateErr := crMgr.kubeCRClient.CisV1().IngressLinks(il.ObjectMeta.Namespace).UpdateStatus(context.TODO(), il, metav1.UpdateOptions{})
-	if nil != updateErr {
-		log.Debugf("Error while updating ingresslink status:%v", updateErr)
-		return
-	}
-}
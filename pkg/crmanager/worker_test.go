@@ -8,8 +8,12 @@ import (
 	cisinfv1 "github.com/F5Networks/k8s-bigip-ctlr/config/client/informers/externalversions/cis/v1"
 	apm "github.com/F5Networks/k8s-bigip-ctlr/pkg/appmanager"
 	"github.com/F5Networks/k8s-bigip-ctlr/pkg/teem"
+	"github.com/F5Networks/k8s-bigip-ctlr/pkg/writer"
+	"io/ioutil"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"os"
 	"reflect"
 	"sort"
 	"time"
@@ -19,6 +23,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -147,6 +152,7 @@ var _ = Describe("Worker Tests", func() {
 	})
 
 	Describe("IPAM", func() {
+		var ipamProvider *ipamCRProvider
 		DEFAULT_PARTITION = "Test"
 		BeforeEach(func() {
 			mockCRM.Agent = &Agent{
@@ -156,26 +162,27 @@ var _ = Describe("Worker Tests", func() {
 					},
 				},
 			}
-			mockCRM.ipamCli = ipammachinery.NewFakeIPAMClient(nil, nil, nil)
+			ipamProvider = newIPAMCRProvider(ipammachinery.NewFakeIPAMClient(nil, nil, nil))
+			mockCRM.ipamProvider = ipamProvider
 		})
 
 		It("Create IPAM Custom Resource", func() {
-			err := mockCRM.createIPAMResource()
+			err := ipamProvider.createIPAMResource()
 			Expect(err).To(BeNil(), "Failed to Create IPAM Custom Resource")
-			err = mockCRM.createIPAMResource()
+			err = ipamProvider.createIPAMResource()
 			Expect(err).To(BeNil(), "Failed to Create IPAM Custom Resource")
 
 		})
 
 		It("Get IPAM Resource", func() {
-			_ = mockCRM.createIPAMResource()
-			ipamCR := mockCRM.getIPAMCR()
+			_ = ipamProvider.createIPAMResource()
+			ipamCR := ipamProvider.getIPAMCR()
 			Expect(ipamCR).NotTo(BeNil(), "Failed to GET IPAM")
-			mockCRM.ipamCR = mockCRM.ipamCR + "invalid"
-			ipamCR = mockCRM.getIPAMCR()
+			ipamProvider.ipamCR = ipamProvider.ipamCR + "invalid"
+			ipamCR = ipamProvider.getIPAMCR()
 			Expect(ipamCR).To(BeNil(), "Failed to GET IPAM")
-			mockCRM.ipamCR = mockCRM.ipamCR + "/invalid"
-			ipamCR = mockCRM.getIPAMCR()
+			ipamProvider.ipamCR = ipamProvider.ipamCR + "/invalid"
+			ipamCR = ipamProvider.getIPAMCR()
 			Expect(ipamCR).To(BeNil(), "Failed to GET IPAM")
 		})
 
@@ -186,7 +193,7 @@ var _ = Describe("Worker Tests", func() {
 			testSpec["key"] = "ns/name"
 
 			for sp, val := range testSpec {
-				_ = mockCRM.createIPAMResource()
+				_ = ipamProvider.createIPAMResource()
 				var key, host, errHint string
 				if sp == "host" {
 					host = val
@@ -198,27 +205,28 @@ var _ = Describe("Worker Tests", func() {
 					errHint = "Key: "
 				}
 
-				ip := mockCRM.requestIP("test", host, key)
+				ip, _ := ipamProvider.RequestIP("test", host, key)
 				Expect(ip).To(BeEmpty(), errHint+"Invalid IP")
-				ipamCR := mockCRM.getIPAMCR()
+				Expect(ipamProvider.requests).To(HaveKey("test/"+host+"/"+key), errHint+"IPAM Request not tracked")
+				ipamCR := ipamProvider.getIPAMCR()
 				Expect(len(ipamCR.Spec.HostSpecs)).To(Equal(1), errHint+"Invalid number of Host Specs")
 				Expect(ipamCR.Spec.HostSpecs[0].IPAMLabel).To(Equal("test"), errHint+"IPAM Request Failed")
 				Expect(ipamCR.Spec.HostSpecs[0].Host).To(Equal(host), errHint+"IPAM Request Failed")
 				Expect(ipamCR.Spec.HostSpecs[0].Key).To(Equal(key), errHint+"IPAM Request Failed")
 
-				ip = mockCRM.requestIP("", host, key)
+				ip, _ = ipamProvider.RequestIP("", host, key)
 				Expect(ip).To(BeEmpty(), errHint+"Invalid IP")
-				newIPAMCR := mockCRM.getIPAMCR()
+				newIPAMCR := ipamProvider.getIPAMCR()
 				Expect(reflect.DeepEqual(ipamCR, newIPAMCR)).To(BeTrue(), errHint+"IPAM CR should not be updated")
 
-				ip = mockCRM.requestIP("test", host, key)
+				ip, _ = ipamProvider.RequestIP("test", host, key)
 				Expect(ip).To(BeEmpty(), errHint+"Invalid IP")
-				newIPAMCR = mockCRM.getIPAMCR()
+				newIPAMCR = ipamProvider.getIPAMCR()
 				Expect(reflect.DeepEqual(ipamCR, newIPAMCR)).To(BeTrue(), errHint+"IPAM CR should not be updated")
 
-				ip = mockCRM.requestIP("test", host, key)
+				ip, _ = ipamProvider.RequestIP("test", host, key)
 				Expect(ip).To(BeEmpty(), errHint+"Invalid IP")
-				newIPAMCR = mockCRM.getIPAMCR()
+				newIPAMCR = ipamProvider.getIPAMCR()
 				Expect(reflect.DeepEqual(ipamCR, newIPAMCR)).To(BeTrue(), errHint+"IPAM CR should not be updated")
 
 				ipamCR.Status.IPStatus = []*ficV1.IPSpec{
@@ -229,24 +237,25 @@ var _ = Describe("Worker Tests", func() {
 						Key:       key,
 					},
 				}
-				ipamCR, _ = mockCRM.ipamCli.Update(ipamCR)
-				ip = mockCRM.requestIP("test", host, key)
+				ipamCR, _ = ipamProvider.ipamCli.Update(ipamCR)
+				ip, _ = ipamProvider.RequestIP("test", host, key)
 				Expect(ip).To(Equal("10.10.10.1"), errHint+"Invalid IP")
-				ipamCR = mockCRM.getIPAMCR()
+				Expect(ipamProvider.requests).NotTo(HaveKey("test/"+host+"/"+key), errHint+"IPAM Request not completed")
+				ipamCR = ipamProvider.getIPAMCR()
 				Expect(len(ipamCR.Spec.HostSpecs)).To(Equal(1), errHint+"Invalid number of Host Specs")
 				Expect(ipamCR.Spec.HostSpecs[0].IPAMLabel).To(Equal("test"), errHint+"IPAM Request Failed")
 				Expect(ipamCR.Spec.HostSpecs[0].Host).To(Equal(host), errHint+"IPAM Request Failed")
 				Expect(ipamCR.Spec.HostSpecs[0].Key).To(Equal(key), errHint+"IPAM Request Failed")
 
-				ip = mockCRM.requestIP("dev", host, key)
+				ip, _ = ipamProvider.RequestIP("dev", host, key)
 				Expect(ip).To(BeEmpty(), errHint+"Invalid IP")
-				ipamCR = mockCRM.getIPAMCR()
+				ipamCR = ipamProvider.getIPAMCR()
 				// TODO: The expected number of Specs is 1. After the bug gest fixed update this to 1 from 2.
 				Expect(len(ipamCR.Spec.HostSpecs)).To(Equal(2), errHint+"Invalid number of Host Specs")
 
-				ip = mockCRM.requestIP("test", "", "")
+				ip, _ = ipamProvider.RequestIP("test", "", "")
 				Expect(ip).To(BeEmpty(), errHint+"Invalid IP")
-				newIPAMCR = mockCRM.getIPAMCR()
+				newIPAMCR = ipamProvider.getIPAMCR()
 				Expect(reflect.DeepEqual(ipamCR, newIPAMCR)).To(BeTrue(), errHint+"IPAM CR should not be updated")
 			}
 		})
@@ -257,7 +266,7 @@ var _ = Describe("Worker Tests", func() {
 			testSpec["key"] = "ns/name"
 
 			for sp, val := range testSpec {
-				_ = mockCRM.createIPAMResource()
+				_ = ipamProvider.createIPAMResource()
 				var key, host, errHint string
 				if sp == "host" {
 					host = val
@@ -269,10 +278,10 @@ var _ = Describe("Worker Tests", func() {
 					errHint = "Key: "
 				}
 
-				ip := mockCRM.releaseIP("", host, key)
+				ip := ipamProvider.ReleaseIP("", host, key)
 				Expect(ip).To(BeEmpty(), errHint+"Unexpected IP address released")
 
-				ipamCR := mockCRM.getIPAMCR()
+				ipamCR := ipamProvider.getIPAMCR()
 				ipamCR.Spec.HostSpecs = []*ficV1.HostSpec{
 					{
 						IPAMLabel: "test",
@@ -288,15 +297,38 @@ var _ = Describe("Worker Tests", func() {
 						Key:       key,
 					},
 				}
-				ipamCR, _ = mockCRM.ipamCli.Update(ipamCR)
+				ipamCR, _ = ipamProvider.ipamCli.Update(ipamCR)
 
-				ip = mockCRM.releaseIP("test", host, key)
-				ipamCR = mockCRM.getIPAMCR()
+				ip = ipamProvider.ReleaseIP("test", host, key)
+				ipamCR = ipamProvider.getIPAMCR()
 				Expect(len(ipamCR.Spec.HostSpecs)).To(Equal(0), errHint+"IP Address Not released")
 				Expect(ip).To(Equal("10.10.10.1"), errHint+"Wrong IP Address released")
 			}
 		})
 
+		It("VirtualServer without a host is not waiting for IPAM", func() {
+			_ = ipamProvider.createIPAMResource()
+			mockCRM.TeemData = &teem.TeemsData{
+				ResourceType: teem.ResourceTypes{
+					VirtualServer: make(map[string]int),
+				},
+			}
+			vrt1.Spec.Host = ""
+			vrt1.Spec.VirtualServerAddress = ""
+			vrt1.Spec.IPAMLabel = "test"
+			_ = mockCRM.crInformers[namespace].vsInformer.GetIndexer().Add(vrt1)
+
+			Expect(mockCRM.processVirtualServers(vrt1, false)).To(BeNil())
+			Expect(mockCRM.ipamPending).To(BeEmpty(), "VirtualServer should not wait for IPAM")
+			Expect(ipamProvider.getIPAMCR().Spec.HostSpecs).To(BeEmpty(), "Address requested without host")
+			updatedVS, err := mockCRM.kubeCRClient.CisV1().VirtualServers(namespace).Get(
+				context.TODO(), vrt1.Name, metav1.GetOptions{})
+			Expect(err).To(BeNil())
+			cond := meta.FindStatusCondition(updatedVS.Status.Conditions, cisapiv1.ConditionIPAllocated)
+			Expect(cond).NotTo(BeNil())
+			Expect(cond.Reason).To(Equal(ReasonInvalidSpec))
+		})
+
 		It("IPAM Label", func() {
 			vrt2 := test.NewVirtualServer(
 				"SampleVS2",
@@ -431,6 +463,78 @@ var _ = Describe("Worker Tests", func() {
 			Expect(res[1]).To(Equal(vrt3), "Wrong list of Virtual Servers")
 		})
 
+		It("Filter VS for Secret", func() {
+			tlsProf := test.NewTLSProfile("sampleTLS", namespace, cisapiv1.TLSProfileSpec{
+				Hosts: []string{"test2.com"},
+				TLS: cisapiv1.TLS{
+					Termination: TLSEdge,
+					Reference:   Secret,
+					ClientSSL:   "clientsecret",
+				},
+			})
+			otherTLSProf := test.NewTLSProfile("otherTLS", namespace, cisapiv1.TLSProfileSpec{
+				Hosts: []string{"test3.com"},
+				TLS: cisapiv1.TLS{
+					Termination: TLSEdge,
+					Reference:   Secret,
+					ClientSSL:   "othersecret",
+				},
+			})
+			vrt2 := test.NewVirtualServer(
+				"SampleVS2",
+				namespace,
+				cisapiv1.VirtualServerSpec{
+					Host:                 "test2.com",
+					VirtualServerAddress: "1.2.3.5",
+					TLSProfileName:       "sampleTLS",
+				})
+			vrt3 := test.NewVirtualServer(
+				"SampleVS3",
+				namespace,
+				cisapiv1.VirtualServerSpec{
+					Host:                 "test3.com",
+					VirtualServerAddress: "1.2.3.6",
+					TLSProfileName:       "otherTLS",
+				})
+			crInf := mockCRM.crInformers["default"]
+			_ = crInf.tlsInformer.GetIndexer().Add(tlsProf)
+			_ = crInf.tlsInformer.GetIndexer().Add(otherTLSProf)
+			_ = crInf.vsInformer.GetIndexer().Add(vrt1)
+			_ = crInf.vsInformer.GetIndexer().Add(vrt2)
+			_ = crInf.vsInformer.GetIndexer().Add(vrt3)
+
+			res := mockCRM.getVirtualsForSecret(namespace, "clientsecret")
+			Expect(res).To(Equal([]*cisapiv1.VirtualServer{vrt2}), "Wrong list of Virtual Servers")
+			Expect(mockCRM.getVirtualsForSecret(namespace, "unusedsecret")).To(BeEmpty(),
+				"Secret not referenced by TLSProfiles should not affect Virtual Servers")
+
+			// Secret used as the client certificate of a pool monitor
+			vrt3.Spec.Pools = []cisapiv1.Pool{{
+				Service: "svc1",
+				Monitors: []cisapiv1.Monitor{
+					{Type: "https", Send: "GET /", ClientCertificate: "monitorsecret"},
+				},
+			}}
+			_ = crInf.vsInformer.GetIndexer().Update(vrt3)
+			res = mockCRM.getVirtualsForSecret(namespace, "monitorsecret")
+			Expect(res).To(Equal([]*cisapiv1.VirtualServer{vrt3}), "Wrong list of Virtual Servers")
+
+			ts := test.NewTransportServer(
+				"SampleTS",
+				namespace,
+				cisapiv1.TransportServerSpec{
+					Pool: cisapiv1.Pool{
+						Service: "svc1",
+						Monitor: cisapiv1.Monitor{Type: "https", Send: "GET /", ClientCertificate: "monitorsecret"},
+					},
+				},
+			)
+			_ = crInf.tsInformer.GetIndexer().Add(ts)
+			Expect(mockCRM.getTransportServersForMonitorSecret(namespace, "monitorsecret")).To(
+				Equal([]*cisapiv1.TransportServer{ts}), "Wrong list of Transport Servers")
+			Expect(mockCRM.getTransportServersForMonitorSecret(namespace, "unusedsecret")).To(BeEmpty())
+		})
+
 		It("VS Handling HTTP", func() {
 			Expect(doesVSHandleHTTP(vrt1)).To(BeTrue(), "HTTP VS in invalid")
 			vrt1.Spec.TLSProfileName = "TLSProf"
@@ -439,6 +543,52 @@ var _ = Describe("Worker Tests", func() {
 			Expect(doesVSHandleHTTP(vrt1)).To(BeTrue(), "HTTPS VS in invalid")
 		})
 
+		It("VirtualServer with alternate backends", func() {
+			vrt1.Spec.Pools[0].AlternateBackends = []cisapiv1.AlternateBackend{
+				{Service: "svc2"},
+			}
+			_ = mockCRM.crInformers[namespace].vsInformer.GetIndexer().Add(vrt1)
+			valid, _ := mockCRM.checkValidVirtualServer(vrt1)
+			Expect(valid).To(BeTrue())
+			vrt1.Spec.Host = ""
+			valid, cond := mockCRM.checkValidVirtualServer(vrt1)
+			Expect(valid).To(BeFalse(), "Alternate backends accepted without host")
+			Expect(cond.Reason).To(Equal(ReasonInvalidSpec))
+		})
+
+		It("VirtualServer and TransportServer with an invalid monitor", func() {
+			vrt1.Spec.Pools[0].Monitors = []cisapiv1.Monitor{
+				{Reference: "/Common/my_monitor"},
+				{Type: "dns", Interval: 5},
+			}
+			_ = mockCRM.crInformers[namespace].vsInformer.GetIndexer().Add(vrt1)
+			valid, cond := mockCRM.checkValidVirtualServer(vrt1)
+			Expect(valid).To(BeFalse(), "Monitor accepted without queryName")
+			Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+			Expect(cond.Reason).To(Equal(ReasonInvalidSpec))
+			vrt1.Spec.Pools[0].Monitors[1].QueryName = "example.com"
+			valid, _ = mockCRM.checkValidVirtualServer(vrt1)
+			Expect(valid).To(BeTrue())
+
+			ts := test.NewTransportServer(
+				"SampleTS",
+				namespace,
+				cisapiv1.TransportServerSpec{
+					VirtualServerAddress: "1.2.3.4",
+					VirtualServerPort:    8080,
+					Pool: cisapiv1.Pool{
+						Service:     "svc1",
+						ServicePort: 80,
+						Monitor:     cisapiv1.Monitor{Type: "tcp", Interval: 5, ServerName: "test.com"},
+					},
+				},
+			)
+			_ = mockCRM.crInformers[namespace].tsInformer.GetIndexer().Add(ts)
+			valid, cond = mockCRM.checkValidTransportServer(ts)
+			Expect(valid).To(BeFalse(), "serverName accepted for tcp monitor")
+			Expect(cond.Reason).To(Equal(ReasonInvalidSpec))
+		})
+
 		Describe("Filter Associated VirtualServers", func() {
 			var vrt2, vrt3, vrt4 *cisapiv1.VirtualServer
 			BeforeEach(func() {
@@ -565,6 +715,21 @@ var _ = Describe("Worker Tests", func() {
 					false)
 				Expect(virts).To(BeNil(), "Wrong Number of Virtual Servers")
 			})
+
+			It("Virtuals with same Virtual Address, but different Partitions", func() {
+				mockCRM.Partition = "test"
+				vrt3.Spec.Partition = "test"
+				virts := mockCRM.getAssociatedVirtualServers(vrt2,
+					[]*cisapiv1.VirtualServer{vrt2, vrt3},
+					false)
+				Expect(len(virts)).To(Equal(2), "Default partition should be same as the partition of CIS")
+
+				vrt3.Spec.Partition = "team1"
+				virts = mockCRM.getAssociatedVirtualServers(vrt2,
+					[]*cisapiv1.VirtualServer{vrt2, vrt3},
+					false)
+				Expect(virts).To(BeNil(), "Wrong Number of Virtual Servers")
+			})
 		})
 	})
 	Describe("Endpoints", func() {
@@ -666,6 +831,31 @@ var _ = Describe("Worker Tests", func() {
 			mems = mockCRM.getEndpointsForCluster("http", nil, 80, "13.13.13.1")
 			Expect(len(mems)).To(Equal(0), "Wrong set of Endpoints for Cluster")
 		})
+
+		It("IPv6 Cluster", func() {
+			ports := []v1.EndpointPort{
+				{
+					Name: "http",
+					Port: 80,
+				},
+			}
+			eps := test.NewEndpoints("svc1", "1", "worker1", namespace,
+				[]string{"2001:db8:0:0::a", "fd00::b", "invalid"}, nil, ports)
+
+			mems := mockCRM.getEndpointsForCluster("http", eps, 80, "fd00::1")
+			Expect(mems).To(Equal([]Member{
+				{
+					Address: "2001:db8::a",
+					Port:    80,
+					Session: "user-enabled",
+				},
+				{
+					Address: "fd00::b",
+					Port:    80,
+					Session: "user-enabled",
+				},
+			}), "Wrong set of IPv6 Endpoints for Cluster")
+		})
 	})
 
 	Describe("Processing Resources", func() {
@@ -681,7 +871,8 @@ var _ = Describe("Worker Tests", func() {
 					},
 				},
 			}
-			mockCRM.ipamCli = ipammachinery.NewFakeIPAMClient(nil, nil, nil)
+			ipamProvider := newIPAMCRProvider(ipammachinery.NewFakeIPAMClient(nil, nil, nil))
+			mockCRM.ipamProvider = ipamProvider
 			mockCRM.eventNotifier = apm.NewEventNotifier(nil)
 
 			svc1.Spec.Type = v1.ServiceTypeLoadBalancer
@@ -700,8 +891,8 @@ var _ = Describe("Worker Tests", func() {
 			_ = mockCRM.processLBServices(svc1, false)
 			Expect(len(mockCRM.resources.rsMap)).To(Equal(0), "Resource Config should be empty")
 
-			_ = mockCRM.createIPAMResource()
-			ipamCR := mockCRM.getIPAMCR()
+			_ = ipamProvider.createIPAMResource()
+			ipamCR := ipamProvider.getIPAMCR()
 
 			ipamCR.Status.IPStatus = []*ficV1.IPSpec{
 				{
@@ -711,7 +902,7 @@ var _ = Describe("Worker Tests", func() {
 					Key:       svc1.Namespace + "/" + svc1.Name + "_svc",
 				},
 			}
-			ipamCR, _ = mockCRM.ipamCli.Update(ipamCR)
+			ipamCR, _ = ipamProvider.ipamCli.Update(ipamCR)
 
 			_ = mockCRM.processLBServices(svc1, false)
 			Expect(len(mockCRM.resources.rsMap)).To(Equal(1), "Invalid Resource Configs")
@@ -724,6 +915,47 @@ var _ = Describe("Worker Tests", func() {
 			Expect(len(svc1.Status.LoadBalancer.Ingress)).To(Equal(0))
 		})
 
+		It("Processing ServiceTypeLoadBalancer with static IP", func() {
+			mockCRM.Agent = &Agent{
+				PostManager: &PostManager{
+					PostParams: PostParams{
+						BIGIPURL: "10.10.10.1",
+					},
+				},
+			}
+			mockCRM.eventNotifier = apm.NewEventNotifier(nil)
+			mockCRM.Partition = "test"
+			mockCRM.resources.Init()
+
+			svc1.Spec.Type = v1.ServiceTypeLoadBalancer
+			svc1.Spec.LoadBalancerIP = "10.10.10.2"
+			svc1, _ = mockCRM.kubeClient.CoreV1().Services(svc1.ObjectMeta.Namespace).UpdateStatus(context.TODO(), svc1, metav1.UpdateOptions{})
+
+			// spec.loadBalancerIP without IPAM
+			_ = mockCRM.processLBServices(svc1, false)
+			Expect(len(mockCRM.resources.rsMap)).To(Equal(1), "Invalid Resource Configs")
+			rsCfg, ok := mockCRM.resources.rsMap["vs_lb_svc_default_svc1_10.10.10.2_80"]
+			Expect(ok).To(BeTrue(), "Failed to process Service with loadBalancerIP")
+			Expect(rsCfg.Virtual.Destination).To(Equal("/test/10.10.10.2:80"))
+			Expect(svc1.Status.LoadBalancer.Ingress[0].IP).To(Equal("10.10.10.2"))
+
+			_ = mockCRM.processLBServices(svc1, true)
+			Expect(len(mockCRM.resources.rsMap)).To(Equal(0), "Invalid Resource Configs")
+
+			// Annotation takes precedence over spec.loadBalancerIP
+			svc1.Annotations = map[string]string{LBServiceIPAnnotation: "10.10.10.3"}
+			_ = mockCRM.processLBServices(svc1, false)
+			_, ok = mockCRM.resources.rsMap["vs_lb_svc_default_svc1_10.10.10.3_80"]
+			Expect(ok).To(BeTrue(), "Failed to process Service with IP annotation")
+
+			_ = mockCRM.processLBServices(svc1, true)
+			Expect(len(mockCRM.resources.rsMap)).To(Equal(0), "Invalid Resource Configs")
+
+			svc1.Annotations[LBServiceIPAnnotation] = "10.10.10"
+			_ = mockCRM.processLBServices(svc1, false)
+			Expect(len(mockCRM.resources.rsMap)).To(Equal(0), "Invalid IP address should be rejected")
+		})
+
 		It("Processing External DNS", func() {
 			mockCRM.resources.Init()
 			mockCRM.TeemData = &teem.TeemsData{
@@ -769,6 +1001,381 @@ var _ = Describe("Worker Tests", func() {
 			mockCRM.processExternalDNS(newEDNS, true)
 			Expect(len(mockCRM.resources.dnsConfig)).To(Equal(0))
 		})
+
+		It("Processing dual-stack VirtualServer", func() {
+			mockCRM.resources.Init()
+			mockCRM.TeemData = &teem.TeemsData{
+				ResourceType: teem.ResourceTypes{
+					VirtualServer: make(map[string]int),
+				},
+			}
+			mockCRM.Partition = "test"
+			vrt1.Spec.IPv6VirtualServerAddress = "2001:db8::4"
+			_ = mockCRM.crInformers[namespace].vsInformer.GetIndexer().Add(vrt1)
+
+			Expect(mockCRM.processVirtualServers(vrt1, false)).To(BeNil())
+			Expect(len(mockCRM.resources.rsMap)).To(Equal(2), "Virtual not created for each address")
+			ipv4Cfg := mockCRM.resources.rsMap["crd_1_2_3_4_80"]
+			ipv6Cfg := mockCRM.resources.rsMap["crd_2001_db8__4_80"]
+			Expect(ipv4Cfg).NotTo(BeNil())
+			Expect(ipv6Cfg).NotTo(BeNil())
+			Expect(ipv6Cfg.Virtual.Destination).To(Equal("/test/2001:db8::4.80"))
+			Expect(ipv6Cfg.Pools).To(Equal(ipv4Cfg.Pools), "Pools not shared by the virtuals")
+
+			Expect(mockCRM.processVirtualServers(vrt1, true)).To(BeNil())
+			Expect(len(mockCRM.resources.rsMap)).To(Equal(0), "Virtuals not deleted")
+
+			// Custom virtual server name
+			vrt1.Spec.VirtualServerName = "app"
+			_ = mockCRM.crInformers[namespace].vsInformer.GetIndexer().Update(vrt1)
+			Expect(mockCRM.processVirtualServers(vrt1, false)).To(BeNil())
+			Expect(mockCRM.resources.rsMap).To(HaveKey("app_80"))
+			Expect(mockCRM.resources.rsMap).To(HaveKey("app_ipv6_80"))
+
+			// Primary address of a dual-stack VirtualServer must be IPv4
+			vrt1.Spec.VirtualServerAddress = "2001:db8::5"
+			valid, cond := mockCRM.checkValidVirtualServer(vrt1)
+			Expect(valid).To(BeFalse())
+			Expect(cond.Reason).To(Equal(ReasonInvalidSpec))
+			vrt1.Spec.VirtualServerAddress = "1.2.3.4"
+			vrt1.Spec.IPv6VirtualServerAddress = "1.2.3.5"
+			valid, _ = mockCRM.checkValidVirtualServer(vrt1)
+			Expect(valid).To(BeFalse(), "IPv4 address accepted as IPv6 address")
+			vrt1.Spec.IPv6VirtualServerAddress = ""
+			vrt1.Spec.IPv6IPAMLabel = "dev"
+			valid, _ = mockCRM.checkValidVirtualServer(vrt1)
+			Expect(valid).To(BeFalse(), "ipv6IpamLabel accepted without IPAM")
+		})
+
+		It("Processing VirtualServer in a route domain", func() {
+			mockCRM.resources.Init()
+			mockCRM.TeemData = &teem.TeemsData{
+				ResourceType: teem.ResourceTypes{
+					VirtualServer: make(map[string]int),
+				},
+			}
+			mockCRM.Partition = "test"
+			vrt1.Spec.RouteDomain = 2
+			_ = mockCRM.crInformers[namespace].vsInformer.GetIndexer().Add(vrt1)
+
+			Expect(mockCRM.processVirtualServers(vrt1, false)).To(BeNil())
+			rsCfg := mockCRM.resources.rsMap["crd_1_2_3_4.2_80"]
+			Expect(rsCfg).NotTo(BeNil(), "Virtual not created in the route domain")
+			Expect(rsCfg.Virtual.Destination).To(Equal("/test/1.2.3.4%2:80"))
+			Expect(rsCfg.Virtual.RouteDomain).To(Equal(int32(2)))
+
+			// Same host and address in another route domain
+			vrt2 := vrt1.DeepCopy()
+			vrt2.Name = "SampleVS2"
+			vrt2.Spec.RouteDomain = 3
+			vrt2.Spec.Pools[0].Path = "/path2"
+			Expect(mockCRM.getAssociatedVirtualServers(vrt1, []*cisapiv1.VirtualServer{vrt1, vrt2}, false)).To(BeNil(),
+				"Conflicting route domains accepted")
+
+			// Route domain given with the address
+			vrt1.Spec.RouteDomain = 0
+			vrt1.Spec.VirtualServerAddress = "1.2.3.4%2"
+			valid, _ := mockCRM.checkValidVirtualServer(vrt1)
+			Expect(valid).To(BeTrue())
+			Expect(getRouteDomain(vrt1.Spec.RouteDomain, vrt1.Spec.VirtualServerAddress)).To(Equal(int32(2)))
+
+			vrt1.Spec.RouteDomain = 3
+			valid, cond := mockCRM.checkValidVirtualServer(vrt1)
+			Expect(valid).To(BeFalse(), "Conflicting route domains accepted")
+			Expect(cond.Reason).To(Equal(ReasonInvalidSpec))
+			vrt1.Spec.RouteDomain = 70000
+			vrt1.Spec.VirtualServerAddress = "1.2.3.4"
+			valid, _ = mockCRM.checkValidVirtualServer(vrt1)
+			Expect(valid).To(BeFalse(), "Invalid route domain accepted")
+		})
+
+		It("Processing VirtualServer with session persistence", func() {
+			mockCRM.resources.Init()
+			mockCRM.TeemData = &teem.TeemsData{
+				ResourceType: teem.ResourceTypes{
+					VirtualServer: make(map[string]int),
+				},
+			}
+			mockCRM.Partition = "test"
+			vrt1.Spec.Persistence = &cisapiv1.Persistence{
+				Method:       "cookie",
+				CookieMethod: "hash",
+				CookieName:   "session",
+				Fallback:     "source-address",
+			}
+			_ = mockCRM.crInformers[namespace].vsInformer.GetIndexer().Add(vrt1)
+
+			valid, _ := mockCRM.checkValidVirtualServer(vrt1)
+			Expect(valid).To(BeTrue())
+			Expect(mockCRM.processVirtualServers(vrt1, false)).To(BeNil())
+			rsCfg := mockCRM.resources.rsMap["crd_1_2_3_4_80"]
+			Expect(rsCfg).NotTo(BeNil())
+			Expect(rsCfg.Virtual.Persistence).To(Equal(&Persistence{
+				Method:       "cookie",
+				CookieMethod: "hash",
+				CookieName:   "session",
+				Fallback:     "source-address",
+			}))
+			Expect(rsCfg.Virtual.PersistenceMethods).To(BeEmpty())
+
+			// VirtualServers sharing the virtual need the same persistence
+			vrt2 := vrt1.DeepCopy()
+			vrt2.Name = "SampleVS2"
+			vrt2.Spec.Pools[0].Path = "/path2"
+			Expect(mockCRM.getAssociatedVirtualServers(vrt1, []*cisapiv1.VirtualServer{vrt1, vrt2}, false)).To(HaveLen(2))
+			vrt2.Spec.Persistence = nil
+			Expect(mockCRM.getAssociatedVirtualServers(vrt1, []*cisapiv1.VirtualServer{vrt1, vrt2}, false)).To(HaveLen(2))
+			vrt2.Spec.Persistence = &cisapiv1.Persistence{Method: "source-address"}
+			Expect(mockCRM.getAssociatedVirtualServers(vrt1, []*cisapiv1.VirtualServer{vrt1, vrt2}, false)).To(BeNil(),
+				"Conflicting persistence accepted")
+
+			vrt1.Spec.Persistence.CookieName = ""
+			valid, cond := mockCRM.checkValidVirtualServer(vrt1)
+			Expect(valid).To(BeFalse(), "Hash cookie persistence accepted without cookie name")
+			Expect(cond.Reason).To(Equal(ReasonInvalidSpec))
+			vrt1.Spec.Persistence = &cisapiv1.Persistence{Method: "universal"}
+			valid, _ = mockCRM.checkValidVirtualServer(vrt1)
+			Expect(valid).To(BeFalse(), "Universal persistence accepted without iRule")
+			vrt1.Spec.Persistence = &cisapiv1.Persistence{Reference: "/Common/my_persist", Fallback: "cookie"}
+			valid, _ = mockCRM.checkValidVirtualServer(vrt1)
+			Expect(valid).To(BeFalse(), "Invalid fallback persistence accepted")
+			vrt1.Spec.Persistence.Fallback = "/Common/my_fallback"
+			valid, _ = mockCRM.checkValidVirtualServer(vrt1)
+			Expect(valid).To(BeTrue())
+
+			// Cookies are not supported for TransportServer
+			ts := test.NewTransportServer("SampleTS", namespace, cisapiv1.TransportServerSpec{
+				VirtualServerAddress: "1.2.3.5",
+				VirtualServerPort:    1600,
+				Persistence:          &cisapiv1.Persistence{Method: "cookie"},
+				Pool: cisapiv1.Pool{
+					Service:     "svc1",
+					ServicePort: 80,
+				},
+			})
+			_ = mockCRM.crInformers[namespace].tsInformer.GetIndexer().Add(ts)
+			valid, _ = mockCRM.checkValidTransportServer(ts)
+			Expect(valid).To(BeFalse(), "Cookie persistence accepted for TransportServer")
+			ts.Spec.Persistence.Method = "source-address"
+			valid, _ = mockCRM.checkValidTransportServer(ts)
+			Expect(valid).To(BeTrue())
+		})
+
+		It("Processing VirtualServer with profile references", func() {
+			mockCRM.resources.Init()
+			mockCRM.TeemData = &teem.TeemsData{
+				ResourceType: teem.ResourceTypes{
+					VirtualServer: make(map[string]int),
+				},
+			}
+			mockCRM.Partition = "test"
+			vrt1.Spec.Profiles = &cisapiv1.ProfileSpec{
+				TCP:  cisapiv1.ProfileTCP{Client: "/Common/f5-tcp-mobile"},
+				HTTP: "/Common/http-keepalive",
+			}
+			_ = mockCRM.crInformers[namespace].vsInformer.GetIndexer().Add(vrt1)
+
+			valid, _ := mockCRM.checkValidVirtualServer(vrt1)
+			Expect(valid).To(BeTrue())
+			Expect(mockCRM.processVirtualServers(vrt1, false)).To(BeNil())
+			rsCfg := mockCRM.resources.rsMap["crd_1_2_3_4_80"]
+			Expect(rsCfg).NotTo(BeNil())
+			Expect(rsCfg.Virtual.ProfileSpec).To(Equal(&ProfileSpec{
+				TCPClient: "/Common/f5-tcp-mobile",
+				HTTP:      "/Common/http-keepalive",
+			}))
+
+			ts := test.NewTransportServer("SampleTS", namespace, cisapiv1.TransportServerSpec{
+				VirtualServerAddress: "1.2.3.5",
+				VirtualServerPort:    1600,
+				Mode:                 "standard",
+				Profiles:             &cisapiv1.ProfileSpec{HTTP: "/Common/http"},
+				Pool: cisapiv1.Pool{
+					Service:     "svc1",
+					ServicePort: 80,
+				},
+			})
+			vrt1.Spec.Profiles.HTTP2 = "/Common/http2"
+			valid, cond := mockCRM.checkValidVirtualServer(vrt1)
+			Expect(valid).To(BeFalse(), "HTTP/2 profile accepted without TLSProfile")
+			Expect(cond.Reason).To(Equal(ReasonInvalidSpec))
+
+			_ = mockCRM.crInformers[namespace].tsInformer.GetIndexer().Add(ts)
+			valid, cond = mockCRM.checkValidTransportServer(ts)
+			Expect(valid).To(BeFalse(), "HTTP profile accepted for TransportServer")
+			Expect(cond.Reason).To(Equal(ReasonInvalidSpec))
+			ts.Spec.Profiles = &cisapiv1.ProfileSpec{TCP: cisapiv1.ProfileTCP{Server: "/Common/f5-tcp-lan"}}
+			valid, _ = mockCRM.checkValidTransportServer(ts)
+			Expect(valid).To(BeTrue())
+			ts.Spec.Mode = "performance"
+			valid, _ = mockCRM.checkValidTransportServer(ts)
+			Expect(valid).To(BeFalse(), "TCP profile accepted for performance TransportServer")
+		})
+
+		It("Processing VirtualServer with source ranges", func() {
+			mockCRM.resources.Init()
+			mockCRM.TeemData = &teem.TeemsData{
+				ResourceType: teem.ResourceTypes{
+					VirtualServer:   make(map[string]int),
+					TransportServer: make(map[string]int),
+				},
+			}
+			mockCRM.Partition = "test"
+			vrt1.Spec.AllowSourceRange = []string{"10.0.0.0/8", "2001:db8::1"}
+			vrt1.Spec.DenySourceRange = []string{"10.1.2.3"}
+			_ = mockCRM.crInformers[namespace].vsInformer.GetIndexer().Add(vrt1)
+
+			valid, _ := mockCRM.checkValidVirtualServer(vrt1)
+			Expect(valid).To(BeTrue())
+			Expect(mockCRM.processVirtualServers(vrt1, false)).To(BeNil())
+			rsCfg := mockCRM.resources.rsMap["crd_1_2_3_4_80"]
+			Expect(rsCfg).NotTo(BeNil())
+			Expect(rsCfg.Virtual.AllowSourceRange).To(Equal([]string{"10.0.0.0/8", "2001:db8::1/128"}))
+			Expect(rsCfg.Virtual.DenySourceRange).To(Equal([]string{"10.1.2.3/32"}))
+			Expect(rsCfg.Policies).To(HaveLen(1))
+			rules := rsCfg.Policies[0].Rules
+			Expect(rules[0].Name).To(Equal("deny_source_range"), "Denied ranges not dropped first")
+			Expect(rules[0].Conditions[0].Values).To(Equal([]string{"10.1.2.3/32"}))
+			Expect(rules[1].Name).To(Equal("allow_source_range"))
+			Expect(rules[1].Conditions[0].Not).To(BeTrue(), "Sources in allowed ranges dropped")
+			Expect(rsCfg.Policies[0].Requires).To(ContainElement("tcp"))
+
+			// VirtualServers sharing the virtual need the same source ranges
+			vrt2 := vrt1.DeepCopy()
+			vrt2.Name = "SampleVS2"
+			vrt2.Spec.Pools[0].Path = "/path2"
+			vrt2.Spec.AllowSourceRange = []string{"2001:db8::1/128", "10.0.0.0/8"}
+			Expect(mockCRM.getAssociatedVirtualServers(vrt1, []*cisapiv1.VirtualServer{vrt1, vrt2}, false)).To(HaveLen(2))
+			_ = mockCRM.crInformers[namespace].vsInformer.GetIndexer().Add(vrt2)
+			Expect(mockCRM.processVirtualServers(vrt2, false)).To(BeNil())
+			rsCfg = mockCRM.resources.rsMap["crd_1_2_3_4_80"]
+			Expect(rsCfg.Policies).To(HaveLen(1))
+			Expect(rsCfg.Policies[0].Rules).To(HaveLen(4), "Source range rules not added once")
+			vrt2.Spec.AllowSourceRange = nil
+			Expect(mockCRM.getAssociatedVirtualServers(vrt1, []*cisapiv1.VirtualServer{vrt1, vrt2}, false)).To(BeNil(),
+				"VirtualServer without the allowed ranges of the virtual accepted")
+			vrt2.Spec.AllowSourceRange = vrt1.Spec.AllowSourceRange
+			vrt2.Spec.DenySourceRange = []string{"10.1.2.4"}
+			Expect(mockCRM.getAssociatedVirtualServers(vrt1, []*cisapiv1.VirtualServer{vrt1, vrt2}, false)).To(BeNil(),
+				"Conflicting denied ranges accepted")
+			_ = mockCRM.crInformers[namespace].vsInformer.GetIndexer().Delete(vrt2)
+
+			vrt1.Spec.DenySourceRange = []string{"10.1.2.0/33"}
+			valid, cond := mockCRM.checkValidVirtualServer(vrt1)
+			Expect(valid).To(BeFalse(), "Invalid source range accepted")
+			Expect(cond.Reason).To(Equal(ReasonInvalidSpec))
+
+			ts := test.NewTransportServer("SampleTS", namespace, cisapiv1.TransportServerSpec{
+				VirtualServerAddress: "1.2.3.5",
+				VirtualServerPort:    1600,
+				Mode:                 "standard",
+				AllowSourceRange:     []string{"internal"},
+				Pool: cisapiv1.Pool{
+					Service:     "svc1",
+					ServicePort: 80,
+				},
+			})
+			_ = mockCRM.crInformers[namespace].tsInformer.GetIndexer().Add(ts)
+			valid, _ = mockCRM.checkValidTransportServer(ts)
+			Expect(valid).To(BeFalse(), "Invalid source range accepted for TransportServer")
+			ts.Spec.AllowSourceRange = []string{"192.168.0.0/16"}
+			valid, _ = mockCRM.checkValidTransportServer(ts)
+			Expect(valid).To(BeTrue())
+			Expect(mockCRM.processTransportServers(ts, false)).To(BeNil())
+			rsCfg = mockCRM.resources.rsMap["crd_1_2_3_5_1600"]
+			Expect(rsCfg).NotTo(BeNil())
+			Expect(rsCfg.Policies).To(HaveLen(1))
+			Expect(rsCfg.Policies[0].Name).To(Equal("crd_1_2_3_5_1600_source_range_policy"))
+			Expect(rsCfg.Policies[0].Requires).To(Equal([]string{"tcp"}))
+			Expect(rsCfg.Policies[0].Rules).To(HaveLen(1))
+		})
+
+		It("Processing dual-stack TransportServer with IPAM", func() {
+			mockCRM.resources.Init()
+			mockCRM.TeemData = &teem.TeemsData{
+				ResourceType: teem.ResourceTypes{
+					TransportServer: make(map[string]int),
+				},
+			}
+			_, _ = mockCRM.kubeClient.CoreV1().ConfigMaps("kube-system").Create(context.TODO(), &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cis-ipam",
+					Namespace: "kube-system",
+				},
+				Data: map[string]string{
+					"dev": "2001:db8::/126",
+				},
+			}, metav1.CreateOptions{})
+			mockCRM.ipamProvider = newStaticIPAMProvider(mockCRM.kubeClient, "kube-system", "cis-ipam")
+
+			ts := test.NewTransportServer("SampleTS", namespace, cisapiv1.TransportServerSpec{
+				VirtualServerAddress: "1.2.3.5",
+				VirtualServerPort:    1600,
+				IPv6IPAMLabel:        "dev",
+				Pool: cisapiv1.Pool{
+					Service:     "svc1",
+					ServicePort: 80,
+				},
+			})
+			_ = mockCRM.crInformers[namespace].tsInformer.GetIndexer().Add(ts)
+
+			Expect(mockCRM.processTransportServers(ts, false)).To(BeNil())
+			Expect(mockCRM.resources.rsMap).To(HaveKey("crd_1_2_3_5_1600"))
+			Expect(mockCRM.resources.rsMap).To(HaveKey("crd_2001_db8__1_1600"), "IPv6 address not allocated")
+
+			Expect(mockCRM.processTransportServers(ts, true)).To(BeNil())
+			Expect(len(mockCRM.resources.rsMap)).To(Equal(0), "Virtuals not deleted")
+			Expect(mockCRM.ipamProvider.ReleaseIP("dev", "", namespace+"/SampleTS_ts"+ipv6Suffix)).To(
+				BeEmpty(), "IPv6 address not released")
+		})
+	})
+
+	Describe("Leader Election", func() {
+		var outputDir string
+		BeforeEach(func() {
+			var err error
+			outputDir, err = ioutil.TempDir("", "crd-leader-election")
+			Expect(err).To(BeNil())
+			mockCRM.rscQueue = workqueue.NewNamedRateLimitingQueue(
+				workqueue.DefaultControllerRateLimiter(), "custom-resource-controller")
+			mockCRM.resources = NewResources()
+			mockCRM.initState = true
+			mockCRM.standby = true
+			mockCRM.Agent = newMockAgent(&test.MockWriter{
+				FailStyle: test.Success,
+				Sections:  make(map[string]interface{}),
+			})
+			mockCRM.Agent.EventChan = nil
+			mockCRM.Agent.declWriter, err = writer.NewDeclarationWriter(outputDir)
+			Expect(err).To(BeNil())
+			mockCRM.TeemData = &teem.TeemsData{
+				ResourceType: teem.ResourceTypes{
+					VirtualServer: make(map[string]int),
+				},
+			}
+			rsCfg := &ResourceConfig{}
+			rsCfg.Virtual.Name = "crd_1_2_3_4_80"
+			rsCfg.Virtual.Partition = "test"
+			rsCfg.Virtual.Destination = "/test/1.2.3.4:80"
+			mockCRM.resources.rsMap["crd_1_2_3_4_80"] = rsCfg
+		})
+		AfterEach(func() {
+			os.RemoveAll(outputDir)
+		})
+
+		It("Posts the configuration only after it is elected as the leader", func() {
+			mockCRM.rscQueue.Add(&rqKey{kind: Endpoints, rsc: svc1})
+			Expect(mockCRM.processResource()).To(BeTrue())
+			Expect(mockCRM.initState).To(BeFalse(), "Resources should be processed in standby")
+			Expect(mockCRM.Agent.activeDecl).To(BeEmpty(), "Configuration posted in standby")
+
+			mockCRM.StartLeading()
+			Expect(mockCRM.isStandby()).To(BeFalse())
+			Expect(mockCRM.rscQueue.Len()).To(Equal(1))
+			Expect(mockCRM.processResource()).To(BeTrue())
+			Expect(mockCRM.Agent.activeDecl).NotTo(BeEmpty(), "Configuration not posted by the leader")
+			Expect(mockCRM.resources.oldRsMap).To(HaveKey("crd_1_2_3_4_80"))
+		})
 	})
 
 	It("get node port", func() {
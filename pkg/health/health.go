@@ -9,12 +9,19 @@ import (
 
 type HealthChecker struct {
 	SubPID int
+	// Python process is not started in dry-run mode
+	DryRun bool
 }
 
 //TODO: Add additional health checks
 //TODO: add health check if Kubernetes API is still reachable
 func (hc HealthChecker) HealthCheckHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hc.DryRun {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("Ok"))
+			return
+		}
 		if hc.SubPID != 0 {
 			_, err := os.FindProcess(hc.SubPID)
 			if err == nil {
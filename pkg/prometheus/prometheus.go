@@ -31,11 +31,67 @@ var CurrentErrors = prometheus.NewGaugeVec(
 	[]string{},
 )
 
-// further metrics? todo think about
+var ProcessingDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "bigip_resource_processing_duration_seconds",
+		Help:    "Duration of processing the resources to the BigIP configuration",
+		Buckets: prometheus.DefBuckets,
+	},
+	[]string{"function"},
+)
+
+var AS3PostDuration = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:    "bigip_as3_post_duration_seconds",
+		Help:    "Duration of posting the AS3 declarations to BigIP by the response code",
+		Buckets: []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120},
+	},
+	[]string{"bigip", "code"},
+)
+
+var AS3DeclarationSize = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Name: "bigip_as3_declaration_size_bytes",
+		Help: "Size of the AS3 declaration last posted to BigIP",
+	},
+)
+
+var AS3LastSuccessfulPost = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "bigip_as3_last_successful_post_timestamp_seconds",
+		Help: "Time of the AS3 declaration last accepted by BigIP",
+	},
+	[]string{"bigip"},
+)
+
+var IPAMRequestDuration = prometheus.NewHistogram(
+	prometheus.HistogramOpts{
+		Name:    "bigip_ipam_request_duration_seconds",
+		Help:    "Duration from requesting an IP address to its allocation by the IPAM controller",
+		Buckets: []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	},
+)
+
+var IPAMPendingAllocations = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "bigip_ipam_pending_allocations",
+		Help: "Number of resources waiting for IPAM to allocate an IP address by the resource kind",
+	},
+	[]string{"kind"},
+)
+
 // RegisterMetrics registers all Prometheus metrics defined above
+// and the metrics of the work queues created afterwards
 func RegisterMetrics() {
 	log.Info("[CORE] Registered BigIP Metrics")
 	prometheus.MustRegister(MonitoredNodes)
 	prometheus.MustRegister(MonitoredServices)
 	prometheus.MustRegister(CurrentErrors)
+	prometheus.MustRegister(ProcessingDuration)
+	prometheus.MustRegister(AS3PostDuration)
+	prometheus.MustRegister(AS3DeclarationSize)
+	prometheus.MustRegister(AS3LastSuccessfulPost)
+	prometheus.MustRegister(IPAMRequestDuration)
+	prometheus.MustRegister(IPAMPendingAllocations)
+	registerWorkqueueMetrics()
 }
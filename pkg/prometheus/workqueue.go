@@ -0,0 +1,112 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// Metrics of the work queues, by the name of the queue
+var (
+	workqueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "bigip_workqueue_depth",
+			Help: "Current depth of the work queue",
+		},
+		[]string{"name"},
+	)
+
+	workqueueAdds = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bigip_workqueue_adds_total",
+			Help: "Total number of items added to the work queue",
+		},
+		[]string{"name"},
+	)
+
+	workqueueLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "bigip_workqueue_queue_duration_seconds",
+			Help:    "Duration of an item waiting in the work queue before being processed",
+			Buckets: prometheus.ExponentialBuckets(10e-9, 10, 10),
+		},
+		[]string{"name"},
+	)
+
+	workqueueWorkDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "bigip_workqueue_work_duration_seconds",
+			Help:    "Duration of processing an item from the work queue",
+			Buckets: prometheus.ExponentialBuckets(10e-9, 10, 10),
+		},
+		[]string{"name"},
+	)
+
+	workqueueUnfinishedWork = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "bigip_workqueue_unfinished_work_seconds",
+			Help: "Duration of the work in progress not yet observed by work_duration, " +
+				"large values indicate stuck workers",
+		},
+		[]string{"name"},
+	)
+
+	workqueueLongestRunningProcessor = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "bigip_workqueue_longest_running_processor_seconds",
+			Help: "Duration of the longest running worker of the work queue",
+		},
+		[]string{"name"},
+	)
+
+	workqueueRetries = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "bigip_workqueue_retries_total",
+			Help: "Total number of items requeued with rate limiting",
+		},
+		[]string{"name"},
+	)
+)
+
+// workqueueMetricsProvider provides the Prometheus metrics to the named work queues
+type workqueueMetricsProvider struct{}
+
+func (workqueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return workqueueDepth.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return workqueueAdds.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewLatencyMetric(name string) workqueue.HistogramMetric {
+	return workqueueLatency.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.HistogramMetric {
+	return workqueueWorkDuration.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return workqueueUnfinishedWork.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewLongestRunningProcessorSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return workqueueLongestRunningProcessor.WithLabelValues(name)
+}
+
+func (workqueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return workqueueRetries.WithLabelValues(name)
+}
+
+// registerWorkqueueMetrics registers the work queue metrics, which are reported
+// only for the queues created after the registration
+func registerWorkqueueMetrics() {
+	prometheus.MustRegister(workqueueDepth)
+	prometheus.MustRegister(workqueueAdds)
+	prometheus.MustRegister(workqueueLatency)
+	prometheus.MustRegister(workqueueWorkDuration)
+	prometheus.MustRegister(workqueueUnfinishedWork)
+	prometheus.MustRegister(workqueueLongestRunningProcessor)
+	prometheus.MustRegister(workqueueRetries)
+	workqueue.SetProvider(workqueueMetricsProvider{})
+}
@@ -0,0 +1,101 @@
+/*-
+ * Copyright (c) 2016-2021, F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resource
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// as3ObjectPath matches the /tenant/application/object references in AS3 errors
+var as3ObjectPath = regexp.MustCompile(`/([\w.\-]+)/([\w.\-]+)/([\w.\-]+)`)
+
+// ParseAS3Failures returns the failures reported in the results or the errors
+// of the AS3 response
+func ParseAS3Failures(responseMap map[string]interface{}) []AS3Failure {
+	var failures []AS3Failure
+	if results, ok := (responseMap["results"]).([]interface{}); ok {
+		for _, value := range results {
+			v, ok := value.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if code, ok := v["code"].(float64); ok && int(code) == http.StatusOK {
+				continue
+			}
+			tenant, _ := v["tenant"].(string)
+			details := []string{fmt.Sprintf("%v", v["message"])}
+			if rsp, ok := v["response"].(string); ok {
+				details = append(details, rsp)
+			}
+			details = append(details, getStrings(v["errors"])...)
+			failures = append(failures, newAS3Failure(tenant, details))
+		}
+	}
+	if len(failures) != 0 {
+		return failures
+	}
+	// Declaration which fails the schema validation is reported with errors
+	for _, err := range getStrings(responseMap["errors"]) {
+		failures = append(failures, newAS3Failure("", []string{err}))
+	}
+	return failures
+}
+
+func newAS3Failure(tenant string, details []string) AS3Failure {
+	failure := AS3Failure{Tenant: tenant}
+	var messages []string
+	for _, detail := range details {
+		if detail == "" || detail == "<nil>" {
+			continue
+		}
+		messages = append(messages, detail)
+		for _, match := range as3ObjectPath.FindAllStringSubmatch(detail, -1) {
+			if failure.Tenant == "" {
+				failure.Tenant = match[1]
+			}
+			if match[1] == failure.Tenant && !contains(failure.Objects, match[3]) {
+				failure.Objects = append(failure.Objects, match[3])
+			}
+		}
+	}
+	failure.Message = strings.Join(messages, ": ")
+	return failure
+}
+
+func getStrings(value interface{}) []string {
+	var strs []string
+	if values, ok := value.([]interface{}); ok {
+		for _, v := range values {
+			if str, ok := v.(string); ok {
+				strs = append(strs, str)
+			}
+		}
+	}
+	return strs
+}
+
+func contains(list []string, str string) bool {
+	for _, s := range list {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
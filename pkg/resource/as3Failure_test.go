@@ -0,0 +1,63 @@
+/*-
+ * Copyright (c) 2016-2021, F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resource
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AS3 Failures", func() {
+	parse := func(body string) []AS3Failure {
+		var responseMap map[string]interface{}
+		Expect(json.Unmarshal([]byte(body), &responseMap)).To(BeNil())
+		return ParseAS3Failures(responseMap)
+	}
+
+	It("Parses failed tenants from results", func() {
+		failures := parse(`{"results":[
+			{"code":200,"message":"success","tenant":"test"},
+			{"code":422,"message":"declaration failed","tenant":"k8s",
+			 "response":"01020036:3: The requested Pool (/k8s/Shared/openshift_default_svc1) was not found."}]}`)
+		Expect(failures).To(HaveLen(1))
+		Expect(failures[0].Tenant).To(Equal("k8s"))
+		Expect(failures[0].Objects).To(Equal([]string{"openshift_default_svc1"}))
+		Expect(failures[0].Message).To(ContainSubstring("declaration failed"))
+		Expect(failures[0].Message).To(ContainSubstring("was not found"))
+	})
+
+	It("Parses schema validation errors", func() {
+		failures := parse(`{"code":422,"message":"declaration is invalid",
+			"errors":["/k8s/Shared/ingress_1_2_3_4_80/virtualAddresses: should have required property",
+			"/test/app/vs/pool: should be string"]}`)
+		Expect(failures).To(HaveLen(2))
+		Expect(failures[0].Tenant).To(Equal("k8s"))
+		Expect(failures[0].Objects).To(Equal([]string{"ingress_1_2_3_4_80"}))
+		Expect(failures[1].Tenant).To(Equal("test"))
+		Expect(failures[1].Objects).To(Equal([]string{"vs"}))
+	})
+
+	It("Tenant failure without objects", func() {
+		failures := parse(`{"results":[{"code":500,"message":"internal error","tenant":"k8s"}]}`)
+		Expect(failures).To(HaveLen(1))
+		Expect(failures[0].Objects).To(BeEmpty())
+		Expect(failures[0].Message).To(Equal("internal error"))
+		Expect(parse(`{"code":404}`)).To(BeEmpty())
+	})
+})
@@ -436,6 +436,20 @@ type (
 
 	ResourceResponse struct {
 		IsResponseSuccessful bool
+		// Errors reported by BIG-IP for the posted AS3 declaration
+		AS3Failures []AS3Failure
+	}
+
+	// AS3Failure is an error reported by BIG-IP for an AS3 tenant
+	AS3Failure struct {
+		Tenant string
+		// Names of the AS3 objects referred by the error
+		Objects []string
+		Message string
+		// Names of the virtuals and pools producing the failed objects
+		Resources []string
+		// AS3 ConfigMaps which declare the tenant as namespace/name
+		ConfigMaps []string
 	}
 
 	MessageRequest struct {
@@ -0,0 +1,198 @@
+/*-
+ * Copyright (c) 2016-2021, F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package tokenmanager authenticates to the iControl REST API of BIG-IP with
+// auth tokens, which are reused until they expire instead of sending the
+// credentials with every request.
+package tokenmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	log "github.com/F5Networks/k8s-bigip-ctlr/pkg/vlogger"
+)
+
+const (
+	// AuthTokenHeader carries the auth token in the iControl REST requests
+	AuthTokenHeader = "X-F5-Auth-Token"
+	// DefaultLoginProvider is the local authentication of BIG-IP
+	DefaultLoginProvider = "tmos"
+
+	loginPath = "/mgmt/shared/authn/login"
+	// BIG-IP issues tokens valid for 1200 seconds by default
+	defaultTokenTimeout = 1200 * time.Second
+)
+
+// TokenManager gets the auth tokens of the BIG-IPs and reuses them until they expire
+type TokenManager struct {
+	mutex         sync.Mutex
+	username      string
+	password      string
+	loginProvider string
+	// tokens by the URL of the BIG-IP
+	tokens map[string]*authToken
+}
+
+type authToken struct {
+	token  string
+	expiry time.Time
+}
+
+type loginRequest struct {
+	Username          string `json:"username"`
+	Password          string `json:"password"`
+	LoginProviderName string `json:"loginProviderName"`
+}
+
+type loginResponse struct {
+	Token struct {
+		Token   string `json:"token"`
+		Timeout int    `json:"timeout"`
+	} `json:"token"`
+}
+
+// NewTokenManager creates a TokenManager logging in with the credentials and the login provider
+func NewTokenManager(username, password, loginProvider string) *TokenManager {
+	if loginProvider == "" {
+		loginProvider = DefaultLoginProvider
+	}
+	return &TokenManager{
+		username:      username,
+		password:      password,
+		loginProvider: loginProvider,
+		tokens:        make(map[string]*authToken),
+	}
+}
+
+// SetCredentials updates the credentials and discards the tokens obtained with the previous ones
+func (tm *TokenManager) SetCredentials(username, password string) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	if tm.username == username && tm.password == password {
+		return
+	}
+	tm.username = username
+	tm.password = password
+	tm.tokens = make(map[string]*authToken)
+}
+
+// Do sends the request with the auth token of the BIG-IP. The token is refreshed
+// and the request is sent again once when BIG-IP rejects the token.
+func (tm *TokenManager) Do(client *http.Client, req *http.Request) (*http.Response, error) {
+	serverURL := getServerURL(req.URL)
+	token, err := tm.getToken(client, serverURL)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(AuthTokenHeader, token)
+	resp, err := client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	resp.Body.Close()
+
+	log.Debugf("[TOKEN] Auth token rejected by BIG-IP %v, logging in again", serverURL)
+	tm.invalidateToken(serverURL, token)
+	token, err = tm.getToken(client, serverURL)
+	if err != nil {
+		return nil, err
+	}
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	retry.Header.Set(AuthTokenHeader, token)
+	return client.Do(retry)
+}
+
+// getToken returns the token of the BIG-IP, logging in when there is no valid token
+func (tm *TokenManager) getToken(client *http.Client, serverURL string) (string, error) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	if tkn, ok := tm.tokens[serverURL]; ok && time.Now().Before(tkn.expiry) {
+		return tkn.token, nil
+	}
+	tkn, err := tm.login(client, serverURL)
+	if err != nil {
+		return "", err
+	}
+	tm.tokens[serverURL] = tkn
+	return tkn.token, nil
+}
+
+// invalidateToken discards the token unless it is already refreshed
+func (tm *TokenManager) invalidateToken(serverURL, token string) {
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+	if tkn, ok := tm.tokens[serverURL]; ok && tkn.token == token {
+		delete(tm.tokens, serverURL)
+	}
+}
+
+func (tm *TokenManager) login(client *http.Client, serverURL string) (*authToken, error) {
+	body, _ := json.Marshal(loginRequest{
+		Username:          tm.username,
+		Password:          tm.password,
+		LoginProviderName: tm.loginProvider,
+	})
+	req, err := http.NewRequest("POST", serverURL+loginPath, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the auth token from BIG-IP %v: %v", serverURL, err)
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the auth token from BIG-IP %v: %v", serverURL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Failed to get the auth token from BIG-IP %v, status code %v",
+			serverURL, resp.StatusCode)
+	}
+	var loginResp loginResponse
+	if err = json.Unmarshal(respBody, &loginResp); err != nil || loginResp.Token.Token == "" {
+		return nil, fmt.Errorf("Auth token not found in the response from BIG-IP %v", serverURL)
+	}
+
+	timeout := defaultTokenTimeout
+	if loginResp.Token.Timeout > 0 {
+		timeout = time.Duration(loginResp.Token.Timeout) * time.Second
+	}
+	log.Debugf("[TOKEN] Got the auth token from BIG-IP %v, valid for %v", serverURL, timeout)
+	// Refresh the token before it expires, not to have requests rejected
+	return &authToken{
+		token:  loginResp.Token.Token,
+		expiry: time.Now().Add(timeout - timeout/10),
+	}, nil
+}
+
+func getServerURL(u *url.URL) string {
+	return u.Scheme + "://" + u.Host
+}
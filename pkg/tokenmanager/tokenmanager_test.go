@@ -0,0 +1,94 @@
+package tokenmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("TokenManager Tests", func() {
+	var server *httptest.Server
+	var tm *TokenManager
+	var logins []loginRequest
+	var validToken string
+	var bodies []string
+
+	BeforeEach(func() {
+		logins = nil
+		bodies = nil
+		validToken = ""
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == loginPath {
+				var login loginRequest
+				json.NewDecoder(r.Body).Decode(&login)
+				logins = append(logins, login)
+				if login.Password != "pass" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				validToken = fmt.Sprintf("token%d", len(logins))
+				fmt.Fprintf(w, `{"token":{"token":"%s","timeout":1200}}`, validToken)
+				return
+			}
+			if r.Header.Get(AuthTokenHeader) != validToken {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			fmt.Fprint(w, `{}`)
+		}))
+		tm = NewTokenManager("user", "pass", "radius")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("Reuses the auth token", func() {
+		for i := 0; i < 2; i++ {
+			req, _ := http.NewRequest("GET", server.URL+"/mgmt/shared/appsvcs/info", nil)
+			resp, err := tm.Do(server.Client(), req)
+			Expect(err).To(BeNil())
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		}
+		Expect(logins).To(Equal([]loginRequest{
+			{Username: "user", Password: "pass", LoginProviderName: "radius"},
+		}), "Token should be reused")
+	})
+
+	It("Refreshes the rejected auth token", func() {
+		req, _ := http.NewRequest("GET", server.URL+"/mgmt/shared/appsvcs/info", nil)
+		_, err := tm.Do(server.Client(), req)
+		Expect(err).To(BeNil())
+
+		// Token expired on BIG-IP
+		validToken = "expired"
+		req, _ = http.NewRequest("POST", server.URL+"/mgmt/shared/appsvcs/declare",
+			bytes.NewBuffer([]byte(`{"class":"AS3"}`)))
+		resp, err := tm.Do(server.Client(), req)
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(logins).To(HaveLen(2), "Token should be refreshed")
+		Expect(bodies).To(Equal([]string{"", `{"class":"AS3"}`}), "Request should be sent again with its body")
+	})
+
+	It("Fails without a token", func() {
+		tm.SetCredentials("user", "wrong")
+		req, _ := http.NewRequest("GET", server.URL+"/mgmt/shared/appsvcs/info", nil)
+		_, err := tm.Do(server.Client(), req)
+		Expect(err).NotTo(BeNil())
+
+		tm.SetCredentials("user", "pass")
+		req, _ = http.NewRequest("GET", server.URL+"/mgmt/shared/appsvcs/info", nil)
+		resp, err := tm.Do(server.Client(), req)
+		Expect(err).To(BeNil())
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+	})
+})
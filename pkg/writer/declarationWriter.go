@@ -0,0 +1,243 @@
+/*-
+ * Copyright (c) 2017-2021 F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package writer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	log "github.com/F5Networks/k8s-bigip-ctlr/pkg/vlogger"
+)
+
+const (
+	// Lines of unchanged context around each change in the diff
+	diffContext = 3
+	// Beyond this size the changed block is not compared line by line
+	maxDiffCells = 4000000
+)
+
+// DeclarationWriter writes the AS3 declarations to a directory or to stdout
+// along with the diff against the previous declaration of the same name.
+// It is used in dry-run mode to review the configuration without a BIG-IP.
+type DeclarationWriter struct {
+	sync.Mutex
+	outputDir string
+	output    io.Writer
+	lastDecls map[string][]string
+	sequence  map[string]int
+}
+
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// NewDeclarationWriter returns a writer for the output directory,
+// declarations are written to stdout when the directory is empty
+func NewDeclarationWriter(outputDir string) (*DeclarationWriter, error) {
+	if outputDir != "" {
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return nil, fmt.Errorf("could not create declaration output directory: %v", err)
+		}
+	}
+	return &DeclarationWriter{
+		outputDir: outputDir,
+		output:    os.Stdout,
+		lastDecls: make(map[string][]string),
+		sequence:  make(map[string]int),
+	}, nil
+}
+
+// Write stores the declaration as <name>-<sequence>.json along with
+// <name>-<sequence>.diff, and <name>.json always holds the latest declaration
+func (dw *DeclarationWriter) Write(name, decl string) error {
+	dw.Lock()
+	defer dw.Unlock()
+
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(decl), "", "  "); err != nil {
+		return fmt.Errorf("invalid declaration %v: %v", name, err)
+	}
+	buf.WriteString("\n")
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+
+	prevLines, found := dw.lastDecls[name]
+	var diff string
+	if found {
+		diff = formatDiff(name, diffLines(prevLines, lines))
+		if diff == "" {
+			log.Debugf("[DRY-RUN] No change in declaration %v", name)
+			return nil
+		}
+	}
+	dw.lastDecls[name] = lines
+	dw.sequence[name]++
+	seq := dw.sequence[name]
+
+	if dw.outputDir == "" {
+		fmt.Fprintf(dw.output, "### Declaration %v-%04d\n%s", name, seq, buf.String())
+		if diff != "" {
+			fmt.Fprintf(dw.output, "### Diff %v-%04d\n%s", name, seq, diff)
+		}
+		return nil
+	}
+
+	prefix := filepath.Join(dw.outputDir, fmt.Sprintf("%v-%04d", name, seq))
+	if err := ioutil.WriteFile(prefix+".json", buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	if diff != "" {
+		if err := ioutil.WriteFile(prefix+".diff", []byte(diff), 0644); err != nil {
+			return err
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(dw.outputDir, name+".json"), buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	log.Infof("[DRY-RUN] Wrote declaration %v", prefix+".json")
+	return nil
+}
+
+// diffLines returns the edit script to turn the lines a into the lines b
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	var ops []diffOp
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, lcsDiff(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+func lcsDiff(a, b []string) []diffOp {
+	var ops []diffOp
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+// formatDiff formats the edit script as an unified diff, empty without any change
+func formatDiff(name string, ops []diffOp) string {
+	var changes []int
+	for idx, op := range ops {
+		if op.kind != ' ' {
+			changes = append(changes, idx)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+	// Line numbers of both the declarations at the start of each op
+	aLine := make([]int, len(ops)+1)
+	bLine := make([]int, len(ops)+1)
+	aLine[0], bLine[0] = 1, 1
+	for idx, op := range ops {
+		aLine[idx+1], bLine[idx+1] = aLine[idx], bLine[idx]
+		if op.kind != '+' {
+			aLine[idx+1]++
+		}
+		if op.kind != '-' {
+			bLine[idx+1]++
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %v (previous)\n+++ %v\n", name, name)
+	for c := 0; c < len(changes); {
+		start := changes[c] - diffContext
+		if start < 0 {
+			start = 0
+		}
+		last := c
+		for last+1 < len(changes) && changes[last+1]-changes[last] <= 2*diffContext {
+			last++
+		}
+		end := changes[last] + diffContext + 1
+		if end > len(ops) {
+			end = len(ops)
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n",
+			aLine[start], aLine[end]-aLine[start], bLine[start], bLine[end]-bLine[start])
+		for _, op := range ops[start:end] {
+			fmt.Fprintf(&sb, "%c%s\n", op.kind, op.line)
+		}
+		c = last + 1
+	}
+	return sb.String()
+}
@@ -0,0 +1,84 @@
+/*-
+ * Copyright (c) 2017-2021 F5 Networks, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *    http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package writer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Declaration Writer Tests", func() {
+	var outputDir string
+	decl1 := `{"declaration": {"test": {"class": "Tenant", "pool": "pool1"}}}`
+	decl2 := `{"declaration": {"test": {"class": "Tenant", "pool": "pool2"}}}`
+
+	BeforeEach(func() {
+		var err error
+		outputDir, err = ioutil.TempDir("", "declaration-writer-unit-test")
+		Expect(err).To(BeNil())
+	})
+	AfterEach(func() {
+		os.RemoveAll(outputDir)
+	})
+
+	It("writes declarations and diffs to the output directory", func() {
+		dw, err := NewDeclarationWriter(outputDir)
+		Expect(err).To(BeNil())
+		Expect(dw.Write("as3", decl1)).To(BeNil())
+		Expect(filepath.Join(outputDir, "as3-0001.json")).To(BeAnExistingFile())
+		Expect(filepath.Join(outputDir, "as3-0001.diff")).NotTo(BeAnExistingFile(),
+			"First declaration should not have a diff")
+
+		// Unchanged declaration is not written again
+		Expect(dw.Write("as3", decl1)).To(BeNil())
+		Expect(filepath.Join(outputDir, "as3-0002.json")).NotTo(BeAnExistingFile())
+
+		Expect(dw.Write("as3", decl2)).To(BeNil())
+		latest, err := ioutil.ReadFile(filepath.Join(outputDir, "as3.json"))
+		Expect(err).To(BeNil())
+		Expect(string(latest)).To(ContainSubstring(`"pool": "pool2"`))
+		diff, err := ioutil.ReadFile(filepath.Join(outputDir, "as3-0002.diff"))
+		Expect(err).To(BeNil())
+		Expect(string(diff)).To(ContainSubstring(`-      "pool": "pool1"`))
+		Expect(string(diff)).To(ContainSubstring(`+      "pool": "pool2"`))
+		Expect(string(diff)).To(ContainSubstring("@@ -2,7 +2,7 @@"))
+	})
+
+	It("writes declarations to stdout without output directory", func() {
+		dw, err := NewDeclarationWriter("")
+		Expect(err).To(BeNil())
+		var out bytes.Buffer
+		dw.output = &out
+		Expect(dw.Write("as3", decl1)).To(BeNil())
+		Expect(dw.Write("as3", decl2)).To(BeNil())
+		Expect(out.String()).To(ContainSubstring("### Declaration as3-0002"))
+		Expect(out.String()).To(ContainSubstring("### Diff as3-0002"))
+		Expect(dw.Write("as3", "invalid")).NotTo(BeNil())
+	})
+
+	It("computes the line diff", func() {
+		ops := diffLines([]string{"a", "b", "c", "d"}, []string{"a", "c", "d", "e"})
+		Expect(formatDiff("test", ops)).To(Equal(
+			"--- test (previous)\n+++ test\n@@ -1,4 +1,4 @@\n a\n-b\n c\n d\n+e\n"))
+		Expect(formatDiff("test", diffLines([]string{"a"}, []string{"a"}))).To(BeEmpty())
+	})
+})